@@ -0,0 +1,115 @@
+package jvm
+
+//lint:file-ignore ST1006 MYSTYLE
+// 类、字段、方法的访问标识符。同一个位在不同的上下文中含义不同，例如 0x0020 对类为 ACC_SUPER，对方法为 ACC_SYNCHRONIZED
+
+// 访问标识符取值
+const (
+	ACC_PUBLIC       = 0x0001 // 类、字段、方法
+	ACC_PRIVATE      = 0x0002 // 字段、方法
+	ACC_PROTECTED    = 0x0004 // 字段、方法
+	ACC_STATIC       = 0x0008 // 字段、方法
+	ACC_FINAL        = 0x0010 // 类、字段、方法
+	ACC_SUPER        = 0x0020 // 类
+	ACC_SYNCHRONIZED = 0x0020 // 方法
+	ACC_VOLATILE     = 0x0040 // 字段
+	ACC_BRIDGE       = 0x0040 // 方法
+	ACC_TRANSIENT    = 0x0080 // 字段
+	ACC_VARARGS      = 0x0080 // 方法
+	ACC_NATIVE       = 0x0100 // 方法
+	ACC_INTERFACE    = 0x0200 // 类
+	ACC_ABSTRACT     = 0x0400 // 类、方法
+	ACC_STRICT       = 0x0800 // 方法
+	ACC_SYNTHETIC    = 0x1000 // 类、字段、方法
+	ACC_ANNOTATION   = 0x2000 // 类
+	ACC_ENUM         = 0x4000 // 类、字段
+	ACC_MODULE       = 0x8000 // 类
+	ACC_MANDATED     = 0x8000 // 方法参数、模块及其requires、exports、opens
+	ACC_OPEN         = 0x0020 // 模块
+	ACC_TRANSITIVE   = 0x0020 // 模块的requires
+	ACC_STATIC_PHASE = 0x0040 // 模块的requires
+)
+
+// 类的访问标识符
+type ClassAccessFlags uint16
+
+func (this ClassAccessFlags) IsPublic() bool     { return this&ACC_PUBLIC != 0 }
+func (this ClassAccessFlags) IsFinal() bool      { return this&ACC_FINAL != 0 }
+func (this ClassAccessFlags) IsSuper() bool      { return this&ACC_SUPER != 0 }
+func (this ClassAccessFlags) IsInterface() bool  { return this&ACC_INTERFACE != 0 }
+func (this ClassAccessFlags) IsAbstract() bool   { return this&ACC_ABSTRACT != 0 }
+func (this ClassAccessFlags) IsSynthetic() bool  { return this&ACC_SYNTHETIC != 0 }
+func (this ClassAccessFlags) IsAnnotation() bool { return this&ACC_ANNOTATION != 0 }
+func (this ClassAccessFlags) IsEnum() bool       { return this&ACC_ENUM != 0 }
+func (this ClassAccessFlags) IsModule() bool     { return this&ACC_MODULE != 0 }
+
+// 字段的访问标识符
+type FieldAccessFlags uint16
+
+func (this FieldAccessFlags) IsPublic() bool    { return this&ACC_PUBLIC != 0 }
+func (this FieldAccessFlags) IsPrivate() bool   { return this&ACC_PRIVATE != 0 }
+func (this FieldAccessFlags) IsProtected() bool { return this&ACC_PROTECTED != 0 }
+func (this FieldAccessFlags) IsStatic() bool    { return this&ACC_STATIC != 0 }
+func (this FieldAccessFlags) IsFinal() bool     { return this&ACC_FINAL != 0 }
+func (this FieldAccessFlags) IsVolatile() bool  { return this&ACC_VOLATILE != 0 }
+func (this FieldAccessFlags) IsTransient() bool { return this&ACC_TRANSIENT != 0 }
+func (this FieldAccessFlags) IsSynthetic() bool { return this&ACC_SYNTHETIC != 0 }
+func (this FieldAccessFlags) IsEnum() bool      { return this&ACC_ENUM != 0 }
+
+// 方法的访问标识符
+type MethodAccessFlags uint16
+
+func (this MethodAccessFlags) IsPublic() bool       { return this&ACC_PUBLIC != 0 }
+func (this MethodAccessFlags) IsPrivate() bool      { return this&ACC_PRIVATE != 0 }
+func (this MethodAccessFlags) IsProtected() bool    { return this&ACC_PROTECTED != 0 }
+func (this MethodAccessFlags) IsStatic() bool       { return this&ACC_STATIC != 0 }
+func (this MethodAccessFlags) IsFinal() bool        { return this&ACC_FINAL != 0 }
+func (this MethodAccessFlags) IsSynchronized() bool { return this&ACC_SYNCHRONIZED != 0 }
+func (this MethodAccessFlags) IsBridge() bool       { return this&ACC_BRIDGE != 0 }
+func (this MethodAccessFlags) IsVarargs() bool      { return this&ACC_VARARGS != 0 }
+func (this MethodAccessFlags) IsNative() bool       { return this&ACC_NATIVE != 0 }
+func (this MethodAccessFlags) IsAbstract() bool     { return this&ACC_ABSTRACT != 0 }
+func (this MethodAccessFlags) IsStrict() bool       { return this&ACC_STRICT != 0 }
+func (this MethodAccessFlags) IsSynthetic() bool    { return this&ACC_SYNTHETIC != 0 }
+
+// 内部类的访问标识符，记录在InnerClasses属性中
+type InnerClassAccessFlags uint16
+
+func (this InnerClassAccessFlags) IsPublic() bool     { return this&ACC_PUBLIC != 0 }
+func (this InnerClassAccessFlags) IsPrivate() bool    { return this&ACC_PRIVATE != 0 }
+func (this InnerClassAccessFlags) IsProtected() bool  { return this&ACC_PROTECTED != 0 }
+func (this InnerClassAccessFlags) IsStatic() bool     { return this&ACC_STATIC != 0 }
+func (this InnerClassAccessFlags) IsFinal() bool      { return this&ACC_FINAL != 0 }
+func (this InnerClassAccessFlags) IsInterface() bool  { return this&ACC_INTERFACE != 0 }
+func (this InnerClassAccessFlags) IsAbstract() bool   { return this&ACC_ABSTRACT != 0 }
+func (this InnerClassAccessFlags) IsSynthetic() bool  { return this&ACC_SYNTHETIC != 0 }
+func (this InnerClassAccessFlags) IsAnnotation() bool { return this&ACC_ANNOTATION != 0 }
+func (this InnerClassAccessFlags) IsEnum() bool       { return this&ACC_ENUM != 0 }
+
+// 方法参数的访问标识符，记录在MethodParameters属性中
+type ParameterAccessFlags uint16
+
+func (this ParameterAccessFlags) IsFinal() bool     { return this&ACC_FINAL != 0 }
+func (this ParameterAccessFlags) IsSynthetic() bool { return this&ACC_SYNTHETIC != 0 }
+func (this ParameterAccessFlags) IsMandated() bool  { return this&ACC_MANDATED != 0 }
+
+// 模块的标识符
+type ModuleAccessFlags uint16
+
+func (this ModuleAccessFlags) IsOpen() bool      { return this&ACC_OPEN != 0 }
+func (this ModuleAccessFlags) IsSynthetic() bool { return this&ACC_SYNTHETIC != 0 }
+func (this ModuleAccessFlags) IsMandated() bool  { return this&ACC_MANDATED != 0 }
+
+// 模块requires的标识符
+type RequiresAccessFlags uint16
+
+func (this RequiresAccessFlags) IsTransitive() bool  { return this&ACC_TRANSITIVE != 0 }
+func (this RequiresAccessFlags) IsStaticPhase() bool { return this&ACC_STATIC_PHASE != 0 }
+func (this RequiresAccessFlags) IsSynthetic() bool   { return this&ACC_SYNTHETIC != 0 }
+func (this RequiresAccessFlags) IsMandated() bool    { return this&ACC_MANDATED != 0 }
+
+// 模块exports和opens的标识符
+type ExportsAccessFlags uint16
+
+func (this ExportsAccessFlags) IsSynthetic() bool { return this&ACC_SYNTHETIC != 0 }
+func (this ExportsAccessFlags) IsMandated() bool  { return this&ACC_MANDATED != 0 }
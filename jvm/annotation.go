@@ -0,0 +1,542 @@
+package jvm
+
+//lint:file-ignore ST1006 MYSTYLE
+// 注解相关属性解析：Runtime[In]VisibleAnnotations、Runtime[In]VisibleParameterAnnotations、
+// AnnotationDefault、Runtime[In]VisibleTypeAnnotations。
+// 参考 https://docs.oracle.com/javase/specs/jvms/se21/html/jvms-4.html#jvms-4.7.16
+
+import (
+	"fmt"
+	"strings"
+)
+
+// element_value 的tag值
+const (
+	ELEMENT_Byte       = 'B'
+	ELEMENT_Char       = 'C'
+	ELEMENT_Double     = 'D'
+	ELEMENT_Float      = 'F'
+	ELEMENT_Int        = 'I'
+	ELEMENT_Long       = 'J'
+	ELEMENT_Short      = 'S'
+	ELEMENT_Boolean    = 'Z'
+	ELEMENT_String     = 's'
+	ELEMENT_Enum       = 'e'
+	ELEMENT_Class      = 'c'
+	ELEMENT_Annotation = '@'
+	ELEMENT_Array      = '['
+)
+
+// 注解
+type Annotation struct {
+	cp        ConstantPool
+	typeIndex uint16
+	elements  []*ElementValuePair
+}
+
+func readAnnotation(reader *JavaByteCodeReader, cp ConstantPool) *Annotation {
+	var annotation = &Annotation{cp: cp}
+	annotation.typeIndex = readUtf8Index(reader, cp, "type")
+	annotation.elements = make([]*ElementValuePair, reader.ReadUint16())
+	for idx := range annotation.elements {
+		var pair = &ElementValuePair{cp: cp}
+		pair.nameIndex = readUtf8Index(reader, cp, "element name")
+		pair.value = readElementValue(reader, cp)
+		annotation.elements[idx] = pair
+	}
+	return annotation
+}
+
+func readAnnotations(reader *JavaByteCodeReader, cp ConstantPool) []*Annotation {
+	var annotations = make([]*Annotation, reader.ReadUint16())
+	for idx := range annotations {
+		reader.enter("annotation #%d", idx)
+		annotations[idx] = readAnnotation(reader, cp)
+		reader.leave()
+	}
+	return annotations
+}
+
+func (this *Annotation) write(writer *JavaByteCodeWriter) {
+	writer.WriteUint16(this.typeIndex)
+	writer.writeCount(len(this.elements), "element value pairs")
+	for _, pair := range this.elements {
+		writer.WriteUint16(pair.nameIndex)
+		pair.value.write(writer)
+	}
+}
+
+func writeAnnotations(writer *JavaByteCodeWriter, annotations []*Annotation) {
+	writer.writeCount(len(annotations), "annotations")
+	for idx, annotation := range annotations {
+		writer.enter("annotation #%d", idx)
+		annotation.write(writer)
+		writer.leave()
+	}
+}
+
+// 获取注解类型的描述符，例如 Ljavax/ws/rs/Path;
+func (this *Annotation) TypeName() string { return this.cp.getUtf8(this.typeIndex) }
+
+// 获取注解的所有元素，没有显式赋值的元素（使用默认值）不会出现
+func (this *Annotation) Elements() []*ElementValuePair { return this.elements }
+
+// 按照名称获取元素的值，找不到时返回nil
+func (this *Annotation) Element(name string) *ElementValue {
+	for _, pair := range this.elements {
+		if pair.Name() == name {
+			return pair.value
+		}
+	}
+	return nil
+}
+
+func (this *Annotation) String() string {
+	var elements = make([]string, len(this.elements))
+	for idx, pair := range this.elements {
+		elements[idx] = pair.Name() + "=" + pair.value.String()
+	}
+	return "@" + this.TypeName() + "(" + strings.Join(elements, ", ") + ")"
+}
+
+// 注解的元素名称和值
+type ElementValuePair struct {
+	cp        ConstantPool
+	nameIndex uint16
+	value     *ElementValue
+}
+
+func (this *ElementValuePair) Name() string { return this.cp.getUtf8(this.nameIndex) }
+
+func (this *ElementValuePair) Value() *ElementValue { return this.value }
+
+// 枚举类型的元素值
+type EnumConstValue struct {
+	TypeName  string // 枚举类型的描述符，例如 Ljavax/persistence/FetchType;
+	ConstName string // 枚举常量名称，例如 LAZY
+}
+
+// 注解元素的值
+type ElementValue struct {
+	cp              ConstantPool
+	tag             uint8
+	constValueIndex uint16          // 基本类型和String：常量索引
+	typeNameIndex   uint16          // 枚举：类型描述符索引
+	constNameIndex  uint16          // 枚举：常量名称索引
+	classInfoIndex  uint16          // Class：返回类型描述符索引
+	annotation      *Annotation     // 嵌套的注解
+	values          []*ElementValue // 数组
+}
+
+func readElementValue(reader *JavaByteCodeReader, cp ConstantPool) *ElementValue {
+	var offset = reader.offset
+	var value = &ElementValue{cp: cp, tag: reader.ReadUint8()}
+	var checkConst = func(ok bool) {
+		if !ok {
+			reader.formatError(offset+1, "invalid const value index %d for tag '%c'", value.constValueIndex, value.tag)
+		}
+	}
+	switch value.tag {
+	case ELEMENT_Byte, ELEMENT_Char, ELEMENT_Int, ELEMENT_Short, ELEMENT_Boolean:
+		value.constValueIndex = reader.ReadUint16()
+		var _, ok = cp.Information(value.constValueIndex).(*ConstantIntegerInfo)
+		checkConst(ok)
+	case ELEMENT_Double:
+		value.constValueIndex = reader.ReadUint16()
+		var _, ok = cp.Information(value.constValueIndex).(*ConstantDoubleInfo)
+		checkConst(ok)
+	case ELEMENT_Float:
+		value.constValueIndex = reader.ReadUint16()
+		var _, ok = cp.Information(value.constValueIndex).(*ConstantFloatInfo)
+		checkConst(ok)
+	case ELEMENT_Long:
+		value.constValueIndex = reader.ReadUint16()
+		var _, ok = cp.Information(value.constValueIndex).(*ConstantLongInfo)
+		checkConst(ok)
+	case ELEMENT_String:
+		value.constValueIndex = reader.ReadUint16()
+		var _, ok = cp.lookupUtf8(value.constValueIndex)
+		checkConst(ok)
+	case ELEMENT_Enum:
+		value.typeNameIndex = readUtf8Index(reader, cp, "enum type name")
+		value.constNameIndex = readUtf8Index(reader, cp, "enum const name")
+	case ELEMENT_Class:
+		value.classInfoIndex = readUtf8Index(reader, cp, "class info")
+	case ELEMENT_Annotation:
+		value.annotation = readAnnotation(reader, cp)
+	case ELEMENT_Array:
+		value.values = make([]*ElementValue, reader.ReadUint16())
+		for idx := range value.values {
+			value.values[idx] = readElementValue(reader, cp)
+		}
+	default:
+		reader.formatError(offset, "unknown element value tag %d", value.tag)
+	}
+	return value
+}
+
+func (this *ElementValue) write(writer *JavaByteCodeWriter) {
+	writer.WriteUint8(this.tag)
+	switch this.tag {
+	case ELEMENT_Enum:
+		writer.WriteUint16(this.typeNameIndex)
+		writer.WriteUint16(this.constNameIndex)
+	case ELEMENT_Class:
+		writer.WriteUint16(this.classInfoIndex)
+	case ELEMENT_Annotation:
+		this.annotation.write(writer)
+	case ELEMENT_Array:
+		writer.writeCount(len(this.values), "array element values")
+		for _, value := range this.values {
+			value.write(writer)
+		}
+	default: // 基本类型和String
+		writer.WriteUint16(this.constValueIndex)
+	}
+}
+
+func (this *ElementValue) Tag() uint8 { return this.tag }
+
+// 获取解析后的值：
+// B => JByte, C => JChar, D => JDouble, F => JFloat, I => JInt, J => JLong, S => JShort, Z => JBoolean,
+// s => string, e => *EnumConstValue, c => 类型描述符string（例如 Ljava/lang/String; 或 V）,
+// @ => *Annotation, [ => []*ElementValue
+func (this *ElementValue) Value() interface{} {
+	switch this.tag {
+	case ELEMENT_Byte:
+		return JByte(this.intValue())
+	case ELEMENT_Char:
+		return JChar(this.intValue())
+	case ELEMENT_Short:
+		return JShort(this.intValue())
+	case ELEMENT_Boolean:
+		return JBoolean(this.intValue() != 0)
+	case ELEMENT_Int:
+		return this.intValue()
+	case ELEMENT_Double:
+		return this.cp.informations[this.constValueIndex].(*ConstantDoubleInfo).doubleValue
+	case ELEMENT_Float:
+		return this.cp.informations[this.constValueIndex].(*ConstantFloatInfo).floatValue
+	case ELEMENT_Long:
+		return this.cp.informations[this.constValueIndex].(*ConstantLongInfo).longValue
+	case ELEMENT_String:
+		return this.cp.getUtf8(this.constValueIndex)
+	case ELEMENT_Enum:
+		return &EnumConstValue{TypeName: this.cp.getUtf8(this.typeNameIndex), ConstName: this.cp.getUtf8(this.constNameIndex)}
+	case ELEMENT_Class:
+		return this.cp.getUtf8(this.classInfoIndex)
+	case ELEMENT_Annotation:
+		return this.annotation
+	default:
+		return this.values
+	}
+}
+
+func (this *ElementValue) intValue() JInt {
+	return this.cp.informations[this.constValueIndex].(*ConstantIntegerInfo).intValue
+}
+
+// 获取嵌套的注解，tag不为@时返回nil
+func (this *ElementValue) Annotation() *Annotation { return this.annotation }
+
+// 获取数组元素，tag不为[时返回nil
+func (this *ElementValue) Values() []*ElementValue { return this.values }
+
+func (this *ElementValue) String() string {
+	switch value := this.Value().(type) {
+	case string:
+		if this.tag == ELEMENT_Class {
+			return value + ".class"
+		}
+		return fmt.Sprintf("%q", value)
+	case *EnumConstValue:
+		return value.TypeName + "." + value.ConstName
+	case []*ElementValue:
+		var values = make([]string, len(value))
+		for idx, v := range value {
+			values[idx] = v.String()
+		}
+		return "{" + strings.Join(values, ", ") + "}"
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+//+--------------------------------- annotation attributes -----------------------------+
+
+// Runtime[In]VisibleAnnotations 的公共部分
+type AnnotationsAttribute struct {
+	cp          ConstantPool
+	name        string
+	length      uint32
+	annotations []*Annotation
+}
+
+func (this *AnnotationsAttribute) ReadAttribute(reader *JavaByteCodeReader) {
+	this.annotations = readAnnotations(reader, this.cp)
+}
+
+func (this *AnnotationsAttribute) WriteAttribute(writer *JavaByteCodeWriter) {
+	writeAnnotations(writer, this.annotations)
+}
+
+func (this *AnnotationsAttribute) Name() string { return this.name }
+
+func (this *AnnotationsAttribute) Annotations() []*Annotation { return this.annotations }
+
+// 运行时可见的注解（@Retention(RUNTIME)）
+type RuntimeVisibleAnnotationsAttribute struct{ AnnotationsAttribute }
+
+// 运行时不可见的注解（@Retention(CLASS)）
+type RuntimeInvisibleAnnotationsAttribute struct{ AnnotationsAttribute }
+
+// Runtime[In]VisibleParameterAnnotations 的公共部分
+type ParameterAnnotationsAttribute struct {
+	cp                   ConstantPool
+	name                 string
+	length               uint32
+	parameterAnnotations [][]*Annotation
+}
+
+func (this *ParameterAnnotationsAttribute) ReadAttribute(reader *JavaByteCodeReader) {
+	this.parameterAnnotations = make([][]*Annotation, reader.ReadUint8())
+	for idx := range this.parameterAnnotations {
+		reader.enter("parameter #%d", idx)
+		this.parameterAnnotations[idx] = readAnnotations(reader, this.cp)
+		reader.leave()
+	}
+}
+
+func (this *ParameterAnnotationsAttribute) WriteAttribute(writer *JavaByteCodeWriter) {
+	writer.writeCount8(len(this.parameterAnnotations), "parameters")
+	for idx, annotations := range this.parameterAnnotations {
+		writer.enter("parameter #%d", idx)
+		writeAnnotations(writer, annotations)
+		writer.leave()
+	}
+}
+
+func (this *ParameterAnnotationsAttribute) Name() string { return this.name }
+
+// 获取每个参数上的注解，下标为参数位置
+func (this *ParameterAnnotationsAttribute) ParameterAnnotations() [][]*Annotation {
+	return this.parameterAnnotations
+}
+
+type RuntimeVisibleParameterAnnotationsAttribute struct{ ParameterAnnotationsAttribute }
+
+type RuntimeInvisibleParameterAnnotationsAttribute struct{ ParameterAnnotationsAttribute }
+
+// 注解类型中元素的默认值
+type AnnotationDefaultAttribute struct {
+	cp           ConstantPool
+	name         string
+	length       uint32
+	defaultValue *ElementValue
+}
+
+func (this *AnnotationDefaultAttribute) ReadAttribute(reader *JavaByteCodeReader) {
+	this.defaultValue = readElementValue(reader, this.cp)
+}
+
+func (this *AnnotationDefaultAttribute) WriteAttribute(writer *JavaByteCodeWriter) {
+	this.defaultValue.write(writer)
+}
+
+func (this *AnnotationDefaultAttribute) Name() string { return this.name }
+
+func (this *AnnotationDefaultAttribute) DefaultValue() *ElementValue { return this.defaultValue }
+
+//+--------------------------------- type annotations -----------------------------+
+
+// 类型注解所在的局部变量范围
+type LocalVarTarget struct {
+	startPC uint16
+	length  uint16
+	index   uint16
+}
+
+func (this *LocalVarTarget) StartPC() uint16 { return this.startPC }
+
+func (this *LocalVarTarget) Length() uint16 { return this.length }
+
+func (this *LocalVarTarget) Index() uint16 { return this.index }
+
+// 类型路径中的一步
+type TypePathEntry struct {
+	typePathKind      uint8 // 0 数组元素，1 嵌套类型，2 通配符边界，3 类型参数
+	typeArgumentIndex uint8
+}
+
+func (this *TypePathEntry) TypePathKind() uint8 { return this.typePathKind }
+
+func (this *TypePathEntry) TypeArgumentIndex() uint8 { return this.typeArgumentIndex }
+
+// 类型注解。target_type决定了哪些target_info字段有效
+type TypeAnnotation struct {
+	targetType           uint8
+	typeParameterIndex   uint8             // 0x00 0x01 0x11 0x12
+	boundIndex           uint8             // 0x11 0x12
+	supertypeIndex       uint16            // 0x10，65535表示extends的超类
+	formalParameterIndex uint8             // 0x16
+	throwsTypeIndex      uint16            // 0x17
+	localVarTargets      []*LocalVarTarget // 0x40 0x41
+	exceptionTableIndex  uint16            // 0x42
+	offset               uint16            // 0x43 - 0x4B
+	typeArgumentIndex    uint8             // 0x47 - 0x4B
+	typePath             []*TypePathEntry
+	annotation           *Annotation
+}
+
+func readTypeAnnotation(reader *JavaByteCodeReader, cp ConstantPool) *TypeAnnotation {
+	var offset = reader.offset
+	var annotation = &TypeAnnotation{targetType: reader.ReadUint8()}
+	switch annotation.targetType {
+	case 0x00, 0x01: // type_parameter_target
+		annotation.typeParameterIndex = reader.ReadUint8()
+	case 0x10: // supertype_target
+		annotation.supertypeIndex = reader.ReadUint16()
+	case 0x11, 0x12: // type_parameter_bound_target
+		annotation.typeParameterIndex = reader.ReadUint8()
+		annotation.boundIndex = reader.ReadUint8()
+	case 0x13, 0x14, 0x15: // empty_target
+	case 0x16: // formal_parameter_target
+		annotation.formalParameterIndex = reader.ReadUint8()
+	case 0x17: // throws_target
+		annotation.throwsTypeIndex = reader.ReadUint16()
+	case 0x40, 0x41: // localvar_target
+		annotation.localVarTargets = make([]*LocalVarTarget, reader.ReadUint16())
+		for idx := range annotation.localVarTargets {
+			annotation.localVarTargets[idx] = &LocalVarTarget{
+				startPC: reader.ReadUint16(),
+				length:  reader.ReadUint16(),
+				index:   reader.ReadUint16(),
+			}
+		}
+	case 0x42: // catch_target
+		annotation.exceptionTableIndex = reader.ReadUint16()
+	case 0x43, 0x44, 0x45, 0x46: // offset_target
+		annotation.offset = reader.ReadUint16()
+	case 0x47, 0x48, 0x49, 0x4A, 0x4B: // type_argument_target
+		annotation.offset = reader.ReadUint16()
+		annotation.typeArgumentIndex = reader.ReadUint8()
+	default:
+		reader.formatError(offset, "unknown type annotation target type 0x%x", annotation.targetType)
+	}
+	annotation.typePath = make([]*TypePathEntry, reader.ReadUint8())
+	for idx := range annotation.typePath {
+		annotation.typePath[idx] = &TypePathEntry{typePathKind: reader.ReadUint8(), typeArgumentIndex: reader.ReadUint8()}
+	}
+	annotation.annotation = readAnnotation(reader, cp)
+	return annotation
+}
+
+func (this *TypeAnnotation) write(writer *JavaByteCodeWriter) {
+	writer.WriteUint8(this.targetType)
+	switch this.targetType {
+	case 0x00, 0x01:
+		writer.WriteUint8(this.typeParameterIndex)
+	case 0x10:
+		writer.WriteUint16(this.supertypeIndex)
+	case 0x11, 0x12:
+		writer.WriteUint8(this.typeParameterIndex)
+		writer.WriteUint8(this.boundIndex)
+	case 0x16:
+		writer.WriteUint8(this.formalParameterIndex)
+	case 0x17:
+		writer.WriteUint16(this.throwsTypeIndex)
+	case 0x40, 0x41:
+		writer.writeCount(len(this.localVarTargets), "local variable targets")
+		for _, target := range this.localVarTargets {
+			writer.WriteUint16(target.startPC)
+			writer.WriteUint16(target.length)
+			writer.WriteUint16(target.index)
+		}
+	case 0x42:
+		writer.WriteUint16(this.exceptionTableIndex)
+	case 0x43, 0x44, 0x45, 0x46:
+		writer.WriteUint16(this.offset)
+	case 0x47, 0x48, 0x49, 0x4A, 0x4B:
+		writer.WriteUint16(this.offset)
+		writer.WriteUint8(this.typeArgumentIndex)
+	}
+	writer.writeCount8(len(this.typePath), "type path entries")
+	for _, entry := range this.typePath {
+		writer.WriteUint8(entry.typePathKind)
+		writer.WriteUint8(entry.typeArgumentIndex)
+	}
+	this.annotation.write(writer)
+}
+
+func (this *TypeAnnotation) TargetType() uint8 { return this.targetType }
+
+func (this *TypeAnnotation) TypeParameterIndex() uint8 { return this.typeParameterIndex }
+
+func (this *TypeAnnotation) BoundIndex() uint8 { return this.boundIndex }
+
+func (this *TypeAnnotation) SupertypeIndex() uint16 { return this.supertypeIndex }
+
+func (this *TypeAnnotation) FormalParameterIndex() uint8 { return this.formalParameterIndex }
+
+func (this *TypeAnnotation) ThrowsTypeIndex() uint16 { return this.throwsTypeIndex }
+
+func (this *TypeAnnotation) LocalVarTargets() []*LocalVarTarget { return this.localVarTargets }
+
+func (this *TypeAnnotation) ExceptionTableIndex() uint16 { return this.exceptionTableIndex }
+
+// 获取注解所在指令的位置，用于 offset_target 和 type_argument_target
+func (this *TypeAnnotation) Offset() uint16 { return this.offset }
+
+func (this *TypeAnnotation) TypeArgumentIndex() uint8 { return this.typeArgumentIndex }
+
+func (this *TypeAnnotation) TypePath() []*TypePathEntry { return this.typePath }
+
+func (this *TypeAnnotation) Annotation() *Annotation { return this.annotation }
+
+// Runtime[In]VisibleTypeAnnotations 的公共部分
+type TypeAnnotationsAttribute struct {
+	cp          ConstantPool
+	name        string
+	length      uint32
+	annotations []*TypeAnnotation
+}
+
+func (this *TypeAnnotationsAttribute) ReadAttribute(reader *JavaByteCodeReader) {
+	this.annotations = make([]*TypeAnnotation, reader.ReadUint16())
+	for idx := range this.annotations {
+		reader.enter("type annotation #%d", idx)
+		this.annotations[idx] = readTypeAnnotation(reader, this.cp)
+		reader.leave()
+	}
+}
+
+func (this *TypeAnnotationsAttribute) WriteAttribute(writer *JavaByteCodeWriter) {
+	writer.writeCount(len(this.annotations), "type annotations")
+	for idx, annotation := range this.annotations {
+		writer.enter("type annotation #%d", idx)
+		annotation.write(writer)
+		writer.leave()
+	}
+}
+
+func (this *TypeAnnotationsAttribute) Name() string { return this.name }
+
+func (this *TypeAnnotationsAttribute) TypeAnnotations() []*TypeAnnotation { return this.annotations }
+
+type RuntimeVisibleTypeAnnotationsAttribute struct{ TypeAnnotationsAttribute }
+
+type RuntimeInvisibleTypeAnnotationsAttribute struct{ TypeAnnotationsAttribute }
+
+// 收集属性中运行时可见和不可见的注解
+func collectAnnotations(attributes []*Attribute) []*Annotation {
+	var annotations = make([]*Annotation, 0)
+	for _, attribute := range attributes {
+		switch attribute := (*attribute).(type) {
+		case *RuntimeVisibleAnnotationsAttribute:
+			annotations = append(annotations, attribute.annotations...)
+		case *RuntimeInvisibleAnnotationsAttribute:
+			annotations = append(annotations, attribute.annotations...)
+		}
+	}
+	return annotations
+}
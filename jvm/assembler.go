@@ -0,0 +1,667 @@
+package jvm
+
+//lint:file-ignore ST1006 MYSTYLE
+// 在Go中生成class文件：声明类、字段和方法，使用标签编写字节码，跳转偏移量在构造时自动计算。
+// 构造得到的字节码经过ParseJavaByteCode解析为JavaClass，可以直接交给类加载和WriteJavaByteCode使用。
+// 不会生成StackMapTable，因此默认使用49版本；使用50及以上的版本时，包含跳转的方法在HotSpot上需要关闭验证
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// 默认生成的class文件版本，对应 Java 5，是最后一个不要求StackMapTable的版本
+const __DEFAULT_MAJOR_VERSION__ = 49
+
+// invokedynamic和BootstrapMethods属性需要的最低版本，对应 Java 7
+const __MIN_INVOKEDYNAMIC_MAJOR_VERSION__ = 51
+
+// 类构造器，所有方法都可以链式调用，出错时记录第一个错误并在Build时返回
+type ClassBuilder struct {
+	constantPool     *ConstantPoolBuilder
+	minorVersion     uint16
+	majorVersion     uint16
+	accessFlags      uint16
+	thisClass        uint16
+	superClass       uint16 // java/lang/Object 和 module-info 为0
+	interfaces       []uint16
+	fields           []*FieldBuilder
+	methods          []*MethodBuilder
+	sourceFile       uint16 // 没有SourceFile属性时为0
+	bootstrapMethods []*BootstrapMethod
+	err              error
+}
+
+// 创建类构造器，类名使用内部形式，例如 com/acme/Hello，superName为空时没有超类
+func NewClassBuilder(accessFlags ClassAccessFlags, name string, superName string, interfaces ...string) *ClassBuilder {
+	var cp = NewConstantPoolBuilder()
+	var builder = &ClassBuilder{
+		constantPool: cp,
+		majorVersion: __DEFAULT_MAJOR_VERSION__,
+		accessFlags:  uint16(accessFlags),
+		thisClass:    cp.Class(name),
+		interfaces:   make([]uint16, len(interfaces)),
+	}
+	if superName != "" {
+		builder.superClass = cp.Class(superName)
+	}
+	for idx, iface := range interfaces {
+		builder.interfaces[idx] = cp.Class(iface)
+	}
+	return builder
+}
+
+// 记录第一个错误，structure为出错的结构，例如 method run()V: code
+func (this *ClassBuilder) fail(structure string, format string, args ...interface{}) {
+	if this.err == nil {
+		this.err = &ClassWriteError{structure: structure, message: fmt.Sprintf(format, args...)}
+	}
+}
+
+// 获取常量池，用于ldc和字段初始值等需要常量索引的地方
+func (this *ClassBuilder) ConstantPool() *ConstantPoolBuilder { return this.constantPool }
+
+// 设置class文件版本，默认为49。不会生成StackMapTable，使用50及以上的版本时需要自行保证可以通过验证
+func (this *ClassBuilder) Version(majorVersion uint16, minorVersion uint16) *ClassBuilder {
+	this.majorVersion, this.minorVersion = majorVersion, minorVersion
+	return this
+}
+
+// 添加SourceFile属性，例如 Hello.java
+func (this *ClassBuilder) SourceFile(fileName string) *ClassBuilder {
+	this.sourceFile = this.constantPool.Utf8(fileName)
+	return this
+}
+
+// 添加引导方法，handle为MethodHandle常量索引，arguments为静态参数的常量索引，返回在BootstrapMethods属性中的下标
+func (this *ClassBuilder) BootstrapMethod(handle uint16, arguments ...uint16) uint16 {
+	this.bootstrapMethods = append(this.bootstrapMethods, &BootstrapMethod{bootstrapMethodRef: handle, arguments: arguments})
+	return uint16(len(this.bootstrapMethods) - 1)
+}
+
+// 字段构造器
+type FieldBuilder struct {
+	member             *MemberInformation
+	constantValueIndex uint16 // 没有ConstantValue属性时为0
+}
+
+// 声明字段，descriptor为字段描述符，例如 I
+func (this *ClassBuilder) Field(accessFlags FieldAccessFlags, name string, descriptor string) *FieldBuilder {
+	if _, err := ParseFieldDescriptor(descriptor); err != nil {
+		this.fail("field "+name, "%s", err)
+	}
+	var field = &FieldBuilder{member: &MemberInformation{
+		accessFlags:     uint16(accessFlags),
+		nameIndex:       this.constantPool.Utf8(name),
+		descriptorIndex: this.constantPool.Utf8(descriptor),
+	}}
+	this.fields = append(this.fields, field)
+	return field
+}
+
+// 设置static final字段的初始值，index为Integer、Float、Long、Double或String常量的索引
+func (this *FieldBuilder) ConstantValue(index uint16) *FieldBuilder {
+	this.constantValueIndex = index
+	return this
+}
+
+// 方法构造器
+type MethodBuilder struct {
+	class      *ClassBuilder
+	name       string
+	descriptor string
+	member     *MemberInformation
+	arguments  uint // 参数和this占用的局部变量槽位
+	exceptions []uint16
+	code       *CodeBuilder // 抽象方法和本地方法没有Code属性
+}
+
+// 声明方法，descriptor为方法描述符，例如 ([Ljava/lang/String;)V
+func (this *ClassBuilder) Method(accessFlags MethodAccessFlags, name string, descriptor string) *MethodBuilder {
+	var method = &MethodBuilder{class: this, name: name, descriptor: descriptor, member: &MemberInformation{
+		accessFlags:     uint16(accessFlags),
+		nameIndex:       this.constantPool.Utf8(name),
+		descriptorIndex: this.constantPool.Utf8(descriptor),
+	}}
+	if parsed, err := ParseMethodDescriptor(descriptor); err != nil {
+		this.fail("method "+name+descriptor, "%s", err)
+	} else {
+		method.arguments = parsed.ArgumentSlots(accessFlags)
+	}
+	this.methods = append(this.methods, method)
+	return method
+}
+
+// 添加Exceptions属性，声明方法可能抛出的受检异常
+func (this *MethodBuilder) Throws(classNames ...string) *MethodBuilder {
+	for _, className := range classNames {
+		this.exceptions = append(this.exceptions, this.class.constantPool.Class(className))
+	}
+	return this
+}
+
+// 获取方法的代码构造器，第一次调用时创建
+func (this *MethodBuilder) Code() *CodeBuilder {
+	if this.code == nil {
+		this.code = &CodeBuilder{method: this, maxLocals: uint16(this.arguments)}
+	}
+	return this.code
+}
+
+//+--------------------------------- code builder -----------------------------+
+
+// 字节码中的位置，先创建后放置，跳转指令可以引用尚未放置的标签
+type Label struct {
+	code   *CodeBuilder
+	offset int
+	marked bool
+}
+
+// 获取标签在字节码中的位置，只有在Build之后才有意义
+func (this *Label) Offset() int { return this.offset }
+
+type codeItemKind int
+
+const (
+	itemInstruction codeItemKind = iota // 长度固定的指令
+	itemLabel                           // 标签，不占用字节
+	itemJump                            // 跳转，长度取决于偏移量
+	itemSwitch                          // tableswitch或lookupswitch，长度取决于对齐
+)
+
+// 代码中的一项
+type codeItem struct {
+	kind          codeItemKind
+	data          []byte // itemInstruction：包括操作码在内的完整字节
+	label         *Label // itemLabel：放置的标签
+	opcode        Opcode // itemJump、itemSwitch：操作码
+	target        *Label // itemJump：跳转目标
+	wide          bool   // itemJump：偏移量超出16位时使用goto_w，条件跳转改为反向条件跳过一条goto_w
+	defaultTarget *Label // itemSwitch
+	keys          []int32
+	targets       []*Label
+	offset        int // 在字节码中的位置
+}
+
+// 条件跳转的反向条件
+var invertedJumps = map[Opcode]Opcode{
+	OP_ifeq: OP_ifne, OP_ifne: OP_ifeq, OP_iflt: OP_ifge, OP_ifge: OP_iflt, OP_ifgt: OP_ifle, OP_ifle: OP_ifgt,
+	OP_if_icmpeq: OP_if_icmpne, OP_if_icmpne: OP_if_icmpeq, OP_if_icmplt: OP_if_icmpge,
+	OP_if_icmpge: OP_if_icmplt, OP_if_icmpgt: OP_if_icmple, OP_if_icmple: OP_if_icmpgt,
+	OP_if_acmpeq: OP_if_acmpne, OP_if_acmpne: OP_if_acmpeq, OP_ifnull: OP_ifnonnull, OP_ifnonnull: OP_ifnull,
+}
+
+// switch指令的操作码之后需要填充0，使default从4的倍数开始
+func switchPadding(offset int) int { return (4 - (offset+1)%4) % 4 }
+
+func (this *codeItem) size() int {
+	switch this.kind {
+	case itemLabel:
+		return 0
+	case itemJump:
+		switch {
+		case !this.wide:
+			return 3
+		case this.opcode == OP_goto || this.opcode == OP_jsr || this.opcode == OP_goto_w || this.opcode == OP_jsr_w:
+			return 5
+		default:
+			return 8
+		}
+	case itemSwitch:
+		if this.opcode == OP_tableswitch {
+			return 1 + switchPadding(this.offset) + 12 + 4*len(this.targets)
+		}
+		return 1 + switchPadding(this.offset) + 8 + 8*len(this.targets)
+	}
+	return len(this.data)
+}
+
+// 异常处理器
+type tryCatchBlock struct {
+	start, end, handler *Label
+	catchType           uint16 // 0表示捕获所有异常
+}
+
+// 代码构造器，生成方法的Code属性
+type CodeBuilder struct {
+	method    *MethodBuilder
+	items     []*codeItem
+	tryCatch  []*tryCatchBlock
+	maxStack  uint16
+	maxLocals uint16 // 至少为参数占用的槽位和使用到的局部变量槽位
+}
+
+func (this *CodeBuilder) fail(format string, args ...interface{}) {
+	this.method.class.fail("method "+this.method.name+this.method.descriptor+": code", format, args...)
+}
+
+func (this *CodeBuilder) emit(data ...byte) *CodeBuilder {
+	this.items = append(this.items, &codeItem{kind: itemInstruction, data: data})
+	return this
+}
+
+// 检查操作码是否可以由当前方法生成
+func (this *CodeBuilder) check(opcode Opcode, kind opcodeKind, method string) bool {
+	if opcode.kind() != kind {
+		this.fail("%s cannot emit %s", method, opcode)
+		return false
+	}
+	return true
+}
+
+// 记录使用到的局部变量，size为变量占用的槽位
+func (this *CodeBuilder) useLocal(index uint16, size int) {
+	if locals := int(index) + size; locals > int(this.maxLocals) {
+		this.maxLocals = uint16(locals)
+	}
+}
+
+func (this *CodeBuilder) constant(index uint16) []byte { return []byte{byte(index >> 8), byte(index)} }
+
+// 设置最大操作数栈深度和局部变量表大小，局部变量表不会小于参数和已使用的槽位
+func (this *CodeBuilder) Maxs(maxStack uint16, maxLocals uint16) *CodeBuilder {
+	this.maxStack = maxStack
+	if maxLocals > this.maxLocals {
+		this.maxLocals = maxLocals
+	}
+	return this
+}
+
+// 创建标签，需要通过Mark放置在代码中
+func (this *CodeBuilder) NewLabel() *Label { return &Label{code: this} }
+
+// 在当前位置放置标签，每个标签只能放置一次
+func (this *CodeBuilder) Mark(label *Label) *CodeBuilder {
+	if label.code != this || label.marked {
+		this.fail("label is marked twice or belongs to another method")
+		return this
+	}
+	label.marked = true
+	this.items = append(this.items, &codeItem{kind: itemLabel, label: label})
+	return this
+}
+
+// 生成没有操作数的指令，例如 iadd、aload_0、return
+func (this *CodeBuilder) Insn(opcode Opcode) *CodeBuilder {
+	if !this.check(opcode, kindNone, "Insn") {
+		return this
+	}
+	switch {
+	case opcode >= OP_iload_0 && opcode <= OP_aload_3:
+		this.useLocal(uint16(opcode-OP_iload_0)%4, localSize(OP_iload+(opcode-OP_iload_0)/4))
+	case opcode >= OP_istore_0 && opcode <= OP_astore_3:
+		this.useLocal(uint16(opcode-OP_istore_0)%4, localSize(OP_istore+(opcode-OP_istore_0)/4))
+	}
+	return this.emit(byte(opcode))
+}
+
+// 生成带有整数操作数的指令：bipush、sipush，以及操作数为数组类型（T_INT等）的newarray
+func (this *CodeBuilder) IntInsn(opcode Opcode, operand int) *CodeBuilder {
+	if !this.check(opcode, kindInt, "IntInsn") {
+		return this
+	}
+	switch {
+	case opcode == OP_bipush && operand >= math.MinInt8 && operand <= math.MaxInt8:
+		return this.emit(byte(opcode), byte(operand))
+	case opcode == OP_sipush && operand >= math.MinInt16 && operand <= math.MaxInt16:
+		return this.emit(byte(opcode), byte(operand>>8), byte(operand))
+	case opcode == OP_newarray && operand >= T_BOOLEAN && operand <= T_LONG:
+		return this.emit(byte(opcode), byte(operand))
+	}
+	this.fail("operand %d is out of range for %s", operand, opcode)
+	return this
+}
+
+// long和double类型的局部变量占用两个槽位
+func localSize(opcode Opcode) int {
+	switch opcode {
+	case OP_lload, OP_dload, OP_lstore, OP_dstore:
+		return 2
+	}
+	return 1
+}
+
+// 生成访问局部变量的指令：xload、xstore、ret，索引超过255时自动添加wide前缀
+func (this *CodeBuilder) VarInsn(opcode Opcode, index uint16) *CodeBuilder {
+	if !this.check(opcode, kindVar, "VarInsn") {
+		return this
+	}
+	this.useLocal(index, localSize(opcode))
+	if index > math.MaxUint8 {
+		return this.emit(byte(OP_wide), byte(opcode), byte(index>>8), byte(index))
+	}
+	return this.emit(byte(opcode), byte(index))
+}
+
+// 生成iinc，索引或增量超出一个字节时自动添加wide前缀
+func (this *CodeBuilder) Iinc(index uint16, increment int16) *CodeBuilder {
+	this.useLocal(index, 1)
+	if index > math.MaxUint8 || increment < math.MinInt8 || increment > math.MaxInt8 {
+		return this.emit(byte(OP_wide), byte(OP_iinc), byte(index>>8), byte(index), byte(increment>>8), byte(increment))
+	}
+	return this.emit(byte(OP_iinc), byte(index), byte(increment))
+}
+
+// 生成跳转指令，偏移量超出16位时goto和jsr改为goto_w和jsr_w，条件跳转改为反向条件跳过一条goto_w
+func (this *CodeBuilder) JumpInsn(opcode Opcode, target *Label) *CodeBuilder {
+	if !this.check(opcode, kindJump, "JumpInsn") {
+		return this
+	}
+	var wide = opcode == OP_goto_w || opcode == OP_jsr_w
+	this.items = append(this.items, &codeItem{kind: itemJump, opcode: opcode, target: target, wide: wide})
+	return this
+}
+
+// 生成操作数为类的指令：new、anewarray、checkcast、instanceof
+func (this *CodeBuilder) TypeInsn(opcode Opcode, className string) *CodeBuilder {
+	if !this.check(opcode, kindType, "TypeInsn") {
+		return this
+	}
+	return this.emit(append([]byte{byte(opcode)}, this.constant(this.method.class.constantPool.Class(className))...)...)
+}
+
+// 生成字段访问指令：getstatic、putstatic、getfield、putfield
+func (this *CodeBuilder) FieldInsn(opcode Opcode, owner string, name string, descriptor string) *CodeBuilder {
+	if !this.check(opcode, kindField, "FieldInsn") {
+		return this
+	}
+	var fieldref = this.method.class.constantPool.Fieldref(owner, name, descriptor)
+	return this.emit(append([]byte{byte(opcode)}, this.constant(fieldref)...)...)
+}
+
+// 生成方法调用指令，isInterface表示owner是接口，invokeinterface总是引用InterfaceMethodref
+func (this *CodeBuilder) MethodInsn(opcode Opcode, owner string, name string, descriptor string, isInterface bool) *CodeBuilder {
+	if !this.check(opcode, kindMethod, "MethodInsn") {
+		return this
+	}
+	var cp = this.method.class.constantPool
+	if opcode != OP_invokeinterface {
+		var methodref = cp.Methodref(owner, name, descriptor)
+		if isInterface {
+			methodref = cp.InterfaceMethodref(owner, name, descriptor)
+		}
+		return this.emit(append([]byte{byte(opcode)}, this.constant(methodref)...)...)
+	}
+	var parsed, err = ParseMethodDescriptor(descriptor)
+	if err != nil {
+		this.fail("%s", err)
+		return this
+	}
+	// invokeinterface的count为参数和this占用的槽位，之后是一个0
+	var data = append([]byte{byte(opcode)}, this.constant(cp.InterfaceMethodref(owner, name, descriptor))...)
+	return this.emit(append(data, byte(parsed.ParameterSlots()+1), 0)...)
+}
+
+// 生成invokedynamic，bootstrapMethod为ClassBuilder.BootstrapMethod返回的下标
+func (this *CodeBuilder) InvokeDynamic(bootstrapMethod uint16, name string, descriptor string) *CodeBuilder {
+	var callSite = this.method.class.constantPool.InvokeDynamic(bootstrapMethod, name, descriptor)
+	return this.emit(append([]byte{byte(OP_invokedynamic)}, this.constant(callSite)...)...)
+}
+
+// 加载常量，index为可加载常量的索引。long和double使用ldc2_w，索引超过255时使用ldc_w
+func (this *CodeBuilder) Ldc(index uint16) *CodeBuilder {
+	switch this.method.class.constantPool.loadable[index] {
+	case 1:
+		if index <= math.MaxUint8 {
+			return this.emit(byte(OP_ldc), byte(index))
+		}
+		return this.emit(append([]byte{byte(OP_ldc_w)}, this.constant(index)...)...)
+	case 2:
+		return this.emit(append([]byte{byte(OP_ldc2_w)}, this.constant(index)...)...)
+	}
+	this.fail("constant #%d is not loadable", index)
+	return this
+}
+
+// 生成tableswitch，targets依次对应 low、low+1 ...
+func (this *CodeBuilder) TableSwitch(low int32, defaultTarget *Label, targets ...*Label) *CodeBuilder {
+	if int64(low)+int64(len(targets))-1 > math.MaxInt32 {
+		this.fail("tableswitch range overflows")
+		return this
+	}
+	var keys = make([]int32, len(targets))
+	for idx := range keys {
+		keys[idx] = low + int32(idx)
+	}
+	this.items = append(this.items, &codeItem{kind: itemSwitch, opcode: OP_tableswitch, defaultTarget: defaultTarget, keys: keys, targets: targets})
+	return this
+}
+
+// 生成lookupswitch，keys与targets一一对应，写出时按照key排序
+func (this *CodeBuilder) LookupSwitch(defaultTarget *Label, keys []int32, targets []*Label) *CodeBuilder {
+	if len(keys) != len(targets) {
+		this.fail("lookupswitch has %d keys but %d targets", len(keys), len(targets))
+		return this
+	}
+	var item = &codeItem{kind: itemSwitch, opcode: OP_lookupswitch, defaultTarget: defaultTarget,
+		keys: append([]int32(nil), keys...), targets: append([]*Label(nil), targets...)}
+	sort.Sort(lookupSwitchPairs{item})
+	for idx := 1; idx < len(item.keys); idx++ {
+		if item.keys[idx] == item.keys[idx-1] {
+			this.fail("lookupswitch has duplicate key %d", item.keys[idx])
+			return this
+		}
+	}
+	this.items = append(this.items, item)
+	return this
+}
+
+// 按照key对lookupswitch的匹配项排序
+type lookupSwitchPairs struct{ *codeItem }
+
+func (this lookupSwitchPairs) Len() int { return len(this.keys) }
+
+func (this lookupSwitchPairs) Less(i, j int) bool { return this.keys[i] < this.keys[j] }
+
+func (this lookupSwitchPairs) Swap(i, j int) {
+	this.keys[i], this.keys[j] = this.keys[j], this.keys[i]
+	this.targets[i], this.targets[j] = this.targets[j], this.targets[i]
+}
+
+// 生成multianewarray，descriptor为数组类型，例如 [[I
+func (this *CodeBuilder) MultiANewArray(descriptor string, dimensions uint8) *CodeBuilder {
+	var data = append([]byte{byte(OP_multianewarray)}, this.constant(this.method.class.constantPool.Class(descriptor))...)
+	return this.emit(append(data, dimensions)...)
+}
+
+// 添加异常处理器，[start, end) 范围内抛出exceptionClass时跳转到handler，exceptionClass为空时捕获所有异常
+func (this *CodeBuilder) TryCatch(start *Label, end *Label, handler *Label, exceptionClass string) *CodeBuilder {
+	var block = &tryCatchBlock{start: start, end: end, handler: handler}
+	if exceptionClass != "" {
+		block.catchType = this.method.class.constantPool.Class(exceptionClass)
+	}
+	this.tryCatch = append(this.tryCatch, block)
+	return this
+}
+
+// 计算每一项的位置。跳转默认使用16位偏移量，超出范围时加宽后重新计算，直到不再变化
+func (this *CodeBuilder) layout() int {
+	for {
+		var offset = 0
+		for _, item := range this.items {
+			item.offset = offset
+			if item.kind == itemLabel {
+				item.label.offset = offset
+			}
+			offset += item.size()
+		}
+		var widened = false
+		for _, item := range this.items {
+			if item.kind != itemJump || item.wide {
+				continue
+			}
+			if delta := item.target.offset - item.offset; delta < math.MinInt16 || delta > math.MaxInt16 {
+				item.wide, widened = true, true
+			}
+		}
+		if !widened {
+			return offset
+		}
+	}
+}
+
+// 检查标签都已经放置
+func (this *CodeBuilder) checkLabels() bool {
+	var check = func(label *Label) bool {
+		if label == nil || label.code != this || !label.marked {
+			this.fail("label is not marked")
+			return false
+		}
+		return true
+	}
+	for _, item := range this.items {
+		if item.kind == itemJump && !check(item.target) {
+			return false
+		}
+		if item.kind == itemSwitch {
+			for _, target := range append([]*Label{item.defaultTarget}, item.targets...) {
+				if !check(target) {
+					return false
+				}
+			}
+		}
+	}
+	for _, block := range this.tryCatch {
+		if !check(block.start) || !check(block.end) || !check(block.handler) {
+			return false
+		}
+	}
+	return true
+}
+
+// 生成Code属性，出错时返回nil
+func (this *CodeBuilder) assemble() *CodeAttribute {
+	if !this.checkLabels() {
+		return nil
+	}
+	var length = this.layout()
+	if length == 0 || length > math.MaxUint16 {
+		this.fail("code length %d is out of range", length)
+		return nil
+	}
+	var writer = newJavaByteCodeWriter(nil)
+	for _, item := range this.items {
+		switch item.kind {
+		case itemInstruction:
+			writer.WriteBytes(item.data)
+		case itemJump:
+			var delta = item.target.offset - item.offset
+			switch {
+			case !item.wide:
+				writer.WriteUint8(uint8(item.opcode))
+				writer.WriteUint16(uint16(delta))
+			case item.opcode == OP_goto || item.opcode == OP_goto_w:
+				writer.WriteUint8(uint8(OP_goto_w))
+				writer.WriteUint32(uint32(delta))
+			case item.opcode == OP_jsr || item.opcode == OP_jsr_w:
+				writer.WriteUint8(uint8(OP_jsr_w))
+				writer.WriteUint32(uint32(delta))
+			default:
+				// if<cond> L => if<!cond> +8; goto_w L
+				writer.WriteUint8(uint8(invertedJumps[item.opcode]))
+				writer.WriteUint16(8)
+				writer.WriteUint8(uint8(OP_goto_w))
+				writer.WriteUint32(uint32(delta - 3))
+			}
+		case itemSwitch:
+			writer.WriteUint8(uint8(item.opcode))
+			writer.WriteBytes(make([]byte, switchPadding(item.offset)))
+			writer.WriteUint32(uint32(item.defaultTarget.offset - item.offset))
+			if item.opcode == OP_tableswitch {
+				var low = int32(0)
+				if len(item.keys) > 0 {
+					low = item.keys[0]
+				}
+				writer.WriteUint32(uint32(low))
+				writer.WriteUint32(uint32(low + int32(len(item.keys)) - 1))
+				for _, target := range item.targets {
+					writer.WriteUint32(uint32(target.offset - item.offset))
+				}
+			} else {
+				writer.WriteUint32(uint32(len(item.keys)))
+				for idx, target := range item.targets {
+					writer.WriteUint32(uint32(item.keys[idx]))
+					writer.WriteUint32(uint32(target.offset - item.offset))
+				}
+			}
+		}
+	}
+	var code = &CodeAttribute{name: CODE, maxStack: this.maxStack, maxLocals: this.maxLocals, code: writer.Bytes()}
+	for _, block := range this.tryCatch {
+		if block.start.offset >= block.end.offset {
+			this.fail("exception handler range [%d, %d) is empty", block.start.offset, block.end.offset)
+			return nil
+		}
+		code.exceptionTables = append(code.exceptionTables, &ExceptionTable{
+			startPC:   uint16(block.start.offset),
+			endPC:     uint16(block.end.offset),
+			handlerPC: uint16(block.handler.offset),
+			catchType: block.catchType,
+		})
+	}
+	return code
+}
+
+//+--------------------------------- build -----------------------------+
+
+func attributeOf(attribute Attribute) *Attribute { return &attribute }
+
+// 生成class文件的字节码
+func (this *ClassBuilder) Bytes() ([]byte, error) {
+	var javaClass = &JavaClass{
+		magic:          0xCAFEBABE,
+		minorVersion:   this.minorVersion,
+		majorVersion:   this.majorVersion,
+		accessFlags:    this.accessFlags,
+		thisClass:      this.thisClass,
+		superClass:     this.superClass,
+		interfaceClass: this.interfaces,
+	}
+	for _, field := range this.fields {
+		field.member.attributes = nil
+		if field.constantValueIndex != 0 {
+			field.member.attributes = []*Attribute{attributeOf(&ConstantValueAttribute{name: CONSTANT_VALUE, constantValueIndex: field.constantValueIndex})}
+		}
+		javaClass.fields = append(javaClass.fields, field.member)
+	}
+	for _, method := range this.methods {
+		method.member.attributes = nil
+		var accessFlags = MethodAccessFlags(method.member.accessFlags)
+		if method.code != nil {
+			if accessFlags.IsAbstract() || accessFlags.IsNative() {
+				this.fail("method "+method.name+method.descriptor, "abstract or native method cannot have code")
+			} else if code := method.code.assemble(); code != nil {
+				method.member.attributes = append(method.member.attributes, attributeOf(code))
+			}
+		} else if !accessFlags.IsAbstract() && !accessFlags.IsNative() {
+			this.fail("method "+method.name+method.descriptor, "missing code")
+		}
+		if len(method.exceptions) > 0 {
+			method.member.attributes = append(method.member.attributes, attributeOf(&ExceptionsAttribute{name: EXCEPTIONS, exceptionIndexTable: method.exceptions}))
+		}
+		javaClass.methods = append(javaClass.methods, method.member)
+	}
+	if this.sourceFile != 0 {
+		javaClass.attributes = append(javaClass.attributes, attributeOf(&SourceFileAttribute{name: SOURCE_FILE, sourceFileIndex: this.sourceFile}))
+	}
+	if len(this.bootstrapMethods) > 0 {
+		if this.majorVersion < __MIN_INVOKEDYNAMIC_MAJOR_VERSION__ {
+			this.fail(BOOTSTRAP_METHODS, "requires class file version %d or above, got %d", __MIN_INVOKEDYNAMIC_MAJOR_VERSION__, this.majorVersion)
+		}
+		javaClass.attributes = append(javaClass.attributes, attributeOf(&BootstrapMethodsAttribute{name: BOOTSTRAP_METHODS, methods: this.bootstrapMethods}))
+	}
+	if this.err != nil {
+		return nil, this.err
+	}
+	return javaClass.serialize(this.constantPool)
+}
+
+// 生成class文件并解析为JavaClass，常量引用等错误在解析时以 *ClassFormatError 返回
+func (this *ClassBuilder) Build() (*JavaClass, error) {
+	var bytecode, err = this.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return ParseJavaByteCode(bytecode)
+}
@@ -0,0 +1,117 @@
+package jvm
+
+//lint:file-ignore ST1006 MYSTYLE
+// BootstrapMethods 属性解析。invokedynamic指令和动态常量（CONSTANT_InvokeDynamic、CONSTANT_Dynamic）
+// 通过bootstrap_method_attr_index找到引导方法及其静态参数，lambda表达式和字符串拼接都依赖它
+
+// 引导方法
+type BootstrapMethod struct {
+	cp                 ConstantPool
+	bootstrapMethodRef uint16   // MethodHandle常量索引
+	arguments          []uint16 // 静态参数的常量索引
+}
+
+// 获取引导方法的方法句柄，例如 LambdaMetafactory.metafactory
+func (this *BootstrapMethod) MethodHandle() *ConstantMethodHandleInfo {
+	return this.cp.informations[this.bootstrapMethodRef].(*ConstantMethodHandleInfo)
+}
+
+// 获取静态参数的常量索引
+func (this *BootstrapMethod) ArgumentIndexes() []uint16 { return this.arguments }
+
+// 获取静态参数，为可加载的常量：Integer、Float、Long、Double、Class、String、MethodHandle、MethodType 或 Dynamic
+func (this *BootstrapMethod) Arguments() []ConstantInformation {
+	var arguments = make([]ConstantInformation, len(this.arguments))
+	for idx, argument := range this.arguments {
+		arguments[idx] = this.cp.informations[argument]
+	}
+	return arguments
+}
+
+// 判断常量是否可以作为ldc或引导方法的静态参数
+func isLoadableConstant(information ConstantInformation) bool {
+	switch information.(type) {
+	case *ConstantIntegerInfo, *ConstantFloatInfo, *ConstantLongInfo, *ConstantDoubleInfo,
+		*ConstantClassInfo, *ConstantStringInfo, *ConstantMethodHandleInfo, *ConstantMethodTypeInfo,
+		*ConstantDynamicInfo:
+		return true
+	}
+	return false
+}
+
+type BootstrapMethodsAttribute struct {
+	cp      ConstantPool
+	name    string
+	length  uint32
+	methods []*BootstrapMethod
+}
+
+func (this *BootstrapMethodsAttribute) ReadAttribute(reader *JavaByteCodeReader) {
+	this.methods = make([]*BootstrapMethod, reader.ReadUint16())
+	for idx := range this.methods {
+		reader.enter("bootstrap method #%d", idx)
+		var method = &BootstrapMethod{cp: this.cp}
+		var offset = reader.offset
+		method.bootstrapMethodRef = reader.ReadUint16()
+		if _, ok := this.cp.Information(method.bootstrapMethodRef).(*ConstantMethodHandleInfo); !ok {
+			reader.formatError(offset, "invalid bootstrap method ref %d", method.bootstrapMethodRef)
+		}
+		method.arguments = make([]uint16, reader.ReadUint16())
+		for i := range method.arguments {
+			offset = reader.offset
+			method.arguments[i] = reader.ReadUint16()
+			if !isLoadableConstant(this.cp.Information(method.arguments[i])) {
+				reader.formatError(offset, "invalid bootstrap argument index %d", method.arguments[i])
+			}
+		}
+		this.methods[idx] = method
+		reader.leave()
+	}
+}
+
+func (this *BootstrapMethodsAttribute) WriteAttribute(writer *JavaByteCodeWriter) {
+	writer.writeCount(len(this.methods), "bootstrap methods")
+	for idx, method := range this.methods {
+		writer.enter("bootstrap method #%d", idx)
+		writer.WriteUint16(method.bootstrapMethodRef)
+		writer.WriteUint16s(method.arguments)
+		writer.leave()
+	}
+}
+
+func (this *BootstrapMethodsAttribute) Name() string { return this.name }
+
+func (this *BootstrapMethodsAttribute) Methods() []*BootstrapMethod { return this.methods }
+
+// 获取类的引导方法，没有BootstrapMethods属性时返回nil
+func (this *JavaClass) BootstrapMethods() []*BootstrapMethod {
+	for _, attribute := range this.attributes {
+		if bootstrapMethods, ok := (*attribute).(*BootstrapMethodsAttribute); ok {
+			return bootstrapMethods.methods
+		}
+	}
+	return nil
+}
+
+// 将常量池中的动态常量关联到对应的引导方法，offsets为每个常量在class文件中的偏移量
+func (this *JavaClass) linkBootstrapMethods(reader *JavaByteCodeReader, offsets []int) {
+	var methods = this.BootstrapMethods()
+	var link = func(idx int, attrIndex uint16) *BootstrapMethod {
+		if int(attrIndex) >= len(methods) {
+			reader.enter("constant pool entry #%d", idx)
+			if methods == nil {
+				reader.formatError(offsets[idx], "missing BootstrapMethods attribute")
+			}
+			reader.formatError(offsets[idx]+1, "invalid bootstrap method attr index %d", attrIndex)
+		}
+		return methods[attrIndex]
+	}
+	for idx, information := range this.constantPool.informations {
+		switch info := information.(type) {
+		case *ConstantInvokeDynamicInfo:
+			info.bootstrapMethod = link(idx, info.bootstrapMethodAttrIndex)
+		case *ConstantDynamicInfo:
+			info.bootstrapMethod = link(idx, info.bootstrapMethodAttrIndex)
+		}
+	}
+}
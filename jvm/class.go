@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math"
+	"strings"
 	"unicode/utf16"
 )
 
@@ -13,36 +14,68 @@ import (
 
 // Java字节码读取
 type JavaByteCodeReader struct {
-	bytecode []byte
+	bytecode   []byte
+	offset     int      // 当前位置相对class文件开头的偏移量
+	structures []string // 正在解析的结构，由外到内，用于错误信息
 }
 
-// 读取8位无符号整数 1 Byte
-func (this *JavaByteCodeReader) ReadUint8() uint8 {
-	var res = this.bytecode[0]
+// 进入一个结构，例如 constant pool entry #37
+func (this *JavaByteCodeReader) enter(format string, args ...interface{}) {
+	this.structures = append(this.structures, fmt.Sprintf(format, args...))
+}
+
+// 离开当前结构
+func (this *JavaByteCodeReader) leave() {
+	this.structures = this.structures[:len(this.structures)-1]
+}
+
+// 描述正在解析的结构
+func (this *JavaByteCodeReader) structure() string {
+	if len(this.structures) == 0 {
+		return "class file"
+	}
+	return strings.Join(this.structures, ": ")
+}
+
+func (this *JavaByteCodeReader) parseError(offset int) classParseError {
+	return classParseError{offset: offset, structure: this.structure()}
+}
+
+// 抛出class格式错误，由ParseJavaByteCode转换为error返回
+func (this *JavaByteCodeReader) formatError(offset int, format string, args ...interface{}) {
+	panic(&ClassFormatError{classParseError: this.parseError(offset), message: fmt.Sprintf(format, args...)})
+}
+
+// 读取size个字节，剩余字节不足时抛出截断错误
+func (this *JavaByteCodeReader) next(size uint32) []byte {
+	if uint64(size) > uint64(len(this.bytecode)) {
+		panic(&ClassTruncatedError{classParseError: this.parseError(this.offset), need: size, left: len(this.bytecode)})
+	}
+	var res = this.bytecode[:size]
 	// 利用切片的方式，左移bytecode
-	this.bytecode = this.bytecode[1:]
+	this.bytecode = this.bytecode[size:]
+	this.offset += int(size)
 	return res
 }
 
+// 读取8位无符号整数 1 Byte
+func (this *JavaByteCodeReader) ReadUint8() uint8 {
+	return this.next(1)[0]
+}
+
 // 读取16位无符号整数 2 Bytes
 func (this *JavaByteCodeReader) ReadUint16() uint16 {
-	var res = binary.BigEndian.Uint16(this.bytecode)
-	this.bytecode = this.bytecode[2:]
-	return res
+	return binary.BigEndian.Uint16(this.next(2))
 }
 
 // 读取32位无符号整数 4 Bytes
 func (this *JavaByteCodeReader) ReadUint32() uint32 {
-	var res = binary.BigEndian.Uint32(this.bytecode)
-	this.bytecode = this.bytecode[4:]
-	return res
+	return binary.BigEndian.Uint32(this.next(4))
 }
 
 // 读取64位无符号整数 8 Bytes
 func (this *JavaByteCodeReader) ReadUint64() uint64 {
-	var res = binary.BigEndian.Uint64(this.bytecode)
-	this.bytecode = this.bytecode[8:]
-	return res
+	return binary.BigEndian.Uint64(this.next(8))
 }
 
 // 读取uint16的数组，数组大小由开头数值决定
@@ -57,9 +90,64 @@ func (this *JavaByteCodeReader) ReadUint16s() []uint16 {
 
 // 读取制定大小字节的数据
 func (this *JavaByteCodeReader) ReadBytes(size uint32) []byte {
-	var res = this.bytecode[:size]
-	this.bytecode = this.bytecode[size:]
-	return res
+	return this.next(size)
+}
+
+//+--------------------------------- class file parse errors -----------------------------+
+
+// 解析错误的公共部分：出错位置的字节偏移量和正在解析的结构
+type classParseError struct {
+	offset    int
+	structure string
+}
+
+// 出错位置相对class文件开头的偏移量
+func (this classParseError) Offset() int { return this.offset }
+
+// 出错时正在解析的结构，例如 method #2: attribute Code
+func (this classParseError) Structure() string { return this.structure }
+
+// class文件格式错误，对应 java.lang.ClassFormatError
+type ClassFormatError struct {
+	classParseError
+	message string
+}
+
+func (this *ClassFormatError) Error() string {
+	return fmt.Sprintf("%s: %s at offset 0x%x", this.structure, this.message, this.offset)
+}
+
+// class文件版本不受支持，对应 java.lang.UnsupportedClassVersionError
+type UnsupportedClassVersionError struct {
+	classParseError
+	majorVersion   uint16
+	minorVersion   uint16
+	previewEnabled bool
+}
+
+func (this *UnsupportedClassVersionError) Error() string {
+	var hint = ""
+	if this.minorVersion == __PREVIEW_MINOR_VERSION__ && !this.previewEnabled {
+		hint = " (preview features are not enabled, try --enable-preview)"
+	}
+	return fmt.Sprintf("%s: unsupported class file version %d.%d%s at offset 0x%x",
+		this.structure, this.majorVersion, this.minorVersion, hint, this.offset)
+}
+
+func (this *UnsupportedClassVersionError) MajorVersion() uint16 { return this.majorVersion }
+
+func (this *UnsupportedClassVersionError) MinorVersion() uint16 { return this.minorVersion }
+
+// class文件在结构完整之前就结束了
+type ClassTruncatedError struct {
+	classParseError
+	need uint32 // 需要读取的字节数
+	left int    // 剩余的字节数
+}
+
+func (this *ClassTruncatedError) Error() string {
+	return fmt.Sprintf("%s: truncated class file, need %d bytes but %d left at offset 0x%x",
+		this.structure, this.need, this.left, this.offset)
 }
 
 // 常量池tag值定义
@@ -78,8 +166,28 @@ const (
 	CONSTANT_MethodHandle       = 15
 	CONSTANT_MethodType         = 16
 	CONSTANT_InvokeDynamic      = 18
+	CONSTANT_Dynamic            = 17
+	CONSTANT_Module             = 19
+	CONSTANT_Package            = 20
 )
 
+// gava支持的最高class文件主版本号，对应 Java 21
+const __MAX_MAJOR_VERSION__ = 65
+
+// 从该主版本号（Java 12）开始，副版本号只能为0或者表示预览特性的0xFFFF
+const __MIN_PREVIEW_MAJOR_VERSION__ = 56
+
+// 依赖预览特性的class文件的副版本号
+const __PREVIEW_MINOR_VERSION__ = 0xFFFF
+
+// 解析class文件的选项
+type ParseOptions struct {
+	EnablePreview bool // 是否允许依赖预览特性的class文件，对应 --enable-preview
+}
+
+// 主版本号与Java版本之间的差值，例如 52 - 44 = 8
+const __MAJOR_VERSION_OFFSET__ = 44
+
 // JVM预定义属性名称
 const (
 	CODE                 = "Code"
@@ -90,11 +198,38 @@ const (
 	LOCAL_VARIABLE_TABLE = "LocalVariableTable"
 	SOURCE_FILE          = "SourceFile"
 	SYNTHETIC            = "Synthetic"
+	STACK_MAP_TABLE      = "StackMapTable"
+
+	RUNTIME_VISIBLE_ANNOTATIONS             = "RuntimeVisibleAnnotations"
+	RUNTIME_INVISIBLE_ANNOTATIONS           = "RuntimeInvisibleAnnotations"
+	RUNTIME_VISIBLE_PARAMETER_ANNOTATIONS   = "RuntimeVisibleParameterAnnotations"
+	RUNTIME_INVISIBLE_PARAMETER_ANNOTATIONS = "RuntimeInvisibleParameterAnnotations"
+	RUNTIME_VISIBLE_TYPE_ANNOTATIONS        = "RuntimeVisibleTypeAnnotations"
+	RUNTIME_INVISIBLE_TYPE_ANNOTATIONS      = "RuntimeInvisibleTypeAnnotations"
+	ANNOTATION_DEFAULT                      = "AnnotationDefault"
+	BOOTSTRAP_METHODS                       = "BootstrapMethods"
+
+	SIGNATURE                 = "Signature"
+	LOCAL_VARIABLE_TYPE_TABLE = "LocalVariableTypeTable"
+	METHOD_PARAMETERS         = "MethodParameters"
+	SOURCE_DEBUG_EXTENSION    = "SourceDebugExtension"
+
+	INNER_CLASSES        = "InnerClasses"
+	ENCLOSING_METHOD     = "EnclosingMethod"
+	NEST_HOST            = "NestHost"
+	NEST_MEMBERS         = "NestMembers"
+	PERMITTED_SUBCLASSES = "PermittedSubclasses"
+	RECORD               = "Record"
+
+	MODULE            = "Module"
+	MODULE_PACKAGES   = "ModulePackages"
+	MODULE_MAIN_CLASS = "ModuleMainClass"
 )
 
 // 常量信息接口定义
 type ConstantInformation interface {
 	ReadInformation(reader *JavaByteCodeReader)
+	WriteInformation(writer *JavaByteCodeWriter) // 写入tag之后的内容
 }
 
 // 派生常量信息
@@ -110,6 +245,10 @@ func (this *ConstantIntegerInfo) ReadInformation(reader *JavaByteCodeReader) {
 	this.intValue = JInt(val)
 }
 
+func (this *ConstantIntegerInfo) WriteInformation(writer *JavaByteCodeWriter) {
+	writer.WriteUint32(uint32(this.intValue))
+}
+
 // 浮点数常量信息
 type ConstantFloatInfo struct {
 	floatValue JFloat
@@ -121,6 +260,10 @@ func (this *ConstantFloatInfo) ReadInformation(reader *JavaByteCodeReader) {
 	this.floatValue = JFloat(float)
 }
 
+func (this *ConstantFloatInfo) WriteInformation(writer *JavaByteCodeWriter) {
+	writer.WriteUint32(math.Float32bits(float32(this.floatValue)))
+}
+
 // 长整形常量信息
 type ConstantLongInfo struct {
 	longValue JLong
@@ -131,6 +274,10 @@ func (this *ConstantLongInfo) ReadInformation(reader *JavaByteCodeReader) {
 	this.longValue = JLong(bits)
 }
 
+func (this *ConstantLongInfo) WriteInformation(writer *JavaByteCodeWriter) {
+	writer.WriteUint64(uint64(this.longValue))
+}
+
 // 双精度浮点型常量信息
 type ConstantDoubleInfo struct {
 	doubleValue JDouble
@@ -142,11 +289,20 @@ func (this *ConstantDoubleInfo) ReadInformation(reader *JavaByteCodeReader) {
 	this.doubleValue = JDouble(double)
 }
 
+func (this *ConstantDoubleInfo) WriteInformation(writer *JavaByteCodeWriter) {
+	writer.WriteUint64(math.Float64bits(float64(this.doubleValue)))
+}
+
 // Utf8字符串常量信息. Class文件中的UTF8字串采用了MUTF8的编码格式，这里在解码时，需要使用MUTF8
 type ConstantUtf8Info struct {
 	stringValue string
 }
 
+// MUTF8解码失败，解析class文件时转换为 *ClassFormatError
+type mutf8DecodeError struct{ message string }
+
+func (this *mutf8DecodeError) Error() string { return this.message }
+
 // Copy 作者源码 => https://github.com/zxh0/jvmgo-book/blob/master/v1/code/go/src/jvmgo/ch03/classfile/cp_utf8.go
 // 解析MUTF8
 func __decodeMUtf8(bytearr []byte) string {
@@ -179,11 +335,11 @@ func __decodeMUtf8(bytearr []byte) string {
 			/* 110x xxxx   10xx xxxx*/
 			count += 2
 			if count > utflen {
-				panic("malformed input: partial character at end")
+				panic(&mutf8DecodeError{"malformed input: partial character at end"})
 			}
 			char2 = uint16(bytearr[count-1])
 			if char2&0xC0 != 0x80 {
-				panic(fmt.Errorf("malformed input around byte %v", count))
+				panic(&mutf8DecodeError{fmt.Sprintf("malformed input around byte %v", count)})
 			}
 			chararr[chararr_count] = c&0x1F<<6 | char2&0x3F
 			chararr_count++
@@ -191,18 +347,18 @@ func __decodeMUtf8(bytearr []byte) string {
 			/* 1110 xxxx  10xx xxxx  10xx xxxx*/
 			count += 3
 			if count > utflen {
-				panic("malformed input: partial character at end")
+				panic(&mutf8DecodeError{"malformed input: partial character at end"})
 			}
 			char2 = uint16(bytearr[count-2])
 			char3 = uint16(bytearr[count-1])
 			if char2&0xC0 != 0x80 || char3&0xC0 != 0x80 {
-				panic(fmt.Errorf("malformed input around byte %v", (count - 1)))
+				panic(&mutf8DecodeError{fmt.Sprintf("malformed input around byte %v", (count - 1))})
 			}
 			chararr[chararr_count] = c&0x0F<<12 | char2&0x3F<<6 | char3&0x3F<<0
 			chararr_count++
 		default:
 			/* 10xx xxxx,  1111 xxxx */
-			panic(fmt.Errorf("malformed input around byte %v", count))
+			panic(&mutf8DecodeError{fmt.Sprintf("malformed input around byte %v", count)})
 		}
 	}
 	// The number of chars produced may be less than utflen
@@ -211,12 +367,35 @@ func __decodeMUtf8(bytearr []byte) string {
 	return string(runes)
 }
 
+// 解析MUTF8，格式错误时返回error而不是panic
+func decodeMUtf8(bytearr []byte) (value string, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			decodeError, ok := recovered.(*mutf8DecodeError)
+			if !ok {
+				panic(recovered)
+			}
+			err = decodeError
+		}
+	}()
+	return __decodeMUtf8(bytearr), nil
+}
+
 func (this *ConstantUtf8Info) ReadInformation(reader *JavaByteCodeReader) {
 	var size = reader.ReadUint16() // 前16位标注了Utf8字串的长度信息
 	var bytes = reader.ReadBytes(uint32(size))
 	this.stringValue = __decodeMUtf8(bytes)
 }
 
+func (this *ConstantUtf8Info) WriteInformation(writer *JavaByteCodeWriter) {
+	var bytes = __encodeMUtf8(this.stringValue)
+	if len(bytes) > math.MaxUint16 {
+		writer.fail("utf8 constant is too long: %d bytes", len(bytes))
+	}
+	writer.WriteUint16(uint16(len(bytes)))
+	writer.WriteBytes(bytes)
+}
+
 func (this *ConstantUtf8Info) String() string {
 	return this.stringValue
 }
@@ -231,6 +410,10 @@ func (this *ConstantStringInfo) ReadInformation(reader *JavaByteCodeReader) {
 	this.stringIndex = reader.ReadUint16()
 }
 
+func (this *ConstantStringInfo) WriteInformation(writer *JavaByteCodeWriter) {
+	writer.WriteUint16(this.stringIndex)
+}
+
 func (this *ConstantStringInfo) String() string {
 	return this.cp.getUtf8(this.stringIndex)
 }
@@ -245,6 +428,10 @@ func (this *ConstantClassInfo) ReadInformation(reader *JavaByteCodeReader) {
 	this.nameIndex = reader.ReadUint16()
 }
 
+func (this *ConstantClassInfo) WriteInformation(writer *JavaByteCodeWriter) {
+	writer.WriteUint16(this.nameIndex)
+}
+
 func (this *ConstantClassInfo) Name() string {
 	return this.cp.getUtf8(this.nameIndex)
 }
@@ -260,6 +447,11 @@ func (this *ConstantNameAndTypeInfo) ReadInformation(reader *JavaByteCodeReader)
 	this.descriptorIndex = reader.ReadUint16()
 }
 
+func (this *ConstantNameAndTypeInfo) WriteInformation(writer *JavaByteCodeWriter) {
+	writer.WriteUint16(this.nameIndex)
+	writer.WriteUint16(this.descriptorIndex)
+}
+
 // MemberrefInfo 常量信息
 type ConstantMemberrefInfo struct {
 	cp               ConstantPool // 常量池
@@ -272,6 +464,11 @@ func (this *ConstantMemberrefInfo) ReadInformation(reader *JavaByteCodeReader) {
 	this.nameAndTypeIndex = reader.ReadUint16()
 }
 
+func (this *ConstantMemberrefInfo) WriteInformation(writer *JavaByteCodeWriter) {
+	writer.WriteUint16(this.classIndex)
+	writer.WriteUint16(this.nameAndTypeIndex)
+}
+
 func (this *ConstantMemberrefInfo) ClassName() string {
 	return this.cp.getClassName(this.classIndex)
 }
@@ -326,6 +523,7 @@ func (this *ConstantInterfaceMethodrefInfo) NameAndDescriptor() (string, string)
 
 // MethodType 常量信息 JSE 1.7 引入
 type ConstantMethodTypeInfo struct {
+	cp              ConstantPool // 常量池
 	descriptorIndex uint16
 }
 
@@ -333,21 +531,101 @@ func (this *ConstantMethodTypeInfo) ReadInformation(reader *JavaByteCodeReader)
 	this.descriptorIndex = reader.ReadUint16()
 }
 
+func (this *ConstantMethodTypeInfo) WriteInformation(writer *JavaByteCodeWriter) {
+	writer.WriteUint16(this.descriptorIndex)
+}
+
+// 获取方法描述符，例如 (Ljava/lang/Object;)V
+func (this *ConstantMethodTypeInfo) Descriptor() string { return this.cp.getUtf8(this.descriptorIndex) }
+
+// 方法句柄的引用类型
+type MethodHandleKind uint8
+
+const (
+	REF_getField         MethodHandleKind = 1
+	REF_getStatic        MethodHandleKind = 2
+	REF_putField         MethodHandleKind = 3
+	REF_putStatic        MethodHandleKind = 4
+	REF_invokeVirtual    MethodHandleKind = 5
+	REF_invokeStatic     MethodHandleKind = 6
+	REF_invokeSpecial    MethodHandleKind = 7
+	REF_newInvokeSpecial MethodHandleKind = 8
+	REF_invokeInterface  MethodHandleKind = 9
+)
+
+func (this MethodHandleKind) String() string {
+	switch this {
+	case REF_getField:
+		return "REF_getField"
+	case REF_getStatic:
+		return "REF_getStatic"
+	case REF_putField:
+		return "REF_putField"
+	case REF_putStatic:
+		return "REF_putStatic"
+	case REF_invokeVirtual:
+		return "REF_invokeVirtual"
+	case REF_invokeStatic:
+		return "REF_invokeStatic"
+	case REF_invokeSpecial:
+		return "REF_invokeSpecial"
+	case REF_newInvokeSpecial:
+		return "REF_newInvokeSpecial"
+	case REF_invokeInterface:
+		return "REF_invokeInterface"
+	default:
+		return fmt.Sprintf("REF_unknown(%d)", uint8(this))
+	}
+}
+
 // ConstantMethodHandle 常量信息 JSE 1.7引入
 type ConstantMethodHandleInfo struct {
-	referenceKind  uint8
-	referenceIndex uint16
+	cp             ConstantPool // 常量池
+	referenceKind  MethodHandleKind
+	referenceIndex uint16 // 字段或方法的引用常量索引
 }
 
 func (this *ConstantMethodHandleInfo) ReadInformation(reader *JavaByteCodeReader) {
-	this.referenceKind = reader.ReadUint8()
+	this.referenceKind = MethodHandleKind(reader.ReadUint8())
 	this.referenceIndex = reader.ReadUint16()
 }
 
+func (this *ConstantMethodHandleInfo) WriteInformation(writer *JavaByteCodeWriter) {
+	writer.WriteUint8(uint8(this.referenceKind))
+	writer.WriteUint16(this.referenceIndex)
+}
+
+func (this *ConstantMethodHandleInfo) ReferenceKind() MethodHandleKind { return this.referenceKind }
+
+// 获取句柄引用的字段或方法：*ConstantFieldrefInfo、*ConstantMethodrefInfo 或 *ConstantInterfaceMethodrefInfo，
+// 不属于常量池或者索引不合法时返回nil
+func (this *ConstantMethodHandleInfo) Reference() ConstantInformation {
+	return this.cp.Information(this.referenceIndex)
+}
+
+// 获取句柄引用的字段或方法所在的类、名称和描述符，引用的不是字段或方法时返回空字符串
+func (this *ConstantMethodHandleInfo) Member() (string, string, string) {
+	var memberref *ConstantMemberrefInfo
+	switch info := this.Reference().(type) {
+	case *ConstantFieldrefInfo:
+		memberref = &info.ConstantMemberrefInfo
+	case *ConstantMethodrefInfo:
+		memberref = &info.ConstantMemberrefInfo
+	case *ConstantInterfaceMethodrefInfo:
+		memberref = &info.ConstantMemberrefInfo
+	default:
+		return "", "", ""
+	}
+	var name, descriptor = memberref.NameAndDescriptor()
+	return memberref.ClassName(), name, descriptor
+}
+
 // ConstantInvokeDynamic 常量信息 JSE1.7 引入
 type ConstantInvokeDynamicInfo struct {
+	cp                       ConstantPool // 常量池
 	bootstrapMethodAttrIndex uint16
 	nameAndTypeIndex         uint16
+	bootstrapMethod          *BootstrapMethod // 解析BootstrapMethods属性后关联
 }
 
 func (this *ConstantInvokeDynamicInfo) ReadInformation(reader *JavaByteCodeReader) {
@@ -355,18 +633,224 @@ func (this *ConstantInvokeDynamicInfo) ReadInformation(reader *JavaByteCodeReade
 	this.nameAndTypeIndex = reader.ReadUint16()
 }
 
+func (this *ConstantInvokeDynamicInfo) WriteInformation(writer *JavaByteCodeWriter) {
+	writer.WriteUint16(this.bootstrapMethodAttrIndex)
+	writer.WriteUint16(this.nameAndTypeIndex)
+}
+
+func (this *ConstantInvokeDynamicInfo) BootstrapMethodAttrIndex() uint16 {
+	return this.bootstrapMethodAttrIndex
+}
+
+// 获取对应的引导方法
+func (this *ConstantInvokeDynamicInfo) BootstrapMethod() *BootstrapMethod {
+	return this.bootstrapMethod
+}
+
+// 获取调用点的方法名称和描述符，例如 apply (Ljava/lang/String;)Ljava/util/function/Function;
+func (this *ConstantInvokeDynamicInfo) NameAndDescriptor() (string, string) {
+	return this.cp.getNameAndType(this.nameAndTypeIndex)
+}
+
+// Dynamic 常量信息 JSE 11 引入，结构与InvokeDynamic相同
+type ConstantDynamicInfo struct {
+	cp                       ConstantPool // 常量池
+	bootstrapMethodAttrIndex uint16
+	nameAndTypeIndex         uint16
+	bootstrapMethod          *BootstrapMethod // 解析BootstrapMethods属性后关联
+}
+
+func (this *ConstantDynamicInfo) ReadInformation(reader *JavaByteCodeReader) {
+	this.bootstrapMethodAttrIndex = reader.ReadUint16()
+	this.nameAndTypeIndex = reader.ReadUint16()
+}
+
+func (this *ConstantDynamicInfo) WriteInformation(writer *JavaByteCodeWriter) {
+	writer.WriteUint16(this.bootstrapMethodAttrIndex)
+	writer.WriteUint16(this.nameAndTypeIndex)
+}
+
+func (this *ConstantDynamicInfo) BootstrapMethodAttrIndex() uint16 {
+	return this.bootstrapMethodAttrIndex
+}
+
+// 获取对应的引导方法
+func (this *ConstantDynamicInfo) BootstrapMethod() *BootstrapMethod { return this.bootstrapMethod }
+
+// 获取常量的名称和字段描述符
+func (this *ConstantDynamicInfo) NameAndDescriptor() (string, string) {
+	return this.cp.getNameAndType(this.nameAndTypeIndex)
+}
+
+// Module 常量信息 JSE 9 引入，只出现在 module-info.class 中
+type ConstantModuleInfo struct {
+	cp        ConstantPool // 常量池
+	nameIndex uint16       // 模块名称索引
+}
+
+func (this *ConstantModuleInfo) ReadInformation(reader *JavaByteCodeReader) {
+	this.nameIndex = reader.ReadUint16()
+}
+
+func (this *ConstantModuleInfo) WriteInformation(writer *JavaByteCodeWriter) {
+	writer.WriteUint16(this.nameIndex)
+}
+
+func (this *ConstantModuleInfo) Name() string {
+	return this.cp.getUtf8(this.nameIndex)
+}
+
+// Package 常量信息 JSE 9 引入，只出现在 module-info.class 中
+type ConstantPackageInfo struct {
+	cp        ConstantPool // 常量池
+	nameIndex uint16       // 包名称索引（内部形式，例如 java/lang）
+}
+
+func (this *ConstantPackageInfo) ReadInformation(reader *JavaByteCodeReader) {
+	this.nameIndex = reader.ReadUint16()
+}
+
+func (this *ConstantPackageInfo) WriteInformation(writer *JavaByteCodeWriter) {
+	writer.WriteUint16(this.nameIndex)
+}
+
+func (this *ConstantPackageInfo) Name() string {
+	return this.cp.getUtf8(this.nameIndex)
+}
+
 // 常量池
 type ConstantPool struct {
 	informations []ConstantInformation
 }
 
+// 获取常量池大小，索引0不可用
+func (this *ConstantPool) Size() int { return len(this.informations) }
+
+// 获取索引处的常量信息，索引不合法或者为long/double之后不可用的位置时返回nil
+func (this *ConstantPool) Information(index uint16) ConstantInformation {
+	if int(index) >= len(this.informations) {
+		return nil
+	}
+	return this.informations[index]
+}
+
 //region 常量池私有函数
 
+// 检查常量之间的引用关系，保证解析完成后按照索引读取常量不会失败。offsets为每个常量在class文件中的偏移量
+func (this *ConstantPool) verify(reader *JavaByteCodeReader, offsets []int) {
+	var isUtf8 = func(index uint16) bool {
+		var _, ok = this.lookupUtf8(index)
+		return ok
+	}
+	var isClass = func(index uint16) bool {
+		var _, ok = this.Information(index).(*ConstantClassInfo)
+		return ok
+	}
+	var isNameAndType = func(index uint16) bool {
+		var _, ok = this.Information(index).(*ConstantNameAndTypeInfo)
+		return ok
+	}
+	for idx, information := range this.informations {
+		var check = func(ok bool, what string, ref uint16) {
+			if !ok {
+				reader.enter("constant pool entry #%d", idx)
+				reader.formatError(offsets[idx], "invalid %s index %d", what, ref)
+			}
+		}
+		var memberref *ConstantMemberrefInfo
+		switch info := information.(type) {
+		case *ConstantClassInfo:
+			check(isUtf8(info.nameIndex), "name", info.nameIndex)
+		case *ConstantStringInfo:
+			check(isUtf8(info.stringIndex), "string", info.stringIndex)
+		case *ConstantNameAndTypeInfo:
+			check(isUtf8(info.nameIndex), "name", info.nameIndex)
+			check(isUtf8(info.descriptorIndex), "descriptor", info.descriptorIndex)
+		case *ConstantFieldrefInfo:
+			memberref = &info.ConstantMemberrefInfo
+		case *ConstantMethodrefInfo:
+			memberref = &info.ConstantMemberrefInfo
+		case *ConstantInterfaceMethodrefInfo:
+			memberref = &info.ConstantMemberrefInfo
+		case *ConstantMethodTypeInfo:
+			check(isUtf8(info.descriptorIndex), "descriptor", info.descriptorIndex)
+		case *ConstantMethodHandleInfo:
+			if info.referenceKind < REF_getField || info.referenceKind > REF_invokeInterface {
+				reader.enter("constant pool entry #%d", idx)
+				reader.formatError(offsets[idx]+1, "invalid reference kind %d", info.referenceKind)
+			}
+			var reference = this.Information(info.referenceIndex)
+			var ok bool
+			switch info.referenceKind {
+			case REF_getField, REF_getStatic, REF_putField, REF_putStatic:
+				_, ok = reference.(*ConstantFieldrefInfo)
+			case REF_invokeVirtual, REF_newInvokeSpecial:
+				_, ok = reference.(*ConstantMethodrefInfo)
+			case REF_invokeStatic, REF_invokeSpecial:
+				switch reference.(type) {
+				case *ConstantMethodrefInfo, *ConstantInterfaceMethodrefInfo:
+					ok = true
+				}
+			case REF_invokeInterface:
+				_, ok = reference.(*ConstantInterfaceMethodrefInfo)
+			}
+			check(ok, "reference", info.referenceIndex)
+		case *ConstantInvokeDynamicInfo:
+			check(isNameAndType(info.nameAndTypeIndex), "name and type", info.nameAndTypeIndex)
+		case *ConstantDynamicInfo:
+			check(isNameAndType(info.nameAndTypeIndex), "name and type", info.nameAndTypeIndex)
+		case *ConstantModuleInfo:
+			check(isUtf8(info.nameIndex), "name", info.nameIndex)
+		case *ConstantPackageInfo:
+			check(isUtf8(info.nameIndex), "name", info.nameIndex)
+		}
+		if memberref != nil {
+			check(isClass(memberref.classIndex), "class", memberref.classIndex)
+			check(isNameAndType(memberref.nameAndTypeIndex), "name and type", memberref.nameAndTypeIndex)
+		}
+	}
+}
+
 func (this *ConstantPool) getUtf8(stringIndex uint16) string {
 	var utf8 = this.informations[stringIndex].(*ConstantUtf8Info)
 	return utf8.String()
 }
 
+// 检查索引处是否为Utf8常量，用于解析阶段
+func (this *ConstantPool) lookupUtf8(stringIndex uint16) (string, bool) {
+	if int(stringIndex) >= len(this.informations) {
+		return "", false
+	}
+	var utf8, ok = this.informations[stringIndex].(*ConstantUtf8Info)
+	if !ok {
+		return "", false
+	}
+	return utf8.String(), true
+}
+
+// 读取指向Utf8常量的索引，索引不合法时抛出格式错误
+func readUtf8Index(reader *JavaByteCodeReader, cp ConstantPool, what string) uint16 {
+	var offset = reader.offset
+	var index = reader.ReadUint16()
+	if _, ok := cp.lookupUtf8(index); !ok {
+		reader.formatError(offset, "invalid %s index %d", what, index)
+	}
+	return index
+}
+
+// 读取指向Class常量的索引，optional为true时允许为0，索引不合法时抛出格式错误
+func readClassIndex(reader *JavaByteCodeReader, cp ConstantPool, what string, optional bool) uint16 {
+	var offset = reader.offset
+	var index = reader.ReadUint16()
+	if index == 0 && optional {
+		return index
+	}
+	if _, ok := cp.Information(index).(*ConstantClassInfo); !ok {
+		reader.formatError(offset, "invalid %s index %d", what, index)
+	}
+	return index
+}
+
 func (this *ConstantPool) getClassName(classIndex uint16) string {
 	var class = this.informations[classIndex].(*ConstantClassInfo)
 	return class.Name()
@@ -396,20 +880,90 @@ func readMember(reader *JavaByteCodeReader, cp ConstantPool) *MemberInformation
 	//1. 2bytes => 访问标识符
 	//2. 2bytes => 名称索引
 	//3. 2bytes => 描述者索引
-	return &MemberInformation{
-		cp:              cp,
-		accessFlags:     reader.ReadUint16(),
-		nameIndex:       reader.ReadUint16(),
-		descriptorIndex: reader.ReadUint16(),
-		attributes:      readAttributes(reader, cp),
+	var member = &MemberInformation{cp: cp, accessFlags: reader.ReadUint16()}
+	var offset = reader.offset
+	member.nameIndex = reader.ReadUint16()
+	member.descriptorIndex = reader.ReadUint16()
+	if _, ok := cp.lookupUtf8(member.nameIndex); !ok {
+		reader.formatError(offset, "invalid name index %d", member.nameIndex)
+	}
+	if _, ok := cp.lookupUtf8(member.descriptorIndex); !ok {
+		reader.formatError(offset+2, "invalid descriptor index %d", member.descriptorIndex)
 	}
+	member.attributes = readAttributes(reader, cp)
+	return member
 }
 
 //#region MemberInformation 读取常量池有关操作函数
 
+// 获取成员名称，例如 main
+func (this *MemberInformation) Name() string { return this.cp.getUtf8(this.nameIndex) }
+
+// 获取成员描述符，例如 ([Ljava/lang/String;)V
+func (this *MemberInformation) Descriptor() string { return this.cp.getUtf8(this.descriptorIndex) }
+
+// 获取访问标识符的原始值，按照成员类型使用 FieldAccessFlags 或 MethodAccessFlags 解读
+func (this *MemberInformation) AccessFlags() uint16 { return this.accessFlags }
+
+// 按照字段解读访问标识符
+func (this *MemberInformation) FieldAccessFlags() FieldAccessFlags {
+	return FieldAccessFlags(this.accessFlags)
+}
+
+// 按照方法解读访问标识符
+func (this *MemberInformation) MethodAccessFlags() MethodAccessFlags {
+	return MethodAccessFlags(this.accessFlags)
+}
+
+// 获取属性信息
+func (this *MemberInformation) Attributes() []*Attribute { return this.attributes }
+
+// 获取成员上运行时可见和不可见的注解
+func (this *MemberInformation) Annotations() []*Annotation {
+	return collectAnnotations(this.attributes)
+}
+
+// 获取泛型签名，没有Signature属性时返回空字符串
+func (this *MemberInformation) Signature() string { return findSignature(this.attributes) }
+
+// 获取方法参数，没有MethodParameters属性（编译时未使用 -parameters）时返回nil
+func (this *MemberInformation) Parameters() []*MethodParameter {
+	for _, attribute := range this.attributes {
+		if parameters, ok := (*attribute).(*MethodParametersAttribute); ok {
+			return parameters.parameters
+		}
+	}
+	return nil
+}
+
+// 获取方法参数名称，没有记录名称的参数为空字符串
+func (this *MemberInformation) ParameterNames() []string {
+	var parameters = this.Parameters()
+	if parameters == nil {
+		return nil
+	}
+	var names = make([]string, len(parameters))
+	for idx, parameter := range parameters {
+		names[idx] = parameter.Name()
+	}
+	return names
+}
+
+// 获取方法的Code属性，抽象方法和本地方法没有Code属性，返回nil
+func (this *MemberInformation) CodeAttribute() *CodeAttribute {
+	for _, attribute := range this.attributes {
+		if code, ok := (*attribute).(*CodeAttribute); ok {
+			return code
+		}
+	}
+	return nil
+}
+
 // 属性信息
 type Attribute interface {
 	ReadAttribute(reader *JavaByteCodeReader)
+	WriteAttribute(writer *JavaByteCodeWriter) // 写入属性长度之后的内容
+	Name() string                              // 属性名称，例如 Code
 }
 
 // 这个属性十分重要, 顶层属性，可以套娃
@@ -432,6 +986,27 @@ type ExceptionTable struct {
 	catchType uint16 // 捕获的类型
 }
 
+func (this *CodeAttribute) MaxStack() uint16 { return this.maxStack }
+
+func (this *CodeAttribute) MaxLocals() uint16 { return this.maxLocals }
+
+// 获取方法的字节码
+func (this *CodeAttribute) Code() []byte { return this.code }
+
+func (this *CodeAttribute) ExceptionTables() []*ExceptionTable { return this.exceptionTables }
+
+// 获取Code属性内部的属性，例如 LineNumberTable
+func (this *CodeAttribute) Attributes() []*Attribute { return this.attributes }
+
+func (this *ExceptionTable) StartPC() uint16 { return this.startPC }
+
+func (this *ExceptionTable) EndPC() uint16 { return this.endPC }
+
+func (this *ExceptionTable) HandlerPC() uint16 { return this.handlerPC }
+
+// 获取捕获类型的常量池索引，0表示捕获所有异常（finally）
+func (this *ExceptionTable) CatchType() uint16 { return this.catchType }
+
 func (this *CodeAttribute) ReadAttribute(reader *JavaByteCodeReader) {
 	this.maxStack = reader.ReadUint16()    // 首先是最大栈深度
 	this.maxLocals = reader.ReadUint16()   // 最大变量表
@@ -451,6 +1026,23 @@ func (this *CodeAttribute) ReadAttribute(reader *JavaByteCodeReader) {
 	this.attributes = readAttributes(reader, this.cp) // 套娃读取
 }
 
+func (this *CodeAttribute) WriteAttribute(writer *JavaByteCodeWriter) {
+	writer.WriteUint16(this.maxStack)
+	writer.WriteUint16(this.maxLocals)
+	writer.WriteUint32(uint32(len(this.code)))
+	writer.WriteBytes(this.code)
+	writer.writeCount(len(this.exceptionTables), "exception tables")
+	for _, exceptionTable := range this.exceptionTables {
+		writer.WriteUint16(exceptionTable.startPC)
+		writer.WriteUint16(exceptionTable.endPC)
+		writer.WriteUint16(exceptionTable.handlerPC)
+		writer.WriteUint16(exceptionTable.catchType)
+	}
+	writeAttributes(writer, this.attributes)
+}
+
+func (this *CodeAttribute) Name() string { return this.name }
+
 type ConstantValueAttribute struct {
 	name               string
 	length             uint32
@@ -461,6 +1053,12 @@ func (this *ConstantValueAttribute) ReadAttribute(reader *JavaByteCodeReader) {
 	this.constantValueIndex = reader.ReadUint16()
 }
 
+func (this *ConstantValueAttribute) WriteAttribute(writer *JavaByteCodeWriter) {
+	writer.WriteUint16(this.constantValueIndex)
+}
+
+func (this *ConstantValueAttribute) Name() string { return this.name }
+
 type DeprecatedAttribute struct {
 	name   string
 	length uint32
@@ -469,6 +1067,10 @@ type DeprecatedAttribute struct {
 //do noting
 func (this *DeprecatedAttribute) ReadAttribute(reader *JavaByteCodeReader) {}
 
+func (this *DeprecatedAttribute) WriteAttribute(writer *JavaByteCodeWriter) {}
+
+func (this *DeprecatedAttribute) Name() string { return this.name }
+
 // 异常属性
 type ExceptionsAttribute struct {
 	name                string
@@ -480,6 +1082,12 @@ func (this *ExceptionsAttribute) ReadAttribute(reader *JavaByteCodeReader) {
 	this.exceptionIndexTable = reader.ReadUint16s()
 }
 
+func (this *ExceptionsAttribute) WriteAttribute(writer *JavaByteCodeWriter) {
+	writer.WriteUint16s(this.exceptionIndexTable)
+}
+
+func (this *ExceptionsAttribute) Name() string { return this.name }
+
 func (this *ExceptionsAttribute) ExceptionsIndexTable() []uint16 { return this.exceptionIndexTable }
 
 // 与异常处理有关
@@ -505,6 +1113,16 @@ func (this *LineNumberTableAttribute) ReadAttribute(reader *JavaByteCodeReader)
 	}
 }
 
+func (this *LineNumberTableAttribute) WriteAttribute(writer *JavaByteCodeWriter) {
+	writer.writeCount(len(this.lineNumberTable), "line numbers")
+	for _, entry := range this.lineNumberTable {
+		writer.WriteUint16(entry.startPC)
+		writer.WriteUint16(entry.lineNumber)
+	}
+}
+
+func (this *LineNumberTableAttribute) Name() string { return this.name }
+
 type LocalVariableTableAttribute struct {
 	name               string
 	length             uint32
@@ -533,6 +1151,19 @@ func (this *LocalVariableTableAttribute) ReadAttribute(reader *JavaByteCodeReade
 	}
 }
 
+func (this *LocalVariableTableAttribute) WriteAttribute(writer *JavaByteCodeWriter) {
+	writer.writeCount(len(this.localVariableTable), "local variables")
+	for _, entry := range this.localVariableTable {
+		writer.WriteUint16(entry.startPc)
+		writer.WriteUint16(entry.length)
+		writer.WriteUint16(entry.nameIndex)
+		writer.WriteUint16(entry.descriptorIndex)
+		writer.WriteUint16(entry.index)
+	}
+}
+
+func (this *LocalVariableTableAttribute) Name() string { return this.name }
+
 type SourceFileAttribute struct {
 	cp              ConstantPool
 	name            string
@@ -544,8 +1175,174 @@ func (this *SourceFileAttribute) ReadAttribute(reader *JavaByteCodeReader) {
 	this.sourceFileIndex = reader.ReadUint16()
 }
 
+func (this *SourceFileAttribute) WriteAttribute(writer *JavaByteCodeWriter) {
+	writer.WriteUint16(this.sourceFileIndex)
+}
+
+func (this *SourceFileAttribute) Name() string { return this.name }
+
 func (this *SourceFileAttribute) FileName() string { return this.cp.getUtf8(this.sourceFileIndex) }
 
+// 泛型签名，使用 ParseClassSignature、ParseMethodSignature、ParseFieldSignature 解析
+type SignatureAttribute struct {
+	cp             ConstantPool
+	name           string
+	length         uint32
+	signatureIndex uint16
+}
+
+func (this *SignatureAttribute) ReadAttribute(reader *JavaByteCodeReader) {
+	this.signatureIndex = readUtf8Index(reader, this.cp, "signature")
+}
+
+func (this *SignatureAttribute) WriteAttribute(writer *JavaByteCodeWriter) {
+	writer.WriteUint16(this.signatureIndex)
+}
+
+func (this *SignatureAttribute) Name() string { return this.name }
+
+func (this *SignatureAttribute) Signature() string { return this.cp.getUtf8(this.signatureIndex) }
+
+// 局部变量的泛型签名，与LocalVariableTable对应
+type LocalVariableTypeTableAttribute struct {
+	cp                     ConstantPool
+	name                   string
+	length                 uint32
+	localVariableTypeTable []*LocalVariableTypeTableEntry
+}
+
+type LocalVariableTypeTableEntry struct {
+	cp             ConstantPool
+	startPc        uint16
+	length         uint16
+	nameIndex      uint16
+	signatureIndex uint16
+	index          uint16
+}
+
+func (this *LocalVariableTypeTableAttribute) ReadAttribute(reader *JavaByteCodeReader) {
+	this.localVariableTypeTable = make([]*LocalVariableTypeTableEntry, reader.ReadUint16())
+	for i := range this.localVariableTypeTable {
+		this.localVariableTypeTable[i] = &LocalVariableTypeTableEntry{
+			cp:             this.cp,
+			startPc:        reader.ReadUint16(),
+			length:         reader.ReadUint16(),
+			nameIndex:      readUtf8Index(reader, this.cp, "name"),
+			signatureIndex: readUtf8Index(reader, this.cp, "signature"),
+			index:          reader.ReadUint16(),
+		}
+	}
+}
+
+func (this *LocalVariableTypeTableAttribute) WriteAttribute(writer *JavaByteCodeWriter) {
+	writer.writeCount(len(this.localVariableTypeTable), "local variable types")
+	for _, entry := range this.localVariableTypeTable {
+		writer.WriteUint16(entry.startPc)
+		writer.WriteUint16(entry.length)
+		writer.WriteUint16(entry.nameIndex)
+		writer.WriteUint16(entry.signatureIndex)
+		writer.WriteUint16(entry.index)
+	}
+}
+
+func (this *LocalVariableTypeTableAttribute) Name() string { return this.name }
+
+func (this *LocalVariableTypeTableAttribute) Entries() []*LocalVariableTypeTableEntry {
+	return this.localVariableTypeTable
+}
+
+func (this *LocalVariableTypeTableEntry) StartPC() uint16 { return this.startPc }
+
+func (this *LocalVariableTypeTableEntry) Length() uint16 { return this.length }
+
+func (this *LocalVariableTypeTableEntry) Name() string { return this.cp.getUtf8(this.nameIndex) }
+
+// 获取局部变量的字段签名，例如 Ljava/util/List<Ljava/lang/String;>;
+func (this *LocalVariableTypeTableEntry) Signature() string {
+	return this.cp.getUtf8(this.signatureIndex)
+}
+
+// 获取局部变量在局部变量表中的位置
+func (this *LocalVariableTypeTableEntry) Index() uint16 { return this.index }
+
+// 方法参数，javac -parameters 编译时生成
+type MethodParametersAttribute struct {
+	cp         ConstantPool
+	name       string
+	length     uint32
+	parameters []*MethodParameter
+}
+
+type MethodParameter struct {
+	cp          ConstantPool
+	nameIndex   uint16 // 没有名称时为0
+	accessFlags uint16
+}
+
+func (this *MethodParametersAttribute) ReadAttribute(reader *JavaByteCodeReader) {
+	this.parameters = make([]*MethodParameter, reader.ReadUint8())
+	for idx := range this.parameters {
+		var parameter = &MethodParameter{cp: this.cp}
+		var offset = reader.offset
+		parameter.nameIndex = reader.ReadUint16()
+		if _, ok := this.cp.lookupUtf8(parameter.nameIndex); !ok && parameter.nameIndex != 0 {
+			reader.enter("parameter #%d", idx)
+			reader.formatError(offset, "invalid name index %d", parameter.nameIndex)
+		}
+		parameter.accessFlags = reader.ReadUint16()
+		this.parameters[idx] = parameter
+	}
+}
+
+func (this *MethodParametersAttribute) WriteAttribute(writer *JavaByteCodeWriter) {
+	writer.writeCount8(len(this.parameters), "parameters")
+	for _, parameter := range this.parameters {
+		writer.WriteUint16(parameter.nameIndex)
+		writer.WriteUint16(parameter.accessFlags)
+	}
+}
+
+func (this *MethodParametersAttribute) Name() string { return this.name }
+
+func (this *MethodParametersAttribute) Parameters() []*MethodParameter { return this.parameters }
+
+// 获取参数名称，没有记录名称时返回空字符串
+func (this *MethodParameter) Name() string {
+	if this.nameIndex == 0 {
+		return ""
+	}
+	return this.cp.getUtf8(this.nameIndex)
+}
+
+func (this *MethodParameter) AccessFlags() ParameterAccessFlags {
+	return ParameterAccessFlags(this.accessFlags)
+}
+
+// 调试扩展信息，例如JSP的SMAP。规范没有要求内容是合法的MUTF8，因此保存原始字节
+type SourceDebugExtensionAttribute struct {
+	name           string
+	length         uint32
+	debugExtension []byte
+}
+
+func (this *SourceDebugExtensionAttribute) ReadAttribute(reader *JavaByteCodeReader) {
+	this.debugExtension = reader.ReadBytes(this.length)
+}
+
+func (this *SourceDebugExtensionAttribute) WriteAttribute(writer *JavaByteCodeWriter) {
+	writer.WriteBytes(this.debugExtension)
+}
+
+func (this *SourceDebugExtensionAttribute) Name() string { return this.name }
+
+// 获取原始字节
+func (this *SourceDebugExtensionAttribute) DebugExtension() []byte { return this.debugExtension }
+
+// 按照MUTF8解码，内容不是合法的MUTF8时返回错误
+func (this *SourceDebugExtensionAttribute) DebugExtensionString() (string, error) {
+	return decodeMUtf8(this.debugExtension)
+}
+
 type SyntheticAttribute struct {
 	name   string
 	length uint32
@@ -554,6 +1351,10 @@ type SyntheticAttribute struct {
 // do noting
 func (this *SyntheticAttribute) ReadAttribute(reader *JavaByteCodeReader) {}
 
+func (this *SyntheticAttribute) WriteAttribute(writer *JavaByteCodeWriter) {}
+
+func (this *SyntheticAttribute) Name() string { return this.name }
+
 type UnparsedAttribute struct {
 	name        string
 	length      uint32
@@ -564,15 +1365,28 @@ func (this *UnparsedAttribute) ReadAttribute(reader *JavaByteCodeReader) {
 	this.information = reader.ReadBytes(this.length)
 }
 
+func (this *UnparsedAttribute) WriteAttribute(writer *JavaByteCodeWriter) {
+	writer.WriteBytes(this.information)
+}
+
+func (this *UnparsedAttribute) Name() string { return this.name }
+
 // 读取属性信息表
 func readAttributes(reader *JavaByteCodeReader, cp ConstantPool) []*Attribute {
 	var attributeCount = reader.ReadUint16() // 2字节表示信息长度
 	var attributes = make([]*Attribute, attributeCount)
 	for idx := range attributes {
 		// 构造属性信息表
+		var offset = reader.offset
 		var attributeNameIndex = reader.ReadUint16()
-		var attributeName = cp.getUtf8(attributeNameIndex)
+		var attributeName, ok = cp.lookupUtf8(attributeNameIndex)
+		if !ok {
+			reader.enter("attribute #%d", idx)
+			reader.formatError(offset, "invalid attribute name index %d", attributeNameIndex)
+		}
+		reader.enter("attribute %s", attributeName)
 		var attributeLength = reader.ReadUint32() // 信息长度有多少
+		var start = reader.offset
 		var attribute Attribute
 		switch attributeName {
 		case CODE:
@@ -591,21 +1405,71 @@ func readAttributes(reader *JavaByteCodeReader, cp ConstantPool) []*Attribute {
 			attribute = &SourceFileAttribute{cp: cp, name: attributeName, length: attributeLength}
 		case SYNTHETIC:
 			attribute = &SyntheticAttribute{name: attributeName, length: attributeLength}
+		case STACK_MAP_TABLE:
+			attribute = &StackMapTableAttribute{cp: cp, name: attributeName, length: attributeLength}
+		case RUNTIME_VISIBLE_ANNOTATIONS:
+			attribute = &RuntimeVisibleAnnotationsAttribute{AnnotationsAttribute{cp: cp, name: attributeName, length: attributeLength}}
+		case RUNTIME_INVISIBLE_ANNOTATIONS:
+			attribute = &RuntimeInvisibleAnnotationsAttribute{AnnotationsAttribute{cp: cp, name: attributeName, length: attributeLength}}
+		case RUNTIME_VISIBLE_PARAMETER_ANNOTATIONS:
+			attribute = &RuntimeVisibleParameterAnnotationsAttribute{ParameterAnnotationsAttribute{cp: cp, name: attributeName, length: attributeLength}}
+		case RUNTIME_INVISIBLE_PARAMETER_ANNOTATIONS:
+			attribute = &RuntimeInvisibleParameterAnnotationsAttribute{ParameterAnnotationsAttribute{cp: cp, name: attributeName, length: attributeLength}}
+		case RUNTIME_VISIBLE_TYPE_ANNOTATIONS:
+			attribute = &RuntimeVisibleTypeAnnotationsAttribute{TypeAnnotationsAttribute{cp: cp, name: attributeName, length: attributeLength}}
+		case RUNTIME_INVISIBLE_TYPE_ANNOTATIONS:
+			attribute = &RuntimeInvisibleTypeAnnotationsAttribute{TypeAnnotationsAttribute{cp: cp, name: attributeName, length: attributeLength}}
+		case ANNOTATION_DEFAULT:
+			attribute = &AnnotationDefaultAttribute{cp: cp, name: attributeName, length: attributeLength}
+		case SIGNATURE:
+			attribute = &SignatureAttribute{cp: cp, name: attributeName, length: attributeLength}
+		case LOCAL_VARIABLE_TYPE_TABLE:
+			attribute = &LocalVariableTypeTableAttribute{cp: cp, name: attributeName, length: attributeLength}
+		case METHOD_PARAMETERS:
+			attribute = &MethodParametersAttribute{cp: cp, name: attributeName, length: attributeLength}
+		case SOURCE_DEBUG_EXTENSION:
+			attribute = &SourceDebugExtensionAttribute{name: attributeName, length: attributeLength}
+		case BOOTSTRAP_METHODS:
+			attribute = &BootstrapMethodsAttribute{cp: cp, name: attributeName, length: attributeLength}
+		case INNER_CLASSES:
+			attribute = &InnerClassesAttribute{cp: cp, name: attributeName, length: attributeLength}
+		case ENCLOSING_METHOD:
+			attribute = &EnclosingMethodAttribute{cp: cp, name: attributeName, length: attributeLength}
+		case NEST_HOST:
+			attribute = &NestHostAttribute{cp: cp, name: attributeName, length: attributeLength}
+		case NEST_MEMBERS:
+			attribute = &NestMembersAttribute{ClassListAttribute{cp: cp, name: attributeName, length: attributeLength}}
+		case PERMITTED_SUBCLASSES:
+			attribute = &PermittedSubclassesAttribute{ClassListAttribute{cp: cp, name: attributeName, length: attributeLength}}
+		case RECORD:
+			attribute = &RecordAttribute{cp: cp, name: attributeName, length: attributeLength}
+		case MODULE:
+			attribute = &ModuleAttribute{cp: cp, name: attributeName, length: attributeLength}
+		case MODULE_PACKAGES:
+			attribute = &ModulePackagesAttribute{cp: cp, name: attributeName, length: attributeLength}
+		case MODULE_MAIN_CLASS:
+			attribute = &ModuleMainClassAttribute{cp: cp, name: attributeName, length: attributeLength}
 		default:
 			attribute = &UnparsedAttribute{name: attributeName, length: attributeLength}
 		}
 		attribute.ReadAttribute(reader)
+		if read := reader.offset - start; uint32(read) != attributeLength {
+			reader.formatError(start, "attribute length %d does not match parsed length %d", attributeLength, read)
+		}
+		reader.leave()
 		attributes[idx] = &attribute
 	}
 	return attributes
 }
 
 // 读取所有的成员信息
-func readMembers(reader *JavaByteCodeReader, cp ConstantPool) []*MemberInformation {
+func readMembers(reader *JavaByteCodeReader, cp ConstantPool, kind string) []*MemberInformation {
 	var memberCount = reader.ReadUint16() // 首先读出成员的个数
 	var members = make([]*MemberInformation, memberCount)
 	for idx := range members {
+		reader.enter("%s #%d", kind, idx)
 		members[idx] = readMember(reader, cp)
+		reader.leave()
 	}
 	return members
 }
@@ -639,7 +1503,7 @@ func (this *JavaClass) MajorVersion() uint16 { return this.majorVersion }
 func (this *JavaClass) ConstantPool() ConstantPool { return this.constantPool }
 
 // 获取访问标识符
-func (this *JavaClass) AccessFlags() uint16 { return this.accessFlags }
+func (this *JavaClass) AccessFlags() ClassAccessFlags { return ClassAccessFlags(this.accessFlags) }
 
 // 获取字段信息
 func (this *JavaClass) Fields() []*MemberInformation { return this.fields }
@@ -647,14 +1511,62 @@ func (this *JavaClass) Fields() []*MemberInformation { return this.fields }
 // 获取方法信息
 func (this *JavaClass) Methods() []*MemberInformation { return this.methods }
 
-// 获取类的全限定名称
-func (this *JavaClass) ClassName() string { return "" }
+// 获取类的全限定名称，例如 java/lang/String
+func (this *JavaClass) ClassName() string { return this.constantPool.getClassName(this.thisClass) }
 
-// 获取超类的全限定名称
-func (this *JavaClass) SuperClassName() string { return "" }
+// 获取超类的全限定名称，java/lang/Object 和 module-info 没有超类，返回空字符串
+func (this *JavaClass) SuperClassName() string {
+	if this.superClass == 0 {
+		return ""
+	}
+	return this.constantPool.getClassName(this.superClass)
+}
 
 // 获取所有接口的全限定名称
-func (this *JavaClass) InterfaceNames() []string { return []string{} }
+func (this *JavaClass) InterfaceNames() []string {
+	var names = make([]string, len(this.interfaceClass))
+	for idx, classIndex := range this.interfaceClass {
+		names[idx] = this.constantPool.getClassName(classIndex)
+	}
+	return names
+}
+
+// 获取类的属性信息
+func (this *JavaClass) Attributes() []*Attribute { return this.attributes }
+
+// 获取类的泛型签名，没有Signature属性时返回空字符串
+func (this *JavaClass) Signature() string { return findSignature(this.attributes) }
+
+// 获取类上运行时可见和不可见的注解
+func (this *JavaClass) Annotations() []*Annotation { return collectAnnotations(this.attributes) }
+
+// 按照名称和描述符查找方法，找不到时返回nil
+func (this *JavaClass) FindMethod(name string, descriptor string) *MemberInformation {
+	return findMember(this.methods, name, descriptor)
+}
+
+// 按照名称和描述符查找字段，找不到时返回nil
+func (this *JavaClass) FindField(name string, descriptor string) *MemberInformation {
+	return findMember(this.fields, name, descriptor)
+}
+
+func findSignature(attributes []*Attribute) string {
+	for _, attribute := range attributes {
+		if signature, ok := (*attribute).(*SignatureAttribute); ok {
+			return signature.Signature()
+		}
+	}
+	return ""
+}
+
+func findMember(members []*MemberInformation, name string, descriptor string) *MemberInformation {
+	for _, member := range members {
+		if member.Name() == name && member.Descriptor() == descriptor {
+			return member
+		}
+	}
+	return nil
+}
 
 //#endregion JavaClass getter & accessor
 
@@ -663,55 +1575,90 @@ func (this *JavaClass) InterfaceNames() []string { return []string{} }
 // 读取字节码，构造Class对象
 // 字节码的排列顺序
 // 魔数 -> 次版本号 -> 主版本号 -> 常量池 -> 类访问标志 -> 两个uint16类型的常量池索引（本类和超类）
-func (this *JavaClass) read(reader *JavaByteCodeReader) {
-	this.readAndCheckMagicNumber(reader)                        // 检查魔数
-	this.readAndCheckVersion(reader)                            // 检查版本号
-	this.readConstantPool(reader)                               // 读取常量池信息
-	this.accessFlags = reader.ReadUint16()                      // 读取类的访问标识符
-	this.thisClass = reader.ReadUint16()                        // 本类
-	this.superClass = reader.ReadUint16()                       // 超类
-	this.interfaceClass = reader.ReadUint16s()                  // 读取接口信息
-	this.fields = readMembers(reader, this.constantPool)        // 读取字段
-	this.methods = readMembers(reader, this.constantPool)       // 读取方法
-	this.attributes = readAttributes(reader, this.constantPool) // 读取属性
-
+func (this *JavaClass) read(reader *JavaByteCodeReader, options ParseOptions) {
+	reader.enter("class file header")
+	this.readAndCheckMagicNumber(reader)                    // 检查魔数
+	this.readAndCheckVersion(reader, options.EnablePreview) // 检查版本号
+	reader.leave()
+	var offsets = this.readConstantPool(reader) // 读取常量池信息
+	reader.enter("class declaration")
+	this.accessFlags = reader.ReadUint16() // 读取类的访问标识符
+	this.thisClass = reader.ReadUint16()   // 本类
+	this.superClass = reader.ReadUint16()  // 超类
+	this.checkClassIndex(reader, reader.offset-4, this.thisClass)
+	if this.superClass != 0 {
+		this.checkClassIndex(reader, reader.offset-2, this.superClass)
+	}
+	reader.leave()
+	reader.enter("interfaces")
+	this.interfaceClass = reader.ReadUint16s() // 读取接口信息
+	for idx, classIndex := range this.interfaceClass {
+		this.checkClassIndex(reader, reader.offset-(len(this.interfaceClass)-idx)*2, classIndex)
+	}
+	reader.leave()
+	this.fields = readMembers(reader, this.constantPool, "field")   // 读取字段
+	this.methods = readMembers(reader, this.constantPool, "method") // 读取方法
+	this.attributes = readAttributes(reader, this.constantPool)     // 读取属性
+	this.linkBootstrapMethods(reader, offsets)
+	if len(reader.bytecode) > 0 {
+		reader.formatError(reader.offset, "extra %d bytes at the end of class file", len(reader.bytecode))
+	}
 }
 
 // 读取并检查魔数
 func (this *JavaClass) readAndCheckMagicNumber(reader *JavaByteCodeReader) {
 	var magic = reader.ReadUint32()
 	if magic != 0xCAFEBABE {
-		panic("java.lang.ClassFormatError => magic!")
+		reader.formatError(0, "bad magic 0x%x", magic)
 	}
 	this.magic = magic
 }
 
 // 读取并检查字节码文件版本号
-func (this *JavaClass) readAndCheckVersion(reader *JavaByteCodeReader) {
+func (this *JavaClass) readAndCheckVersion(reader *JavaByteCodeReader, enablePreview bool) {
+	var offset = reader.offset
 	this.minorVersion = reader.ReadUint16() // 首先出现的是副版本号
 	this.majorVersion = reader.ReadUint16() // 其次是主版本号
 
 	debug("minor version => ", this.minorVersion)
 	debug("major version => ", this.majorVersion)
-	switch this.majorVersion {
-	case 45:
+	switch {
+	case this.majorVersion == 45:
 		return
-	case 46, 47, 48, 49, 50, 51, 52:
+	case this.majorVersion >= 46 && this.majorVersion <= __MAX_MAJOR_VERSION__:
 		if this.minorVersion == 0 {
 			return
 		}
+		// 预览特性只能用于当前支持的最高版本
+		if this.minorVersion == __PREVIEW_MINOR_VERSION__ && enablePreview &&
+			this.majorVersion >= __MIN_PREVIEW_MAJOR_VERSION__ && this.majorVersion == __MAX_MAJOR_VERSION__ {
+			return
+		}
 	}
-	panic("java.lang.UnsupportedClassVersionError")
+	panic(&UnsupportedClassVersionError{
+		classParseError: reader.parseError(offset),
+		majorVersion:    this.majorVersion,
+		minorVersion:    this.minorVersion,
+		previewEnabled:  enablePreview,
+	})
 }
 
-// 读取解析常量池信息
-func (this *JavaClass) readConstantPool(reader *JavaByteCodeReader) {
+// 读取解析常量池信息，返回每个常量在class文件中的偏移量
+func (this *JavaClass) readConstantPool(reader *JavaByteCodeReader) []int {
+	reader.enter("constant pool")
 	var constantPoolSize = reader.ReadUint16()
+	reader.leave()
 	var constantPool = new(ConstantPool)
 	var informations = make([]ConstantInformation, constantPoolSize)
+	// 先设置informations，使复制出去的常量池与最终的常量池共享同一个底层数组
+	constantPool.informations = informations
 	// 开始解析常量池
 	// 一定注意，常量池的索引是从1开始的
+	var offsets = make([]int, constantPoolSize) // 每个常量在class文件中的偏移量
 	for i := 1; i < int(constantPoolSize); i++ {
+		reader.enter("constant pool entry #%d", i)
+		var offset = reader.offset
+		offsets[i] = offset
 		var tag = reader.ReadUint8() // 获取常量的tag
 		var constantInformation ConstantInformation
 		switch tag {
@@ -738,16 +1685,22 @@ func (this *JavaClass) readConstantPool(reader *JavaByteCodeReader) {
 		case CONSTANT_NameAndType:
 			constantInformation = &ConstantNameAndTypeInfo{}
 		case CONSTANT_MethodType:
-			constantInformation = &ConstantMethodTypeInfo{}
+			constantInformation = &ConstantMethodTypeInfo{cp: *constantPool}
 		case CONSTANT_MethodHandle:
-			constantInformation = &ConstantMethodTypeInfo{}
+			constantInformation = &ConstantMethodHandleInfo{cp: *constantPool}
 		case CONSTANT_InvokeDynamic:
-			constantInformation = &ConstantInvokeDynamicInfo{}
+			constantInformation = &ConstantInvokeDynamicInfo{cp: *constantPool}
+		case CONSTANT_Dynamic:
+			constantInformation = &ConstantDynamicInfo{cp: *constantPool}
+		case CONSTANT_Module:
+			constantInformation = &ConstantModuleInfo{cp: *constantPool}
+		case CONSTANT_Package:
+			constantInformation = &ConstantPackageInfo{cp: *constantPool}
 		default:
-			panic("java.lang.ClassFormatError! => constant pool")
-
+			reader.formatError(offset, "unknown tag %d", tag)
 		}
 		constantInformation.ReadInformation(reader) // 从字节码中读取信息
+		reader.leave()
 		informations[i] = constantInformation
 		// http://docs.oracle.com/javase/specs/jvms/se8/html/jvms-4.html#jvms-4.4.5
 		// All 8-byte constants take up two entries in the constant_pool table of the class file.
@@ -759,22 +1712,51 @@ func (this *JavaClass) readConstantPool(reader *JavaByteCodeReader) {
 			i++
 		}
 	}
-	constantPool.informations = informations
+	constantPool.verify(reader, offsets)
 	this.constantPool = *constantPool
+	return offsets
+}
+
+// 检查索引是否指向合法的class常量
+func (this *JavaClass) checkClassIndex(reader *JavaByteCodeReader, offset int, classIndex uint16) {
+	if _, ok := this.constantPool.Information(classIndex).(*ConstantClassInfo); !ok {
+		reader.formatError(offset, "invalid class index %d", classIndex)
+	}
 }
 
 //#endregion
 
-// 解析Java字节码
+// 将解析过程中的panic转换为error，MUTF8解码失败视为class格式错误。
+// 其他panic（例如空指针、越界）是解析器自身的缺陷，继续向上抛出
+func (this *JavaByteCodeReader) recoverError(recovered interface{}) error {
+	switch err := recovered.(type) {
+	case *ClassFormatError:
+		return err
+	case *UnsupportedClassVersionError:
+		return err
+	case *ClassTruncatedError:
+		return err
+	case *mutf8DecodeError:
+		return &ClassFormatError{classParseError: this.parseError(this.offset), message: err.Error()}
+	default:
+		panic(recovered)
+	}
+}
+
+// 解析Java字节码，格式错误时返回 *ClassFormatError、*UnsupportedClassVersionError 或 *ClassTruncatedError
 func ParseJavaByteCode(bytecode []byte) (*JavaClass, error) {
+	return ParseJavaByteCodeWithOptions(bytecode, ParseOptions{})
+}
+
+// 使用指定的选项解析Java字节码
+func ParseJavaByteCodeWithOptions(bytecode []byte, options ParseOptions) (javaClass *JavaClass, err error) {
+	var reader = JavaByteCodeReader{bytecode: bytecode}
 	defer func() {
-		var err = recover()
-		if err != nil {
-			fatal(err)
+		if recovered := recover(); recovered != nil {
+			javaClass, err = nil, reader.recoverError(recovered)
 		}
 	}()
-	var reader = JavaByteCodeReader{bytecode: bytecode}
-	var javaClass = JavaClass{}
-	javaClass.read(&reader)
-	return &javaClass, nil
+	javaClass = &JavaClass{}
+	javaClass.read(&reader, options)
+	return javaClass, nil
 }
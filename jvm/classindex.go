@@ -0,0 +1,296 @@
+package jvm
+
+//lint:file-ignore ST1006 MYSTYLE
+// classpath 索引模块。在ClassEntry树之上建立 包 => ClassEntry 的索引，并缓存找不到的class，
+// 避免在大量jar和目录组成的classpath中逐个查找。查找结果与按顺序查找完全一致。
+// 目录中的class可能发生变化，只检查可能改变查找结果的目录，有变化时只重新扫描该目录
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 索引的命中统计
+type ClasspathIndexStats struct {
+	Hits         uint64 // 找到class的次数
+	Misses       uint64 // 查找后仍然没有找到class的次数
+	NegativeHits uint64 // 由缓存直接判定class不存在的次数
+	Rebuilds     uint64 // 建立整个索引的次数
+	Rescans      uint64 // 目录发生变化后重新扫描单个目录的次数
+	Listings     uint64 // 列出压缩包中class的次数，修改时间和大小不变的压缩包使用缓存
+}
+
+// 索引中的一个leaf
+type indexedLeaf struct {
+	entry    ClassEntry
+	indexed  bool                 // 是否能够列出class，不能时每次查找都需要访问
+	packages map[string]bool      // 包含的包
+	modTimes map[string]time.Time // 目录leaf中各个包目录的修改时间，其他leaf为nil
+}
+
+// 压缩包的class列表，压缩包的修改时间和大小不变时重新建立索引不需要再次列出
+type archiveListing struct {
+	modTime  time.Time
+	size     int64
+	packages map[string]bool
+}
+
+type ClasspathIndex struct {
+	root       ClassEntry
+	mutex      sync.Mutex
+	built      bool
+	leaves     []*indexedLeaf             // 按照查找顺序展开后的ClassEntry
+	packages   map[string][]int           // 包（例如 java/lang） => 包含该包的leaf下标，按查找顺序排列
+	unindexed  []int                      // 无法列出class的leaf下标
+	archives   map[string]*archiveListing // 压缩包的绝对路径 => class列表，Invalidate之后仍然保留
+	missing    map[string]bool            // 找不到的class
+	generation uint64                     // 索引每次变化时加1
+	stats      ClasspathIndexStats
+}
+
+// 获取class所在的包，例如 java/lang/Object => java/lang
+func packageOf(classQulifierName string) string {
+	var name = strings.TrimSuffix(classFileName(classQulifierName), __CLASS_FILE_SUFFIX__)
+	if idx := strings.LastIndex(name, "/"); idx >= 0 {
+		return name[:idx]
+	}
+	return ""
+}
+
+// 获取class列表中出现的所有包
+func packagesOf(classes []string) map[string]bool {
+	var packages = make(map[string]bool)
+	for _, class := range classes {
+		packages[packageOf(class)] = true
+	}
+	return packages
+}
+
+// 按照查找顺序展开ClassEntry树
+func flattenClassEntry(entry ClassEntry) []ClassEntry {
+	switch entry := entry.(type) {
+	case nil:
+		return nil
+	case *Classpath:
+		var leaves = flattenClassEntry(entry.bootClasspath)
+		leaves = append(leaves, flattenClassEntry(entry.extClasspath)...)
+		return append(leaves, flattenClassEntry(entry.userClasspath)...)
+	case *CompositeClassEntry:
+		var leaves = make([]ClassEntry, 0, len(entry.entrys))
+		for _, child := range entry.entrys {
+			leaves = append(leaves, flattenClassEntry(child)...)
+		}
+		return leaves
+	case *ClasspathIndex:
+		return flattenClassEntry(entry.root)
+	default:
+		return []ClassEntry{entry}
+	}
+}
+
+// 列出压缩包中的包，修改时间和大小与上次相同时使用缓存，调用方需要持有锁
+func (this *ClasspathIndex) listArchive(archive *CompressedClassEntry) (map[string]bool, error) {
+	var stat, err = os.Stat(archive.entrysAbsolutePath)
+	if err != nil {
+		return nil, err
+	}
+	var cached, ok = this.archives[archive.entrysAbsolutePath]
+	if ok && cached.modTime.Equal(stat.ModTime()) && cached.size == stat.Size() {
+		return cached.packages, nil
+	}
+	var classes []string
+	if classes, err = archive.ListClasses(); err != nil {
+		return nil, err
+	}
+	var packages = packagesOf(classes)
+	this.stats.Listings++
+	this.archives[archive.entrysAbsolutePath] = &archiveListing{modTime: stat.ModTime(), size: stat.Size(), packages: packages}
+	return packages, nil
+}
+
+// 扫描一个leaf包含的包，调用方需要持有锁
+func (this *ClasspathIndex) scan(leaf *indexedLeaf) {
+	leaf.indexed, leaf.packages, leaf.modTimes = false, nil, nil
+	var classes []string
+	var err error
+	switch entry := leaf.entry.(type) {
+	case *DirClassEntry:
+		var modTimes map[string]time.Time
+		if classes, modTimes, err = entry.walk(); err == nil {
+			leaf.modTimes = modTimes
+		}
+	case *CompressedClassEntry:
+		if entry.outer == nil {
+			leaf.packages, err = this.listArchive(entry)
+			break
+		}
+		classes, err = entry.ListClasses()
+	case ClassLister:
+		classes, err = entry.ListClasses()
+	default:
+		return
+	}
+	if err != nil {
+		debug("classpath index fallback => ", leaf.entry, " ", err)
+		leaf.packages = nil
+		return
+	}
+	if leaf.packages == nil {
+		leaf.packages = packagesOf(classes)
+	}
+	leaf.indexed = true
+}
+
+// 由各个leaf包含的包重新建立包索引，并清空找不到的class缓存，调用方需要持有锁
+func (this *ClasspathIndex) reindex() {
+	this.packages = make(map[string][]int)
+	this.unindexed = make([]int, 0)
+	for idx, leaf := range this.leaves {
+		if !leaf.indexed {
+			this.unindexed = append(this.unindexed, idx)
+			continue
+		}
+		for pkg := range leaf.packages {
+			this.packages[pkg] = append(this.packages[pkg], idx)
+		}
+	}
+	this.missing = make(map[string]bool)
+	this.generation++
+}
+
+// 建立索引，调用方需要持有锁
+func (this *ClasspathIndex) build() {
+	var entrys = flattenClassEntry(this.root)
+	this.leaves = make([]*indexedLeaf, len(entrys))
+	for idx, entry := range entrys {
+		this.leaves[idx] = &indexedLeaf{entry: entry}
+		this.scan(this.leaves[idx])
+	}
+	this.reindex()
+	this.built = true
+	this.stats.Rebuilds++
+	debug("classpath index built => leaves ", len(this.leaves), " packages ", len(this.packages), " unindexed ", len(this.unindexed))
+}
+
+// 检查排在limit之前的目录，这些目录中新增的class会改变查找结果。consulted为true时本次查找已经直接访问过
+// 索引中包含该包的目录，只需要检查其余目录。包目录的修改时间有变化时只重新扫描该目录。
+// 返回索引是否发生了变化，调用方需要持有锁
+func (this *ClasspathIndex) refresh(pkg string, limit int, consulted bool) bool {
+	var changed = false
+	for idx := 0; idx < limit && idx < len(this.leaves); idx++ {
+		var leaf = this.leaves[idx]
+		if leaf.modTimes == nil || (consulted && leaf.packages[pkg]) {
+			continue
+		}
+		var dir = leaf.entry.(*DirClassEntry)
+		var modTime time.Time
+		if stat, err := os.Stat(filepath.Join(dir.entrysAbsolutePath, filepath.FromSlash(pkg))); err == nil {
+			modTime = stat.ModTime()
+		}
+		if !modTime.Equal(leaf.modTimes[pkg]) {
+			debug("classpath index rescan => ", dir, " package ", pkg)
+			this.scan(leaf)
+			this.stats.Rescans++
+			changed = true
+		}
+	}
+	if changed {
+		this.reindex()
+	}
+	return changed
+}
+
+// 获取可能包含该包的leaf下标，保持原有的查找顺序
+func (this *ClasspathIndex) candidates(pkg string) []int {
+	var indexes = append(append([]int{}, this.packages[pkg]...), this.unindexed...)
+	sort.Ints(indexes)
+	return indexes
+}
+
+func (this *ClasspathIndex) ReadClass(classQulifierName string) ([]byte, ClassEntry, error) {
+	var name = strings.TrimSuffix(classFileName(classQulifierName), __CLASS_FILE_SUFFIX__)
+	var pkg = packageOf(name)
+	for {
+		this.mutex.Lock()
+		if !this.built {
+			this.build()
+		}
+		var leaves, candidates, generation = this.leaves, this.candidates(pkg), this.generation
+		var negative = this.missing[name]
+		this.mutex.Unlock()
+
+		var bytecode []byte
+		var from ClassEntry
+		var served = len(leaves) // 提供class的leaf下标，没有找到时为leaf的数量
+		for _, idx := range candidates {
+			if negative {
+				break
+			}
+			var data, entry, err = leaves[idx].entry.ReadClass(classQulifierName)
+			if err == nil {
+				bytecode, from, served = data, entry, idx
+				break
+			}
+			if !IsClassNotFound(err) {
+				return nil, nil, err
+			}
+		}
+
+		this.mutex.Lock()
+		// 只有排在提供class的leaf之前的目录可能改变查找结果，有变化时重新查找
+		if generation == this.generation && this.refresh(pkg, served, !negative) {
+			this.mutex.Unlock()
+			continue
+		}
+		switch {
+		case from != nil:
+			this.stats.Hits++
+		case negative:
+			this.stats.NegativeHits++
+		default:
+			this.stats.Misses++
+			if generation == this.generation {
+				this.missing[name] = true // 查找期间索引发生过变化时不缓存
+			}
+		}
+		this.mutex.Unlock()
+		if from == nil {
+			return nil, nil, &ClassNotFoundError{className: classQulifierName}
+		}
+		return bytecode, from, nil
+	}
+}
+
+func (this *ClasspathIndex) String() string {
+	return this.root.String()
+}
+
+// 立即建立索引，不调用时在第一次查找时建立
+func (this *ClasspathIndex) Build() {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.build()
+}
+
+// 丢弃索引和缓存，下一次查找时重新建立。修改时间和大小没有变化的压缩包不会再次列出
+func (this *ClasspathIndex) Invalidate() {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.built = false
+}
+
+// 获取命中统计
+func (this *ClasspathIndex) Stats() ClasspathIndexStats {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.stats
+}
+
+// 在ClassEntry树（例如 *Classpath）之上建立索引，索引在第一次查找时建立
+func NewClasspathIndex(root ClassEntry) *ClasspathIndex {
+	return &ClasspathIndex{root: root, archives: make(map[string]*archiveListing)}
+}
@@ -5,19 +5,148 @@ package jvm
 // 并通过实现ClassEntry接口，实现对classpath下的文件进行读取
 
 import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"io/fs"
+	"io/ioutil"
+	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 const __OS_PATH_SEPARATOR__ = string(os.PathListSeparator) // 系统路径分隔符
+const __CLASS_FILE_SUFFIX__ = ".class"                     // class文件后缀
 
 type ClassEntry interface {
 	ReadClass(classQulifierName string) ([]byte, ClassEntry, error)
 	String() string
 }
 
+// 能够列出自身包含的所有class的ClassEntry，类名形如 java/lang/Object
+type ClassLister interface {
+	ListClasses() ([]string, error)
+}
+
+// 在classpath中找不到对应的class时返回该错误
+type ClassNotFoundError struct {
+	className string
+}
+
+func (this *ClassNotFoundError) Error() string {
+	return "java.lang.ClassNotFoundException => " + this.className
+}
+
+func (this *ClassNotFoundError) ClassName() string { return this.className }
+
+// 判断错误是否为class未找到
+func IsClassNotFound(err error) bool {
+	var notFound *ClassNotFoundError
+	return errors.As(err, &notFound)
+}
+
+// classpath片段构造失败的原因
+type ClasspathErrorKind int
+
+const (
+	CLASSPATH_NOT_FOUND          ClasspathErrorKind = iota + 1 // 路径不存在
+	CLASSPATH_NOT_DIRECTORY                                    // 路径不是目录
+	CLASSPATH_UNREADABLE_ARCHIVE                               // 压缩包无法读取
+	CLASSPATH_INVALID                                          // 路径不合法
+	CLASSPATH_CLOSED                                           // 压缩包已经关闭
+)
+
+func (this ClasspathErrorKind) String() string {
+	switch this {
+	case CLASSPATH_NOT_FOUND:
+		return "not found"
+	case CLASSPATH_NOT_DIRECTORY:
+		return "not a directory"
+	case CLASSPATH_UNREADABLE_ARCHIVE:
+		return "unreadable archive"
+	case CLASSPATH_CLOSED:
+		return "entry closed"
+	default:
+		return "invalid classpath"
+	}
+}
+
+// 构造classpath时产生的错误，记录了出错的classpath片段以及原因
+type ClasspathError struct {
+	segment string             // 出错的classpath片段
+	kind    ClasspathErrorKind // 出错原因
+	err     error              // 底层错误，可能为nil
+}
+
+func (this *ClasspathError) Error() string {
+	var message = "classpath " + this.segment + " => " + this.kind.String()
+	if this.err != nil {
+		message += ": " + this.err.Error()
+	}
+	return message
+}
+
+func (this *ClasspathError) Segment() string { return this.segment }
+
+func (this *ClasspathError) Kind() ClasspathErrorKind { return this.kind }
+
+func (this *ClasspathError) Unwrap() error { return this.err }
+
+// 检查classpath片段是否存在，返回其绝对路径和文件信息
+func statClasspath(classpath string) (string, fs.FileInfo, error) {
+	var absPath, err = filepath.Abs(classpath)
+	if err != nil {
+		return "", nil, &ClasspathError{segment: classpath, kind: CLASSPATH_INVALID, err: err}
+	}
+	var stat fs.FileInfo
+	stat, err = os.Stat(absPath)
+	if err != nil {
+		return "", nil, &ClasspathError{segment: classpath, kind: CLASSPATH_NOT_FOUND, err: err}
+	}
+	return absPath, stat, nil
+}
+
+// 由class文件的相对路径得到类名，不是class文件时返回false
+func classNameOf(fileName string) (string, bool) {
+	if !strings.HasSuffix(fileName, __CLASS_FILE_SUFFIX__) {
+		return "", false
+	}
+	return strings.TrimSuffix(fileName, __CLASS_FILE_SUFFIX__), true
+}
+
+// 将全限定类名转换为class文件的相对路径（使用/作为分隔符）
+// 例如 java/lang/Object 或 com.acme.Main => java/lang/Object.class, com/acme/Main.class
+func classFileName(classQulifierName string) string {
+	var name = strings.TrimSuffix(classQulifierName, __CLASS_FILE_SUFFIX__)
+	name = strings.ReplaceAll(name, ".", "/")
+	return name + __CLASS_FILE_SUFFIX__
+}
+
+// 构造classpath的选项
+type ClasspathOptions struct {
+	Lenient       bool // 与java一致，跳过不存在或无法读取的片段并输出警告
+	Release       int  // 读取多版本jar时的目标Java版本，0表示gava支持的最高版本
+	EnablePreview bool // 是否允许依赖预览特性的class文件，对应 --enable-preview
+}
+
+func (this ClasspathOptions) release() int {
+	if this.Release == 0 {
+		return __DEFAULT_MULTI_RELEASE_VERSION__
+	}
+	return this.Release
+}
+
+func (this ClasspathOptions) parseOptions() ParseOptions {
+	return ParseOptions{EnablePreview: this.EnablePreview}
+}
+
 //+-------------------------------- CompositeClassEntry definition ---------------------------+
 
 type CompositeClassEntry struct {
@@ -26,38 +155,63 @@ type CompositeClassEntry struct {
 	classpath          string // 对应的classpath
 }
 
+// 按照classpath的顺序依次查找，返回第一个找到的class以及提供该class的子ClassEntry
 func (this *CompositeClassEntry) ReadClass(classQulifierName string) ([]byte, ClassEntry, error) {
-	return nil, nil, nil
+	for _, entry := range this.entrys {
+		var bytecode, from, err = entry.ReadClass(classQulifierName)
+		if err == nil {
+			return bytecode, from, nil
+		}
+		if !IsClassNotFound(err) {
+			return nil, nil, err
+		}
+	}
+	return nil, nil, &ClassNotFoundError{className: classQulifierName}
 }
 
 func (this *CompositeClassEntry) String() string {
 	return this.classpath
 }
 
-func newCompositeClassEntry(classpath string) *CompositeClassEntry {
-	var entrysPath = strings.Split(classpath, __OS_PATH_SEPARATOR__)
-	if len(entrysPath) <= 1 {
-		fatal("invalid system path separator")
-	}
-	// 构建绝对路径
-	var entrysAbsolutePath = make([]string, len(entrysPath))
-	for idx, path := range entrysPath {
-		var abs, err = filepath.Abs(path)
-		if err != nil {
-			fatal("invalid classpath => ", path)
+// 获取按照查找顺序排列的子ClassEntry
+func (this *CompositeClassEntry) Entrys() []ClassEntry { return this.entrys }
+
+// 按照分隔符拆分classpath并依次构造ClassEntry。
+// 宽松模式下与java一致，跳过无法使用的片段并输出警告，否则返回第一个错误
+func newCompositeClassEntry(classpath string, options ClasspathOptions) (*CompositeClassEntry, error) {
+	var entrys = make([]ClassEntry, 0)
+	var entrysAbsolutePath = make([]string, 0)
+	for _, path := range strings.Split(classpath, __OS_PATH_SEPARATOR__) {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue // 跳过空的classpath片段，例如 a.jar::b
 		}
-		// 检测文件是否存在
-		_, err = os.Stat(abs)
+		var entry, err = newClassEntry(path, options)
 		if err != nil {
-			fatal("classpath is not exists => ", path)
+			if options.Lenient {
+				warn("skip classpath => ", err)
+				continue
+			}
+			return nil, err
 		}
-		entrysAbsolutePath[idx] = abs
+		entrys = append(entrys, entry)
+		entrysAbsolutePath = append(entrysAbsolutePath, entry.String())
 	}
-	// 解析组合起来的classEntry
-	var entrys = make([]ClassEntry, len(entrysPath))
-	for idx, path := range entrysPath {
-		entrys[idx] = NewClassEntry(path)
+	return &CompositeClassEntry{entrysAbsolutePath: entrysAbsolutePath, entrys: entrys, classpath: classpath}, nil
+}
+
+// 由Go代码中构造好的ClassEntry组合出CompositeClassEntry，查找顺序与参数顺序一致
+func NewCompositeClassEntry(entrys ...ClassEntry) *CompositeClassEntry {
+	return newCompositeClassEntryOf(entrys)
+}
+
+// 由已经构造好的ClassEntry组合出CompositeClassEntry，查找顺序与entrys的顺序一致
+func newCompositeClassEntryOf(entrys []ClassEntry) *CompositeClassEntry {
+	var entrysAbsolutePath = make([]string, len(entrys))
+	for idx, entry := range entrys {
+		entrysAbsolutePath[idx] = entry.String()
 	}
+	var classpath = strings.Join(entrysAbsolutePath, __OS_PATH_SEPARATOR__)
 	return &CompositeClassEntry{entrysAbsolutePath: entrysAbsolutePath, entrys: entrys, classpath: classpath}
 }
 
@@ -69,72 +223,858 @@ type DirClassEntry struct {
 }
 
 func (this *DirClassEntry) ReadClass(classQulifierName string) ([]byte, ClassEntry, error) {
-	return nil, nil, nil
+	var path = filepath.Join(this.entrysAbsolutePath, filepath.FromSlash(classFileName(classQulifierName)))
+	var bytecode, err = ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, &ClassNotFoundError{className: classQulifierName}
+		}
+		return nil, nil, err
+	}
+	return bytecode, this, nil
 }
 
 func (this *DirClassEntry) String() string {
 	return this.entrysAbsolutePath
 }
 
-func newDirClassEntry(classpath string) *DirClassEntry {
-	var absPath, err = filepath.Abs(classpath)
-	if err != nil {
-		fatal("invalid classpath => ", classpath)
-	}
-	// 检测文件是否存在
-	var stat fs.FileInfo
-	stat, err = os.Stat(absPath)
+func (this *DirClassEntry) ListClasses() ([]string, error) {
+	var classes, _, err = this.walk()
+	return classes, err
+}
+
+// 遍历目录，返回所有的类名以及每个包目录的修改时间
+func (this *DirClassEntry) walk() ([]string, map[string]time.Time, error) {
+	var classes = make([]string, 0)
+	var modTimes = make(map[string]time.Time)
+	var err = filepath.WalkDir(this.entrysAbsolutePath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		var rel, relErr = filepath.Rel(this.entrysAbsolutePath, path)
+		if relErr != nil {
+			return relErr
+		}
+		rel = filepath.ToSlash(rel)
+		if entry.IsDir() {
+			var info, infoErr = entry.Info()
+			if infoErr != nil {
+				return infoErr
+			}
+			if rel == "." {
+				rel = ""
+			}
+			modTimes[rel] = info.ModTime()
+			return nil
+		}
+		if name, ok := classNameOf(rel); ok {
+			classes = append(classes, name)
+		}
+		return nil
+	})
+	return classes, modTimes, err
+}
+
+func newDirClassEntry(classpath string) (*DirClassEntry, error) {
+	var absPath, stat, err = statClasspath(classpath)
 	if err != nil {
-		fatal("classpath is not exists => ", classpath)
+		return nil, err
 	}
 	// 检测是否是dir
 	if !stat.IsDir() {
-		fatal("classpath is not a valid dir => ", classpath)
+		return nil, &ClasspathError{segment: classpath, kind: CLASSPATH_NOT_DIRECTORY}
 	}
-	return &DirClassEntry{entrysAbsolutePath: absPath, classpath: classpath}
+	return &DirClassEntry{entrysAbsolutePath: absPath, classpath: classpath}, nil
+}
+
+//+--------------------------------- FSClassEntry definition ---------------------------------+
+
+// 基于 io/fs.FS 的ClassEntry，可以是 embed.FS、fstest.MapFS、zip.Reader 等。
+// class文件需要位于fsys的根目录下，embed.FS 通常需要先经过 fs.Sub 去掉目录前缀
+type FSClassEntry struct {
+	fsys fs.FS
+	name string // 用于显示的名称
+}
+
+func (this *FSClassEntry) ReadClass(classQulifierName string) ([]byte, ClassEntry, error) {
+	var bytecode, err = fs.ReadFile(this.fsys, classFileName(classQulifierName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, &ClassNotFoundError{className: classQulifierName}
+		}
+		return nil, nil, err
+	}
+	return bytecode, this, nil
+}
+
+func (this *FSClassEntry) String() string {
+	return this.name
+}
+
+func (this *FSClassEntry) ListClasses() ([]string, error) {
+	var classes = make([]string, 0)
+	var err = fs.WalkDir(this.fsys, ".", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name, ok := classNameOf(path); ok && !entry.IsDir() {
+			classes = append(classes, name)
+		}
+		return nil
+	})
+	return classes, err
+}
+
+// 由fs.FS构造ClassEntry，name用于在String中展示该entry
+func NewFSClassEntry(fsys fs.FS, name string) *FSClassEntry {
+	return &FSClassEntry{fsys: fsys, name: name}
 }
 
 //+--------------------------------- CompressedClassEntry -------------------------------------+
 
+const __MULTI_RELEASE__ = "Multi-Release"        // manifest中标识多版本jar的属性
+const __VERSIONS_PREFIX__ = "META-INF/versions/" // 多版本jar中各版本class的目录
+const __MIN_MULTI_RELEASE_VERSION__ = 9          // 多版本jar支持的最低版本
+
+// 读取多版本jar时默认的目标Java版本，即gava支持的最高class文件版本对应的Java版本
+const __DEFAULT_MULTI_RELEASE_VERSION__ = __MAX_MAJOR_VERSION__ - __MAJOR_VERSION_OFFSET__
+
 type CompressedClassEntry struct {
 	entrysAbsolutePath string
 	compressedType     string
 	classpath          string
+	outer              *CompressedClassEntry // 非nil时表示嵌套在outer中的压缩包，例如 app.jar!/BOOT-INF/lib/dep.jar
+	innerName          string                // 嵌套压缩包在outer中的文件名
+	release            int                   // 读取多版本jar时的目标Java版本
+	once               sync.Once             // 压缩包只打开一次
+	source             io.ReaderAt           // 压缩包的数据源
+	closer             io.Closer             // 压缩包对应的文件
+	reader             *zip.Reader           // 打开的压缩包
+	index              map[string]*zip.File  // 压缩包内文件名 => 文件
+	openErr            error                 // 打开压缩包时产生的错误
+}
+
+// 打开压缩包并建立文件名索引。压缩包中可能没有目录项，因此只按文件名建立索引
+func (this *CompressedClassEntry) open() error {
+	this.once.Do(func() {
+		var source, size, err = this.openSource()
+		if err != nil {
+			this.openErr = &ClasspathError{segment: this.entrysAbsolutePath, kind: CLASSPATH_UNREADABLE_ARCHIVE, err: err}
+			return
+		}
+		var reader *zip.Reader
+		reader, err = zip.NewReader(source, size)
+		if err != nil {
+			if this.closer != nil {
+				this.closer.Close()
+			}
+			this.openErr = &ClasspathError{segment: this.entrysAbsolutePath, kind: CLASSPATH_UNREADABLE_ARCHIVE, err: err}
+			return
+		}
+		this.source = source
+		this.reader = reader
+		this.index = make(map[string]*zip.File, len(reader.File))
+		for _, file := range reader.File {
+			if strings.HasSuffix(file.Name, "/") {
+				continue // 跳过目录项
+			}
+			this.index[file.Name] = file
+		}
+		var manifest, _ = this.readManifest()
+		if manifest != nil && strings.EqualFold(manifest.Get(__MULTI_RELEASE__), "true") {
+			this.indexVersionedFiles(this.release)
+		}
+		debug("open compressed classpath => ", this.entrysAbsolutePath, " files => ", len(this.index))
+	})
+	if this.openErr == nil && this.outer != nil {
+		return this.outer.open() // 外层压缩包关闭后嵌套的压缩包也无法读取
+	}
+	return this.openErr
+}
+
+// 打开压缩包的数据源。嵌套的压缩包从外层压缩包中读取：
+// 未压缩（stored）的直接在外层压缩包的数据上读取，压缩过（deflated）的解压到内存中
+func (this *CompressedClassEntry) openSource() (io.ReaderAt, int64, error) {
+	if this.outer == nil {
+		var file, err = os.Open(this.entrysAbsolutePath)
+		if err != nil {
+			return nil, 0, err
+		}
+		var stat fs.FileInfo
+		stat, err = file.Stat()
+		if err != nil {
+			file.Close()
+			return nil, 0, err
+		}
+		this.closer = file
+		return file, stat.Size(), nil
+	}
+	if err := this.outer.open(); err != nil {
+		return nil, 0, err
+	}
+	var file, ok = this.outer.index[this.innerName]
+	if !ok {
+		return nil, 0, fmt.Errorf("nested archive is not exists => %s", this.entrysAbsolutePath)
+	}
+	if file.Method == zip.Store {
+		var offset, err = file.DataOffset()
+		if err != nil {
+			return nil, 0, err
+		}
+		var size = int64(file.UncompressedSize64)
+		return io.NewSectionReader(this.outer.source, offset, size), size, nil
+	}
+	var data, err = readZipFile(file)
+	if err != nil {
+		return nil, 0, err
+	}
+	debug("inflate nested archive into memory => ", this.entrysAbsolutePath)
+	return bytes.NewReader(data), int64(len(data)), nil
+}
+
+// 多版本jar中，使用 META-INF/versions/N/ 下的class覆盖根目录的class，
+// N 为不超过release的最高版本
+func (this *CompressedClassEntry) indexVersionedFiles(release int) {
+	var versions = make(map[string]int)
+	for name, file := range this.index {
+		if !strings.HasPrefix(name, __VERSIONS_PREFIX__) {
+			continue
+		}
+		var rest = name[len(__VERSIONS_PREFIX__):]
+		var slash = strings.Index(rest, "/")
+		if slash <= 0 {
+			continue
+		}
+		var version, err = strconv.Atoi(rest[:slash])
+		if err != nil || version < __MIN_MULTI_RELEASE_VERSION__ || version > release {
+			continue
+		}
+		var realName = rest[slash+1:]
+		if version > versions[realName] {
+			versions[realName] = version
+			this.index[realName] = file
+		}
+	}
+	debug("multi-release jar => ", this.entrysAbsolutePath, " release => ", release, " versioned files => ", len(versions))
 }
 
 func (this *CompressedClassEntry) ReadClass(classQulifierName string) ([]byte, ClassEntry, error) {
-	return nil, nil, nil
+	if err := this.open(); err != nil {
+		return nil, nil, err
+	}
+	var file, ok = this.index[classFileName(classQulifierName)]
+	if !ok {
+		return nil, nil, &ClassNotFoundError{className: classQulifierName}
+	}
+	var bytecode, err = readZipFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bytecode, this, nil
+}
+
+// 读取压缩包中的 META-INF/MANIFEST.MF，没有manifest时返回nil
+func (this *CompressedClassEntry) Manifest() (*Manifest, error) {
+	if err := this.open(); err != nil {
+		return nil, err
+	}
+	return this.readManifest()
+}
+
+func (this *CompressedClassEntry) readManifest() (*Manifest, error) {
+	var file, ok = this.index[__MANIFEST_NAME__]
+	if !ok {
+		return nil, nil
+	}
+	var data, err = readZipFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return ParseManifest(data)
+}
+
+// 列出压缩包中的所有class，META-INF 下的class（例如多版本jar中的各版本class）不包含在内
+func (this *CompressedClassEntry) ListClasses() ([]string, error) {
+	if err := this.open(); err != nil {
+		return nil, err
+	}
+	var classes = make([]string, 0, len(this.index))
+	for fileName := range this.index {
+		if name, ok := classNameOf(fileName); ok && !strings.HasPrefix(fileName, "META-INF/") {
+			classes = append(classes, name)
+		}
+	}
+	sort.Strings(classes)
+	return classes, nil
+}
+
+// 获取压缩包内指定目录下的所有文件名（不递归），按照文件名排序
+func (this *CompressedClassEntry) list(dir string) []string {
+	var names = make([]string, 0)
+	for name := range this.index {
+		if strings.HasPrefix(name, dir) && !strings.Contains(name[len(dir):], "/") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// 关闭打开的压缩包，之后的读取都会返回 CLASSPATH_CLOSED 错误。Close不能与其他方法并发调用
+func (this *CompressedClassEntry) Close() error {
+	this.once.Do(func() {}) // 没有打开过时，之后也不会再打开
+	var closer = this.closer
+	this.source, this.closer, this.reader, this.index = nil, nil, nil, nil
+	this.openErr = &ClasspathError{segment: this.entrysAbsolutePath, kind: CLASSPATH_CLOSED}
+	if closer == nil {
+		return nil
+	}
+	return closer.Close()
+}
+
+// 构造嵌套在当前压缩包中的ClassEntry，innerName可以是压缩包（BOOT-INF/lib/dep.jar）或目录（BOOT-INF/classes）
+func (this *CompressedClassEntry) nested(innerName string) ClassEntry {
+	innerName = strings.Trim(innerName, "/")
+	if innerName == "" {
+		return this
+	}
+	var path = this.entrysAbsolutePath + __NESTED_SEPARATOR__ + innerName
+	if isCompressed(innerName) {
+		var compressedType = strings.TrimPrefix(filepath.Ext(innerName), ".")
+		return &CompressedClassEntry{entrysAbsolutePath: path, classpath: path, compressedType: compressedType, outer: this, innerName: innerName, release: this.release}
+	}
+	return &NestedDirClassEntry{outer: this, prefix: innerName + "/", entrysAbsolutePath: path}
 }
 
 func (this *CompressedClassEntry) String() string {
 	return this.entrysAbsolutePath
 }
 
-func newCompressedClassEntry(classpath string) *CompressedClassEntry {
-	var absPath, err = filepath.Abs(classpath)
+// 构造时即打开压缩包，以便尽早发现无法读取的压缩包。release为读取多版本jar时的目标Java版本
+func newCompressedClassEntry(classpath string, release int) (*CompressedClassEntry, error) {
+	var absPath, stat, err = statClasspath(classpath)
 	if err != nil {
-		fatal("invalid classpath => ", classpath)
+		return nil, err
 	}
-	// 检测文件是否存在
-	_, err = os.Stat(absPath)
-	if err != nil {
-		fatal("classpath is not exists => ", classpath)
+	if stat.IsDir() {
+		return nil, &ClasspathError{segment: classpath, kind: CLASSPATH_UNREADABLE_ARCHIVE, err: errors.New("is a directory")}
 	}
 
 	var spliteResult = strings.Split(classpath, ".")
 	var compressedType = spliteResult[len(spliteResult)-1]
 
-	return &CompressedClassEntry{entrysAbsolutePath: absPath, classpath: classpath, compressedType: compressedType}
+	var entry = &CompressedClassEntry{entrysAbsolutePath: absPath, classpath: classpath, compressedType: compressedType, release: release}
+	if err = entry.open(); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// 读取压缩包中的一个文件，archive/zip 会根据压缩方式（store/deflate）自动选择解压器
+func readZipFile(file *zip.File) ([]byte, error) {
+	var rc, err = file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return ioutil.ReadAll(rc)
+}
+
+//+--------------------------------- NestedDirClassEntry definition ---------------------------+
+
+const __NESTED_SEPARATOR__ = "!/" // 嵌套classpath分隔符，例如 app.jar!/BOOT-INF/lib/dep.jar
+
+// 压缩包内的目录，例如 app.jar!/BOOT-INF/classes
+type NestedDirClassEntry struct {
+	outer              *CompressedClassEntry
+	prefix             string   // 目录在压缩包中的前缀，例如 BOOT-INF/classes/
+	excludes           []string // 不属于该entry的子目录，例如fat jar根目录下的 BOOT-INF/
+	entrysAbsolutePath string
+}
+
+// 判断压缩包中的文件是否属于该entry
+func (this *NestedDirClassEntry) contains(fileName string) bool {
+	if !strings.HasPrefix(fileName, this.prefix) {
+		return false
+	}
+	for _, exclude := range this.excludes {
+		if strings.HasPrefix(fileName, exclude) {
+			return false
+		}
+	}
+	return true
+}
+
+func (this *NestedDirClassEntry) ReadClass(classQulifierName string) ([]byte, ClassEntry, error) {
+	if err := this.outer.open(); err != nil {
+		return nil, nil, err
+	}
+	var fileName = this.prefix + classFileName(classQulifierName)
+	var file, ok = this.outer.index[fileName]
+	if !ok || !this.contains(fileName) {
+		return nil, nil, &ClassNotFoundError{className: classQulifierName}
+	}
+	var bytecode, err = readZipFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bytecode, this, nil
+}
+
+func (this *NestedDirClassEntry) String() string {
+	return this.entrysAbsolutePath
+}
+
+func (this *NestedDirClassEntry) ListClasses() ([]string, error) {
+	if err := this.outer.open(); err != nil {
+		return nil, err
+	}
+	var classes = make([]string, 0)
+	for fileName := range this.outer.index {
+		if !this.contains(fileName) || this.prefix == "" && strings.HasPrefix(fileName, "META-INF/") {
+			continue
+		}
+		if name, ok := classNameOf(fileName[len(this.prefix):]); ok {
+			classes = append(classes, name)
+		}
+	}
+	sort.Strings(classes)
+	return classes, nil
+}
+
+// 解析 outer.jar!/inner 形式的classpath，支持多层嵌套
+func newNestedClassEntry(classpath string, release int) (ClassEntry, error) {
+	var idx = strings.LastIndex(classpath, __NESTED_SEPARATOR__)
+	var outerPath, innerName = classpath[:idx], classpath[idx+len(__NESTED_SEPARATOR__):]
+	var outer *CompressedClassEntry
+	if strings.Contains(outerPath, __NESTED_SEPARATOR__) {
+		var entry, err = newNestedClassEntry(outerPath, release)
+		if err != nil {
+			return nil, err
+		}
+		var ok bool
+		outer, ok = entry.(*CompressedClassEntry)
+		if !ok {
+			return nil, &ClasspathError{segment: classpath, kind: CLASSPATH_INVALID, err: errors.New("nested classpath must be inside an archive")}
+		}
+	} else {
+		var err error
+		outer, err = newCompressedClassEntry(outerPath, release)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var entry = outer.nested(innerName)
+	if nested, ok := entry.(*CompressedClassEntry); ok {
+		if err := nested.open(); err != nil {
+			return nil, err
+		}
+	}
+	return entry, nil
+}
+
+//+--------------------------------- Wildcard definition -------------------------------------+
+
+const __WILDCARD__ = "*" // classpath通配符
+
+// 判断classpath是否为通配符形式，例如 * 或 lib/*
+func isWildcard(classpath string) bool {
+	return classpath == __WILDCARD__ ||
+		strings.HasSuffix(classpath, "/"+__WILDCARD__) ||
+		strings.HasSuffix(classpath, string(filepath.Separator)+__WILDCARD__)
+}
+
+// 与java一致，lib/* 展开为lib目录下所有的.jar和.JAR文件（不递归），目录不存在时展开为空
+func newWildcardClassEntry(classpath string, options ClasspathOptions) (*CompositeClassEntry, error) {
+	var dir = strings.TrimSuffix(classpath, __WILDCARD__)
+	if dir == "" {
+		dir = "."
+	}
+	var absDir, err = filepath.Abs(dir)
+	if err != nil {
+		return nil, &ClasspathError{segment: classpath, kind: CLASSPATH_INVALID, err: err}
+	}
+	var jars = listJarFiles(absDir)
+	var entrys = make([]ClassEntry, 0, len(jars))
+	for _, jar := range jars {
+		var entry, err = newCompressedClassEntry(jar, options.release())
+		if err != nil {
+			if options.Lenient {
+				warn("skip classpath => ", err)
+				continue
+			}
+			return nil, err
+		}
+		entrys = append(entrys, entry)
+	}
+	debug("expand classpath wildcard => ", classpath, " jars => ", len(entrys))
+	return newCompositeClassEntryOf(entrys), nil
 }
 
 //+--------------------------------- Package functions ------------------------------------+
 
-func NewClassEntry(classpath string) ClassEntry {
+// 判断classpath是否指向一个压缩包
+func isCompressed(classpath string) bool {
+	var ext = strings.ToLower(filepath.Ext(classpath))
+	return ext == ".jar" || ext == ".zip"
+}
+
+// 根据classpath构造ClassEntry，任何一个片段无法使用时返回 *ClasspathError
+func NewClassEntry(classpath string) (ClassEntry, error) {
+	return newClassEntry(classpath, ClasspathOptions{})
+}
+
+// 宽松模式构造ClassEntry，与java一致，跳过不存在或无法读取的片段并输出警告
+func NewLenientClassEntry(classpath string) (ClassEntry, error) {
+	return NewClassEntryWithOptions(classpath, ClasspathOptions{Lenient: true})
+}
+
+// 使用指定的选项构造ClassEntry
+func NewClassEntryWithOptions(classpath string, options ClasspathOptions) (ClassEntry, error) {
+	if !options.Lenient {
+		return newClassEntry(classpath, options)
+	}
+	var entry, err = newCompositeClassEntry(strings.TrimSpace(classpath), options)
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func newClassEntry(classpath string, options ClasspathOptions) (ClassEntry, error) {
+	classpath = strings.TrimSpace(classpath)
+	var entry ClassEntry
+	var err error
 	if strings.Contains(classpath, __OS_PATH_SEPARATOR__) {
-		return newCompositeClassEntry(classpath)
-	} else if strings.HasSuffix(classpath, "jar") || strings.HasSuffix(classpath, "zip") {
-		return newCompressedClassEntry(classpath)
+		entry, err = newCompositeClassEntry(classpath, options)
+	} else if strings.Contains(classpath, __NESTED_SEPARATOR__) {
+		entry, err = newNestedClassEntry(classpath, options.release())
+	} else if isWildcard(classpath) {
+		entry, err = newWildcardClassEntry(classpath, options)
+	} else if isCompressed(classpath) {
+		entry, err = newCompressedClassEntry(classpath, options.release())
 	} else {
-		return newDirClassEntry(classpath)
+		entry, err = newDirClassEntry(classpath)
+	}
+	if err != nil {
+		return nil, err // 避免返回包含nil指针的接口
+	}
+	return entry, nil
+}
+
+// 列出目录下所有的jar文件（不递归），按照文件名排序
+func listJarFiles(dir string) []string {
+	var files, err = ioutil.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var jars = make([]string, 0, len(files))
+	for _, file := range files {
+		if !file.IsDir() && (strings.HasSuffix(file.Name(), ".jar") || strings.HasSuffix(file.Name(), ".JAR")) {
+			jars = append(jars, filepath.Join(dir, file.Name()))
+		}
+	}
+	return jars
+}
+
+//+--------------------------------- Classpath definition ------------------------------------+
+
+const __RT_JAR__ = "rt.jar" // 启动类路径中最先查找的jar
+
+// 与java命令一致的三级classpath，查找顺序为 启动类路径 -> 扩展类路径 -> 用户类路径
+type Classpath struct {
+	bootClasspath ClassEntry // jre/lib/*.jar
+	extClasspath  ClassEntry // jre/lib/ext/*
+	userClasspath ClassEntry // -cp 指定的路径
+	index         *ClasspathIndex
+	options       ClasspathOptions
+}
+
+// 通过索引查找，结果与依次查找三级classpath一致
+func (this *Classpath) ReadClass(classQulifierName string) ([]byte, ClassEntry, error) {
+	return this.index.ReadClass(classQulifierName)
+}
+
+// 查找并解析class，使用构造classpath时的选项
+func (this *Classpath) ParseClass(classQulifierName string) (*JavaClass, ClassEntry, error) {
+	var bytecode, from, err = this.ReadClass(classQulifierName)
+	if err != nil {
+		return nil, nil, err
+	}
+	var javaClass *JavaClass
+	if javaClass, err = ParseJavaByteCodeWithOptions(bytecode, this.options.parseOptions()); err != nil {
+		return nil, nil, err
+	}
+	return javaClass, from, nil
+}
+
+func (this *Classpath) String() string {
+	return strings.Join([]string{
+		this.bootClasspath.String(),
+		this.extClasspath.String(),
+		this.userClasspath.String(),
+	}, __OS_PATH_SEPARATOR__)
+}
+
+// 在用户类路径的末尾追加一个ClassEntry，例如由 embed.FS 构造的 FSClassEntry
+func (this *Classpath) AddUserClassEntry(entry ClassEntry) {
+	this.setUserClasspath(newCompositeClassEntryOf([]ClassEntry{this.userClasspath, entry}))
+}
+
+// 替换用户类路径，索引在下一次查找时重新建立
+func (this *Classpath) setUserClasspath(entry ClassEntry) {
+	this.userClasspath = entry
+	this.index.Invalidate()
+}
+
+func (this *Classpath) BootClasspath() ClassEntry { return this.bootClasspath }
+
+func (this *Classpath) ExtClasspath() ClassEntry { return this.extClasspath }
+
+func (this *Classpath) UserClasspath() ClassEntry { return this.userClasspath }
+
+func (this *Classpath) Index() *ClasspathIndex { return this.index }
+
+// 确定jre目录，-Xjre优先，其次是 SYS_JAVA_JRE_HOME。
+// JAVA_HOME 指向jdk时使用其下的jre目录，JRE_HOME 则直接指向jre目录
+func getJreDir(jreOption string) (string, error) {
+	var home = jreOption
+	if home == "" {
+		home = SYS_JAVA_JRE_HOME
+	}
+	if home == "" {
+		return "", errors.New("can not find jre, please set JAVA_HOME or use -Xjre")
+	}
+	var absHome, stat, err = statClasspath(home)
+	if err != nil {
+		return "", err
+	}
+	if !stat.IsDir() {
+		return "", &ClasspathError{segment: home, kind: CLASSPATH_NOT_DIRECTORY}
+	}
+	var jre = filepath.Join(absHome, "jre")
+	if stat, err := os.Stat(jre); err == nil && stat.IsDir() {
+		return jre, nil
+	}
+	return absHome, nil
+}
+
+// 启动类路径 jre/lib/*.jar，rt.jar 最先查找。JDK 9+ 的布局则使用 lib/modules
+func newBootClassEntry(jreDir string, options ClasspathOptions) (ClassEntry, error) {
+	if isJImageLayout(jreDir) {
+		var entry, err = newJImageClassEntry(filepath.Join(jreDir, "lib", __JIMAGE_MODULES__))
+		if err != nil {
+			return nil, err
+		}
+		return entry, nil
+	}
+	var jars = listJarFiles(filepath.Join(jreDir, "lib"))
+	var entrys = make([]ClassEntry, 0, len(jars))
+	for _, jar := range jars {
+		var entry, err = newCompressedClassEntry(jar, options.release())
+		if err != nil {
+			if options.Lenient {
+				warn("skip boot classpath => ", err)
+				continue
+			}
+			return nil, err
+		}
+		if filepath.Base(jar) == __RT_JAR__ {
+			entrys = append([]ClassEntry{entry}, entrys...)
+		} else {
+			entrys = append(entrys, entry)
+		}
+	}
+	return newCompositeClassEntryOf(entrys), nil
+}
+
+// 扩展类路径 jre/lib/ext/*
+func newExtClassEntry(jreDir string, options ClasspathOptions) (ClassEntry, error) {
+	var entry, err = newWildcardClassEntry(filepath.Join(jreDir, "lib", "ext", __WILDCARD__), options)
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// 确定用户类路径：-cp 优先，其次是 CLASSPATH 环境变量，两者都没有时使用当前目录
+func getUserClasspath(cpOption string) string {
+	cpOption = strings.TrimSpace(cpOption)
+	if cpOption == "" {
+		cpOption = strings.TrimSpace(os.Getenv("CLASSPATH"))
+	}
+	if cpOption == "" {
+		cpOption = "."
+	}
+	return cpOption
+}
+
+// 构造classpath，jreOption对应 -Xjre，cpOption对应 -cp。
+// 没有指定 -cp 时使用 CLASSPATH 环境变量，两者都没有时使用当前目录
+func NewClasspath(jreOption string, cpOption string) (*Classpath, error) {
+	return NewClasspathWithOptions(jreOption, cpOption, ClasspathOptions{})
+}
+
+// 与 NewClasspath 相同，但使用宽松模式，跳过不存在或无法读取的片段
+func NewLenientClasspath(jreOption string, cpOption string) (*Classpath, error) {
+	return NewClasspathWithOptions(jreOption, cpOption, ClasspathOptions{Lenient: true})
+}
+
+// 使用指定的选项构造classpath，宽松模式下启动类路径、扩展类路径和用户类路径中无法读取的jar都会被跳过
+func NewClasspathWithOptions(jreOption string, cpOption string, options ClasspathOptions) (*Classpath, error) {
+	var user, err = NewClassEntryWithOptions(getUserClasspath(cpOption), options)
+	if err != nil {
+		return nil, err
+	}
+	return newClasspath(jreOption, user, options)
+}
+
+// 以 -jar 方式构造classpath，此时忽略 -cp 和 CLASSPATH。
+// 用户类路径由jar本身以及manifest中 Class-Path 递归引用的路径组成，返回classpath和 Main-Class。
+// Spring Boot 的fat jar则使用 BOOT-INF/classes 和 BOOT-INF/lib 下的jar，入口类为 Start-Class
+func NewJarClasspath(jreOption string, jarPath string) (*Classpath, string, error) {
+	return NewJarClasspathWithOptions(jreOption, jarPath, ClasspathOptions{})
+}
+
+// 使用指定的选项以 -jar 方式构造classpath，Class-Path 中无法读取的路径总是被忽略
+func NewJarClasspathWithOptions(jreOption string, jarPath string, options ClasspathOptions) (*Classpath, string, error) {
+	var jar, err = newCompressedClassEntry(jarPath, options.release())
+	if err != nil {
+		return nil, "", err
+	}
+	var manifest *Manifest
+	manifest, err = jar.Manifest()
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid or corrupt jarfile %s => %w", jarPath, err)
+	}
+	if manifest == nil || manifest.MainClass() == "" {
+		return nil, "", fmt.Errorf("no main manifest attribute, in %s", jarPath)
+	}
+	var entrys []ClassEntry
+	var mainClass = manifest.MainClass()
+	if isSpringBootJar(manifest) {
+		entrys, err = collectSpringBootClassEntrys(jar, manifest)
+		if err != nil {
+			return nil, "", err
+		}
+		mainClass = manifest.Get(__SPRING_BOOT_START_CLASS__)
+	} else {
+		entrys = collectJarClassEntrys(jar, manifest, map[string]bool{})
+	}
+	var classpath *Classpath
+	classpath, err = newClasspath(jreOption, newCompositeClassEntryOf(entrys), options)
+	if err != nil {
+		return nil, "", err
+	}
+	return classpath, mainClass, nil
+}
+
+// Spring Boot fat jar 的manifest属性及默认值
+const (
+	__SPRING_BOOT_START_CLASS__     = "Start-Class"
+	__SPRING_BOOT_CLASSES__         = "Spring-Boot-Classes"
+	__SPRING_BOOT_LIB__             = "Spring-Boot-Lib"
+	__SPRING_BOOT_DEFAULT_CLASSES__ = "BOOT-INF/classes/"
+	__SPRING_BOOT_DEFAULT_LIB__     = "BOOT-INF/lib/"
+	__SPRING_BOOT_INF__             = "BOOT-INF/"
+)
+
+func isSpringBootJar(manifest *Manifest) bool {
+	return manifest.Get(__SPRING_BOOT_START_CLASS__) != ""
+}
+
+// Spring Boot fat jar 的用户类路径：BOOT-INF/classes -> BOOT-INF/lib/*.jar -> jar本身（Boot的loader类）。
+// jar本身只提供loader类，BOOT-INF/ 下的文件不作为class提供
+func collectSpringBootClassEntrys(jar *CompressedClassEntry, manifest *Manifest) ([]ClassEntry, error) {
+	var classes = manifest.Get(__SPRING_BOOT_CLASSES__)
+	if classes == "" {
+		classes = __SPRING_BOOT_DEFAULT_CLASSES__
+	}
+	var lib = manifest.Get(__SPRING_BOOT_LIB__)
+	if lib == "" {
+		lib = __SPRING_BOOT_DEFAULT_LIB__
+	}
+	lib = strings.TrimSuffix(lib, "/") + "/"
+	var entrys = []ClassEntry{jar.nested(classes)}
+	for _, name := range jar.list(lib) {
+		if !isCompressed(name) {
+			continue
+		}
+		var entry = jar.nested(name)
+		if err := entry.(*CompressedClassEntry).open(); err != nil {
+			return nil, err
+		}
+		entrys = append(entrys, entry)
+	}
+	var loader = &NestedDirClassEntry{outer: jar, entrysAbsolutePath: jar.entrysAbsolutePath,
+		excludes: []string{__SPRING_BOOT_INF__, strings.TrimSuffix(classes, "/") + "/", lib}}
+	return append(entrys, loader), nil
+}
+
+// 按照manifest中的 Class-Path 递归收集ClassEntry，visited用于检测循环引用。
+// Class-Path 中的路径相对于jar所在的目录，不存在或无法读取的路径与java一样直接忽略
+func collectJarClassEntrys(jar *CompressedClassEntry, manifest *Manifest, visited map[string]bool) []ClassEntry {
+	visited[jar.entrysAbsolutePath] = true
+	var entrys = []ClassEntry{jar}
+	if manifest == nil {
+		return entrys
+	}
+	var base = filepath.Dir(jar.entrysAbsolutePath)
+	for _, path := range manifest.ClassPath() {
+		if unescaped, err := url.PathUnescape(path); err == nil {
+			path = unescaped
+		}
+		path = filepath.FromSlash(path)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(base, path)
+		}
+		if visited[path] {
+			continue
+		}
+		var stat, err = os.Stat(path)
+		if err != nil {
+			debug("ignore missing manifest class path => ", path)
+			continue
+		}
+		if stat.IsDir() {
+			visited[path] = true
+			if dir, err := newDirClassEntry(path); err == nil {
+				entrys = append(entrys, dir)
+			}
+			continue
+		}
+		if !isCompressed(path) {
+			debug("ignore unsupported manifest class path => ", path)
+			continue
+		}
+		var child *CompressedClassEntry
+		child, err = newCompressedClassEntry(path, jar.release)
+		if err != nil {
+			warn("ignore manifest class path => ", err)
+			visited[path] = true
+			continue
+		}
+		var childManifest, _ = child.Manifest()
+		entrys = append(entrys, collectJarClassEntrys(child, childManifest, visited)...)
+	}
+	return entrys
+}
+
+// 构造三级classpath，user为用户类路径
+func newClasspath(jreOption string, user ClassEntry, options ClasspathOptions) (*Classpath, error) {
+	var jreDir, err = getJreDir(jreOption)
+	if err != nil {
+		return nil, err
+	}
+	debug("jre dir => ", jreDir)
+	var boot, ext ClassEntry
+	if boot, err = newBootClassEntry(jreDir, options); err != nil {
+		return nil, err
+	}
+	if ext, err = newExtClassEntry(jreDir, options); err != nil {
+		return nil, err
 	}
+	var classpath = &Classpath{bootClasspath: boot, extClasspath: ext, userClasspath: user, options: options}
+	classpath.index = NewClasspathIndex(classpath)
+	return classpath, nil
 }
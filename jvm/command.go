@@ -7,12 +7,23 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 )
 
 // gava虚拟机命令行参数
 const __HELP_FLAG_USAGE__ = "help will show the gava usage"
 const __VERSION_FLAG_USAGE__ = "version will show the gava version"
 const __CLASSPATH_FLAG_USAGE__ = "classpath will allow you to set gava virtual machine class path"
+const __JAR_FLAG_USAGE__ = "jar will run the Main-Class of the given jar, the remaining arguments are passed to it"
+const __MODULE_PATH_FLAG_USAGE__ = "module-path will set the directories, jars and exploded modules to search for modules"
+const __MODULE_FLAG_USAGE__ = "m will run the main class of the given module/mainclass, the remaining arguments are passed to it"
+const __XLENIENT_FLAG_USAGE__ = "Xlenient will skip missing or unreadable classpath entries instead of failing"
+const __XJRE_FLAG_USAGE__ = "Xjre will override the jre directory found from JAVA_HOME or JRE_HOME"
+const __ENABLE_PREVIEW_FLAG_USAGE__ = "enable-preview will allow classes that depend on preview features of the latest supported release"
+const __DUPLICATES_FLAG_USAGE__ = "duplicates will list the classes shadowed by an earlier classpath entry"
+
+// gava子命令
+const TOOL_CLASSPATH = "classpath" // gava classpath describe | gava classpath which class | gava classpath --duplicates
 
 var SYS_JAVA_JRE_HOME string = ""
 
@@ -27,7 +38,15 @@ func init() {
 type Command struct {
 	Version         bool     // 是否显示版本号
 	ClassPath       string   // classpath
+	XjreOption      string   // jre目录
+	Jar             string   // -jar 指定的jar文件
+	ModulePath      string   // --module-path 指定的模块路径
+	Module          string   // -m 指定的模块和入口类，例如 com.acme.app/com.acme.Main
+	Lenient         bool     // 是否跳过无法使用的classpath
+	EnablePreview   bool     // 是否允许依赖预览特性的class文件
 	Help            bool     // 是否显示help
+	Tool            string   // 子命令，例如 classpath
+	Duplicates      bool     // classpath子命令：是否列出被遮蔽的class
 	EntryPointClass string   // 入口的class文件
 	Args            []string // 运行时参数
 }
@@ -35,7 +54,42 @@ type Command struct {
 // 解析gava虚拟机参数
 
 func gavaUsage() {
-	fmt.Println("usage: gava [options...] file [args..]")
+	fmt.Println("usage: gava [options...] class [args..]")
+	fmt.Println("   or  gava [options...] -jar jarfile [args...]")
+	fmt.Println("   or  gava [options...] -m module[/mainclass] [args...]")
+	fmt.Println("   or  gava classpath [options...] describe")
+	fmt.Println("   or  gava classpath [options...] which class")
+	fmt.Println("   or  gava classpath [options...] --duplicates")
+}
+
+// 找到 -jar 指定的jar文件或 -m 指定的模块之后的第一个参数位置，两者都没有时返回-1。
+// 之后的参数（即使以-开头）全部属于应用程序
+func launchArgsStart(args []string) int {
+	for idx := 0; idx < len(args); idx++ {
+		var arg = args[idx]
+		if arg == "--" || arg == "-" || !strings.HasPrefix(arg, "-") {
+			return -1 // gava参数已经结束
+		}
+		var name = strings.TrimLeft(arg, "-")
+		var hasValue = strings.Contains(name, "=")
+		if hasValue {
+			name = name[:strings.Index(name, "=")]
+		}
+		if name == "jar" || name == "m" || name == "module" {
+			if hasValue {
+				return idx + 1
+			}
+			return idx + 2
+		}
+		// 跳过需要取值的参数的值，例如 -cp xxx
+		var f = flag.Lookup(name)
+		if f != nil && !hasValue {
+			if boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool }); !ok || !boolFlag.IsBoolFlag() {
+				idx++
+			}
+		}
+	}
+	return -1
 }
 
 func ParseCommand() Command {
@@ -45,8 +99,31 @@ func ParseCommand() Command {
 	flag.BoolVar(&command.Version, "version", false, __VERSION_FLAG_USAGE__)
 	flag.StringVar(&command.ClassPath, "classpath", "", __CLASSPATH_FLAG_USAGE__)
 	flag.StringVar(&command.ClassPath, "cp", "", __CLASSPATH_FLAG_USAGE__)
-	flag.Parse()
-	var args = flag.Args()
+	flag.StringVar(&command.XjreOption, "Xjre", "", __XJRE_FLAG_USAGE__)
+	flag.StringVar(&command.Jar, "jar", "", __JAR_FLAG_USAGE__)
+	flag.StringVar(&command.ModulePath, "module-path", "", __MODULE_PATH_FLAG_USAGE__)
+	flag.StringVar(&command.ModulePath, "p", "", __MODULE_PATH_FLAG_USAGE__)
+	flag.StringVar(&command.Module, "m", "", __MODULE_FLAG_USAGE__)
+	flag.StringVar(&command.Module, "module", "", __MODULE_FLAG_USAGE__)
+	flag.BoolVar(&command.Lenient, "Xlenient", false, __XLENIENT_FLAG_USAGE__)
+	flag.BoolVar(&command.EnablePreview, "enable-preview", false, __ENABLE_PREVIEW_FLAG_USAGE__)
+	var args = os.Args[1:]
+	if len(args) > 0 && args[0] == TOOL_CLASSPATH {
+		// classpath诊断子命令，剩余参数为子命令的参数
+		command.Tool = TOOL_CLASSPATH
+		flag.BoolVar(&command.Duplicates, "duplicates", false, __DUPLICATES_FLAG_USAGE__)
+		flag.CommandLine.Parse(args[1:])
+		command.Args = flag.Args()
+		return command
+	}
+	if start := launchArgsStart(args); start >= 0 && start <= len(args) {
+		// -jar 或 -m 模式，入口类由manifest的 Main-Class 或者模块决定
+		flag.CommandLine.Parse(args[:start])
+		command.Args = args[start:]
+		return command
+	}
+	flag.CommandLine.Parse(args)
+	args = flag.Args()
 	if len(args) > 0 {
 		command.EntryPointClass = args[0]
 		command.Args = args[1:]
@@ -0,0 +1,336 @@
+package jvm
+
+//lint:file-ignore ST1006 MYSTYLE
+// 字段和方法描述符解析，例如 (IJ[Ljava/lang/String;)V。解释器根据参数占用的局部变量槽位传递参数
+// 参考 https://docs.oracle.com/javase/specs/jvms/se21/html/jvms-4.html#jvms-4.3
+
+import (
+	"fmt"
+	"strings"
+)
+
+const __MAX_ARRAY_DIMENSIONS__ = 255 // 数组最多255维
+const __MAX_PARAMETER_SLOTS__ = 255  // 方法参数（包括this）最多占用255个槽位
+
+// 描述符中的类型，Tag为 B C D F I J S Z，引用类型为 L，数组为 [，方法返回值为void时为 V
+type DescriptorType struct {
+	Tag       byte
+	ClassName string          // Tag为L时的全限定名称，例如 java/lang/String
+	Component *DescriptorType // Tag为[时的元素类型
+}
+
+// 获取描述符形式，例如 [Ljava/lang/String;
+func (this *DescriptorType) Descriptor() string {
+	switch this.Tag {
+	case 'L':
+		return "L" + this.ClassName + ";"
+	case '[':
+		return "[" + this.Component.Descriptor()
+	default:
+		return string(this.Tag)
+	}
+}
+
+// 获取Java源码形式，例如 java.lang.String[]
+func (this *DescriptorType) String() string {
+	switch this.Tag {
+	case 'L':
+		return strings.ReplaceAll(this.ClassName, "/", ".")
+	case '[':
+		return this.Component.String() + "[]"
+	default:
+		return (&BaseTypeSignature{Tag: this.Tag}).String()
+	}
+}
+
+// 获取占用的局部变量槽位数，long和double占用两个，void不占用
+func (this *DescriptorType) Slots() uint {
+	switch this.Tag {
+	case 'J', 'D':
+		return 2
+	case 'V':
+		return 0
+	default:
+		return 1
+	}
+}
+
+// 判断是否为引用类型（类或者数组）
+func (this *DescriptorType) IsReference() bool { return this.Tag == 'L' || this.Tag == '[' }
+
+// 方法描述符
+type MethodDescriptor struct {
+	Parameters []*DescriptorType
+	Return     *DescriptorType // void时Tag为V
+}
+
+func (this *MethodDescriptor) Descriptor() string {
+	var builder strings.Builder
+	builder.WriteByte('(')
+	for _, parameter := range this.Parameters {
+		builder.WriteString(parameter.Descriptor())
+	}
+	builder.WriteByte(')')
+	builder.WriteString(this.Return.Descriptor())
+	return builder.String()
+}
+
+// 获取Java源码形式，例如 void (int, long, java.lang.String[])
+func (this *MethodDescriptor) String() string {
+	var parameters = make([]string, len(this.Parameters))
+	for idx, parameter := range this.Parameters {
+		parameters[idx] = parameter.String()
+	}
+	return this.Return.String() + " (" + strings.Join(parameters, ", ") + ")"
+}
+
+// 获取参数占用的局部变量槽位数，不包括this
+func (this *MethodDescriptor) ParameterSlots() uint {
+	var slots uint
+	for _, parameter := range this.Parameters {
+		slots += parameter.Slots()
+	}
+	return slots
+}
+
+// 获取调用时需要传递的槽位数，实例方法在槽位0额外传递this
+func (this *MethodDescriptor) ArgumentSlots(accessFlags MethodAccessFlags) uint {
+	if HasReceiverSlot(accessFlags) {
+		return this.ParameterSlots() + 1
+	}
+	return this.ParameterSlots()
+}
+
+// 判断方法的局部变量槽位0是否为this，即方法没有 ACC_STATIC 标识
+func HasReceiverSlot(accessFlags MethodAccessFlags) bool { return !accessFlags.IsStatic() }
+
+// 描述符格式错误
+type DescriptorFormatError struct {
+	descriptor string
+	offset     int
+	message    string
+}
+
+func (this *DescriptorFormatError) Error() string {
+	return fmt.Sprintf("invalid descriptor %q: %s at offset %d", this.descriptor, this.message, this.offset)
+}
+
+func (this *DescriptorFormatError) Descriptor() string { return this.descriptor }
+
+func (this *DescriptorFormatError) Offset() int { return this.offset }
+
+//+--------------------------------- text reader -----------------------------+
+
+// 描述符和签名共用的读取器，格式错误时panic，由run恢复为newError构造的错误
+type textReader struct {
+	text     string
+	pos      int
+	newError func(offset int, message string) error
+}
+
+// 读取器内部的panic值，用于区分格式错误和其他panic
+type textFormatFailure struct {
+	err error
+}
+
+func (this *textReader) fail(format string, args ...interface{}) {
+	panic(&textFormatFailure{err: this.newError(this.pos, fmt.Sprintf(format, args...))})
+}
+
+// 查看下一个字符，已经结束时返回0
+func (this *textReader) peek() byte {
+	if this.pos >= len(this.text) {
+		return 0
+	}
+	return this.text[this.pos]
+}
+
+// 下一个字符为c时读取并返回true
+func (this *textReader) accept(c byte) bool {
+	if this.peek() != c {
+		return false
+	}
+	this.pos++
+	return true
+}
+
+func (this *textReader) expect(c byte) {
+	if !this.accept(c) {
+		this.fail("expect '%c'", c)
+	}
+}
+
+// 读取非空标识符，标识符不能包含stops中的字符
+func (this *textReader) identifier(stops string) string {
+	var start = this.pos
+	for this.pos < len(this.text) && !strings.ContainsRune(stops, rune(this.text[this.pos])) {
+		this.pos++
+	}
+	if this.pos == start {
+		this.fail("expect identifier")
+	}
+	return this.text[start:this.pos]
+}
+
+// 读取基本类型 B C D F I J S Z，下一个字符不是基本类型时返回0
+func (this *textReader) baseType() byte {
+	switch c := this.peek(); c {
+	case 'B', 'C', 'D', 'F', 'I', 'J', 'S', 'Z':
+		this.pos++
+		return c
+	default:
+		return 0
+	}
+}
+
+// 读取数组类型开头的 [ 并返回维数，最多255维
+func (this *textReader) arrayDimensions() int {
+	var start = this.pos
+	for this.peek() == '[' {
+		this.pos++
+	}
+	if this.pos-start > __MAX_ARRAY_DIMENSIONS__ {
+		this.pos = start
+		this.fail("array type has more than %d dimensions", __MAX_ARRAY_DIMENSIONS__)
+	}
+	return this.pos - start
+}
+
+// 执行parse，parse返回后必须恰好读完
+func (this *textReader) run(parse func()) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			var failure, ok = recovered.(*textFormatFailure)
+			if !ok {
+				panic(recovered)
+			}
+			err = failure.err
+		}
+	}()
+	parse()
+	if this.pos != len(this.text) {
+		this.fail("unexpected trailing characters")
+	}
+	return nil
+}
+
+//+--------------------------------- descriptor parser -----------------------------+
+
+type descriptorParser struct {
+	textReader
+}
+
+// 类名由 / 分隔的非空标识符组成，标识符不能包含 . ; [ /
+func (this *descriptorParser) className() string {
+	var start = this.pos
+	this.identifier(".;[/")
+	for this.accept('/') {
+		this.identifier(".;[/")
+	}
+	this.expect(';')
+	return this.text[start : this.pos-1]
+}
+
+func (this *descriptorParser) fieldType() *DescriptorType {
+	if tag := this.baseType(); tag != 0 {
+		return &DescriptorType{Tag: tag}
+	}
+	switch c := this.peek(); c {
+	case 'L':
+		this.pos++
+		return &DescriptorType{Tag: c, ClassName: this.className()}
+	case '[':
+		var dimensions = this.arrayDimensions()
+		var result = this.fieldType()
+		for ; dimensions > 0; dimensions-- {
+			result = &DescriptorType{Tag: '[', Component: result}
+		}
+		return result
+	case 0:
+		this.fail("unexpected end")
+	default:
+		this.fail("unexpected '%c'", c)
+	}
+	return nil
+}
+
+// 解析整个描述符，parse返回后必须恰好读完
+func parseDescriptor(descriptor string, parse func(parser *descriptorParser)) error {
+	var parser = &descriptorParser{textReader{text: descriptor, newError: func(offset int, message string) error {
+		return &DescriptorFormatError{descriptor: descriptor, offset: offset, message: message}
+	}}}
+	return parser.run(func() { parse(parser) })
+}
+
+// 解析字段描述符，例如 [Ljava/lang/String;
+func ParseFieldDescriptor(descriptor string) (*DescriptorType, error) {
+	var result *DescriptorType
+	var err = parseDescriptor(descriptor, func(parser *descriptorParser) {
+		result = parser.fieldType()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// 解析方法描述符，例如 (IJ[Ljava/lang/String;)V
+func ParseMethodDescriptor(descriptor string) (*MethodDescriptor, error) {
+	var result = &MethodDescriptor{Parameters: make([]*DescriptorType, 0)}
+	var err = parseDescriptor(descriptor, func(parser *descriptorParser) {
+		parser.expect('(')
+		for parser.peek() != ')' {
+			result.Parameters = append(result.Parameters, parser.fieldType())
+		}
+		if result.ParameterSlots() > __MAX_PARAMETER_SLOTS__ {
+			parser.fail("parameters take more than %d slots", __MAX_PARAMETER_SLOTS__)
+		}
+		parser.pos++
+		if parser.accept('V') {
+			result.Return = &DescriptorType{Tag: 'V'}
+		} else {
+			result.Return = parser.fieldType()
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+//+--------------------------------- MemberInformation accessors -----------------------------+
+
+// 解析方法描述符，参数和this占用的槽位超过255时返回错误
+func (this *MemberInformation) MethodDescriptor() (*MethodDescriptor, error) {
+	var descriptor, err = ParseMethodDescriptor(this.Descriptor())
+	if err != nil {
+		return nil, err
+	}
+	if descriptor.ArgumentSlots(this.MethodAccessFlags()) > __MAX_PARAMETER_SLOTS__ {
+		return nil, &DescriptorFormatError{descriptor: this.Descriptor(),
+			message: fmt.Sprintf("parameters and receiver take more than %d slots", __MAX_PARAMETER_SLOTS__)}
+	}
+	return descriptor, nil
+}
+
+// 解析字段描述符
+func (this *MemberInformation) FieldDescriptor() (*DescriptorType, error) {
+	return ParseFieldDescriptor(this.Descriptor())
+}
+
+// 获取调用方法时局部变量表的大小。有Code属性时为max_locals，
+// native和abstract方法没有Code属性，此时为参数和this占用的槽位数
+func (this *MemberInformation) LocalSlots() (uint, error) {
+	var descriptor, err = this.MethodDescriptor()
+	if err != nil {
+		return 0, err
+	}
+	var slots = descriptor.ArgumentSlots(this.MethodAccessFlags())
+	if code := this.CodeAttribute(); code != nil {
+		if uint(code.MaxLocals()) < slots {
+			return 0, fmt.Errorf("max_locals %d of %s%s is less than %d argument slots", code.MaxLocals(), this.Name(), this.Descriptor(), slots)
+		}
+		return uint(code.MaxLocals()), nil
+	}
+	return slots, nil
+}
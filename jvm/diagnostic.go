@@ -0,0 +1,96 @@
+package jvm
+
+//lint:file-ignore ST1006 MYSTYLE
+// classpath 诊断模块，用于排查class由哪个ClassEntry提供，以及同一个class出现在多个ClassEntry中的问题
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// classpath的三个层级名称
+const (
+	TIER_BOOTSTRAP = "bootstrap"
+	TIER_EXTENSION = "extension"
+	TIER_USER      = "user"
+)
+
+// 同一个class出现在多个ClassEntry中，只有查找顺序中的第一个生效，其余的被遮蔽
+type DuplicateClass struct {
+	className string
+	entrys    []ClassEntry // 按照查找顺序排列
+}
+
+func (this *DuplicateClass) ClassName() string { return this.className }
+
+// 获取包含该class的所有ClassEntry，第一个为实际生效的
+func (this *DuplicateClass) Entrys() []ClassEntry { return this.entrys }
+
+// 扫描ClassEntry树中的所有ClassEntry，找出被遮蔽的class，结果按类名排序。
+// 无法列出class的ClassEntry会被跳过
+func FindDuplicateClasses(root ClassEntry) ([]*DuplicateClass, error) {
+	var owners = make(map[string][]ClassEntry)
+	for _, leaf := range flattenClassEntry(root) {
+		var lister, ok = leaf.(ClassLister)
+		if !ok {
+			debug("skip unlistable class entry => ", leaf)
+			continue
+		}
+		var classes, err = lister.ListClasses()
+		if err != nil {
+			return nil, err
+		}
+		for _, class := range classes {
+			owners[class] = append(owners[class], leaf)
+		}
+	}
+	var duplicates = make([]*DuplicateClass, 0)
+	for class, entrys := range owners {
+		if len(entrys) > 1 {
+			duplicates = append(duplicates, &DuplicateClass{className: class, entrys: entrys})
+		}
+	}
+	sort.Slice(duplicates, func(i, j int) bool { return duplicates[i].className < duplicates[j].className })
+	return duplicates, nil
+}
+
+// classpath的一个层级
+type classpathTier struct {
+	name  string
+	entry ClassEntry
+}
+
+// 按照查找顺序获取classpath的三个层级
+func (this *Classpath) tiers() []classpathTier {
+	return []classpathTier{
+		{TIER_BOOTSTRAP, this.bootClasspath},
+		{TIER_EXTENSION, this.extClasspath},
+		{TIER_USER, this.userClasspath},
+	}
+}
+
+// 查找提供该class的ClassEntry，同时返回其所在的层级
+func (this *Classpath) Which(classQulifierName string) (string, ClassEntry, error) {
+	for _, tier := range this.tiers() {
+		var _, from, err = tier.entry.ReadClass(classQulifierName)
+		if err == nil {
+			return tier.name, from, nil
+		}
+		if !IsClassNotFound(err) {
+			return "", nil, err
+		}
+	}
+	return "", nil, &ClassNotFoundError{className: classQulifierName}
+}
+
+// 按照查找顺序输出classpath的各个层级及其包含的ClassEntry
+func (this *Classpath) Describe(w io.Writer) {
+	for _, tier := range this.tiers() {
+		var leaves = flattenClassEntry(tier.entry)
+		fmt.Fprintf(w, "%s classpath (%d entries):\n", tier.name, len(leaves))
+		for _, leaf := range leaves {
+			fmt.Fprintf(w, "    %s\n", leaf)
+		}
+	}
+}
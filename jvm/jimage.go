@@ -0,0 +1,499 @@
+package jvm
+
+//lint:file-ignore ST1006 MYSTYLE
+// JDK 9+ 运行时镜像（lib/modules，jimage格式）读取模块。
+// 文件结构：header -> redirect表 -> offsets表 -> locations -> strings -> 资源内容
+// 参考 jdk.internal.jimage.BasicImageReader
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const __JIMAGE_MAGIC__ = 0xCAFEDADA           // jimage魔数
+const __JIMAGE_MAJOR_VERSION__ = 1            // 支持的jimage主版本号
+const __JIMAGE_HEADER_SIZE__ = 7 * 4          // header由7个u4组成
+const __JIMAGE_HASH_MULTIPLIER__ = 0x01000193 // 字符串哈希乘数
+const __JIMAGE_MODULES__ = "modules"          // lib/modules
+
+const __JIMAGE_COMPRESSED_MAGIC__ = 0xCAFEFAFA // 压缩资源头魔数
+const __JIMAGE_COMPRESSED_HEADER_SIZE__ = 29   // magic u4, 压缩前后大小 u8 u8, 解压器名称 u4, 配置 u4, isTerminal u1
+
+// location属性类型
+const (
+	jimageAttributeEnd = iota
+	jimageAttributeModule
+	jimageAttributeParent
+	jimageAttributeBase
+	jimageAttributeExtension
+	jimageAttributeOffset
+	jimageAttributeCompressed
+	jimageAttributeUncompressed
+	jimageAttributeCount
+)
+
+// jimage文件头
+type JImageHeader struct {
+	magic         uint32
+	majorVersion  uint16
+	minorVersion  uint16
+	flags         uint32
+	resourceCount uint32
+	tableLength   uint32 // redirect表和offsets表的长度
+	locationsSize uint32 // locations的字节数
+	stringsSize   uint32 // strings的字节数
+}
+
+// 索引部分（资源内容之前）的总大小
+func (this *JImageHeader) indexSize() int64 {
+	return __JIMAGE_HEADER_SIZE__ + int64(this.tableLength)*4*2 + int64(this.locationsSize) + int64(this.stringsSize)
+}
+
+func (this *JImageHeader) ResourceCount() uint32 { return this.resourceCount }
+
+// 与 ImageStringsReader.unmaskedHashCode 一致，按照MUTF8编码后的字节计算哈希
+func jimageHash(name string, seed int32) int32 {
+	for _, b := range []byte(name) {
+		seed = seed*__JIMAGE_HASH_MULTIPLIER__ ^ int32(b)
+	}
+	return seed & 0x7FFFFFFF
+}
+
+//+--------------------------------- JImageClassEntry definition -----------------------------+
+
+type JImageClassEntry struct {
+	entrysAbsolutePath string
+	classpath          string
+	once               sync.Once
+	file               *os.File
+	order              binary.ByteOrder // jimage使用生成时平台的字节序
+	header             JImageHeader
+	redirect           []int32
+	offsets            []uint32
+	locations          []byte
+	strings            []byte
+	packages           map[string]string // 包路径（例如 java/lang） => 模块名称
+	openErr            error
+}
+
+// 打开jimage并读取索引部分
+func (this *JImageClassEntry) open() error {
+	this.once.Do(func() {
+		var file, err = os.Open(this.entrysAbsolutePath)
+		if err != nil {
+			this.openErr = &ClasspathError{segment: this.entrysAbsolutePath, kind: CLASSPATH_UNREADABLE_ARCHIVE, err: err}
+			return
+		}
+		this.file = file
+		if err = this.readIndex(); err != nil {
+			file.Close()
+			this.openErr = &ClasspathError{segment: this.entrysAbsolutePath, kind: CLASSPATH_UNREADABLE_ARCHIVE, err: err}
+			return
+		}
+		this.indexPackages()
+		debug("open jimage => ", this.entrysAbsolutePath, " resources => ", this.header.resourceCount, " packages => ", len(this.packages))
+	})
+	return this.openErr
+}
+
+func (this *JImageClassEntry) readIndex() error {
+	var raw = make([]byte, __JIMAGE_HEADER_SIZE__)
+	if _, err := this.file.ReadAt(raw, 0); err != nil {
+		return err
+	}
+	// 根据魔数确定字节序
+	switch uint32(__JIMAGE_MAGIC__) {
+	case binary.LittleEndian.Uint32(raw):
+		this.order = binary.LittleEndian
+	case binary.BigEndian.Uint32(raw):
+		this.order = binary.BigEndian
+	default:
+		return fmt.Errorf("bad magic 0x%x", binary.LittleEndian.Uint32(raw))
+	}
+	var slots [7]uint32
+	for idx := range slots {
+		slots[idx] = this.order.Uint32(raw[idx*4:])
+	}
+	this.header = JImageHeader{
+		magic:         slots[0],
+		majorVersion:  uint16(slots[1] >> 16),
+		minorVersion:  uint16(slots[1]),
+		flags:         slots[2],
+		resourceCount: slots[3],
+		tableLength:   slots[4],
+		locationsSize: slots[5],
+		stringsSize:   slots[6],
+	}
+	if this.header.majorVersion != __JIMAGE_MAJOR_VERSION__ {
+		return fmt.Errorf("unsupported version %d.%d", this.header.majorVersion, this.header.minorVersion)
+	}
+	var index = make([]byte, this.header.indexSize()-__JIMAGE_HEADER_SIZE__)
+	if _, err := this.file.ReadAt(index, __JIMAGE_HEADER_SIZE__); err != nil {
+		return err
+	}
+	var length = int(this.header.tableLength)
+	this.redirect = make([]int32, length)
+	this.offsets = make([]uint32, length)
+	for idx := 0; idx < length; idx++ {
+		this.redirect[idx] = int32(this.order.Uint32(index[idx*4:]))
+		this.offsets[idx] = this.order.Uint32(index[(length+idx)*4:])
+	}
+	index = index[length*4*2:]
+	this.locations = index[:this.header.locationsSize]
+	this.strings = index[this.header.locationsSize:]
+	return nil
+}
+
+// 读取strings表中以0结尾的字符串的MUTF8编码
+func (this *JImageClassEntry) getBytes(offset uint64) []byte {
+	if offset >= uint64(len(this.strings)) {
+		return nil
+	}
+	var data = this.strings[offset:]
+	if end := bytes.IndexByte(data, 0); end >= 0 {
+		data = data[:end]
+	}
+	return data
+}
+
+// 读取strings表中以0结尾的字符串
+func (this *JImageClassEntry) getString(offset uint64) string {
+	return __decodeMUtf8(this.getBytes(offset))
+}
+
+// 解析location的属性，每个属性由1字节头（高5位为类型，低3位为长度-1）和大端存储的值组成
+func (this *JImageClassEntry) getAttributes(offset uint32) [jimageAttributeCount]uint64 {
+	var attributes [jimageAttributeCount]uint64
+	for idx := int(offset); idx < len(this.locations); {
+		var data = this.locations[idx]
+		idx++
+		var kind = data >> 3
+		if kind == jimageAttributeEnd || kind >= jimageAttributeCount {
+			break
+		}
+		var length = int(data&0x7) + 1
+		var value uint64
+		for j := 0; j < length && idx < len(this.locations); j++ {
+			value = value<<8 | uint64(this.locations[idx])
+			idx++
+		}
+		attributes[kind] = value
+	}
+	return attributes
+}
+
+// 由location属性拼出资源全名，例如 /java.base/java/lang/Object.class
+func (this *JImageClassEntry) fullName(attributes [jimageAttributeCount]uint64) string {
+	var builder strings.Builder
+	if module := this.getString(attributes[jimageAttributeModule]); module != "" {
+		builder.WriteString("/" + module + "/")
+	}
+	if parent := this.getString(attributes[jimageAttributeParent]); parent != "" {
+		builder.WriteString(parent + "/")
+	}
+	builder.WriteString(this.getString(attributes[jimageAttributeBase]))
+	if extension := this.getString(attributes[jimageAttributeExtension]); extension != "" {
+		builder.WriteString("." + extension)
+	}
+	return builder.String()
+}
+
+// 通过redirect表和offsets表查找资源，与 BasicImageReader.findLocation 一致
+func (this *JImageClassEntry) findLocation(name string) ([jimageAttributeCount]uint64, bool) {
+	var length = int32(this.header.tableLength)
+	if length == 0 {
+		return [jimageAttributeCount]uint64{}, false
+	}
+	var index = this.redirect[jimageHash(name, __JIMAGE_HASH_MULTIPLIER__)%length]
+	if index < 0 {
+		index = -index - 1
+	} else if index > 0 {
+		index = jimageHash(name, index) % length
+	} else {
+		return [jimageAttributeCount]uint64{}, false
+	}
+	var attributes = this.getAttributes(this.offsets[index])
+	if this.fullName(attributes) != name {
+		return attributes, false
+	}
+	return attributes, true
+}
+
+// 遍历所有location，建立包与模块的对应关系
+func (this *JImageClassEntry) indexPackages() {
+	this.packages = make(map[string]string)
+	for _, offset := range this.offsets {
+		var attributes = this.getAttributes(offset)
+		if this.getString(attributes[jimageAttributeExtension]) != "class" {
+			continue
+		}
+		var module = this.getString(attributes[jimageAttributeModule])
+		var parent = this.getString(attributes[jimageAttributeParent])
+		if _, ok := this.packages[parent]; !ok && module != "" {
+			this.packages[parent] = module
+		}
+	}
+}
+
+// 获取jimage文件头
+func (this *JImageClassEntry) Header() (*JImageHeader, error) {
+	if err := this.open(); err != nil {
+		return nil, err
+	}
+	return &this.header, nil
+}
+
+// 读取资源内容，name为资源全名，例如 /java.base/java/lang/Object.class
+func (this *JImageClassEntry) ReadResource(name string) ([]byte, error) {
+	if err := this.open(); err != nil {
+		return nil, err
+	}
+	var attributes, ok = this.findLocation(name)
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	var size = attributes[jimageAttributeUncompressed]
+	if attributes[jimageAttributeCompressed] != 0 {
+		size = attributes[jimageAttributeCompressed]
+	}
+	var data = make([]byte, size)
+	var _, err = this.file.ReadAt(data, this.header.indexSize()+int64(attributes[jimageAttributeOffset]))
+	if err != nil {
+		return nil, err
+	}
+	if attributes[jimageAttributeCompressed] != 0 {
+		return this.decompress(name, data)
+	}
+	return data, nil
+}
+
+//+--------------------------------- compressed resources -----------------------------+
+
+// jlink --compress 生成的资源无法解压，例如使用了不支持的解压器
+type JImageDecompressError struct {
+	resource     string
+	decompressor string
+	err          error
+}
+
+func (this *JImageDecompressError) Error() string {
+	return fmt.Sprintf("cannot decompress jimage resource %s with %q: %v", this.resource, this.decompressor, this.err)
+}
+
+func (this *JImageDecompressError) Unwrap() error { return this.err }
+
+func (this *JImageDecompressError) Resource() string { return this.resource }
+
+func (this *JImageDecompressError) Decompressor() string { return this.decompressor }
+
+// 解压资源，与 jdk.internal.jimage.decompressor.Decompressor 一致：
+// 资源可以被多个插件依次压缩，每一层都以压缩资源头开始，逐层解压直到没有压缩资源头
+func (this *JImageClassEntry) decompress(name string, data []byte) ([]byte, error) {
+	for len(data) >= __JIMAGE_COMPRESSED_HEADER_SIZE__ && this.order.Uint32(data) == __JIMAGE_COMPRESSED_MAGIC__ {
+		var uncompressedSize = this.order.Uint64(data[12:])
+		var decompressor = this.getString(uint64(this.order.Uint32(data[20:])))
+		var content = data[__JIMAGE_COMPRESSED_HEADER_SIZE__:]
+		var err error
+		switch decompressor {
+		case "zip":
+			data, err = inflateJImageResource(content)
+		case "compact-cp":
+			data, err = this.expandSharedStrings(content)
+		default:
+			err = fmt.Errorf("unsupported decompressor")
+		}
+		if err == nil && uint64(len(data)) != uncompressedSize {
+			err = fmt.Errorf("expect %d bytes, got %d", uncompressedSize, len(data))
+		}
+		if err != nil {
+			return nil, &JImageDecompressError{resource: name, decompressor: decompressor, err: err}
+		}
+	}
+	return data, nil
+}
+
+// zip插件使用 java.util.zip.Deflater 压缩，数据为zlib格式
+func inflateJImageResource(content []byte) ([]byte, error) {
+	var reader, err = zlib.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return ioutil.ReadAll(reader)
+}
+
+// compact-cp插件替换的常量池项
+const (
+	jimageExternalizedString           = 23 // Utf8字符串保存在strings表中
+	jimageExternalizedStringDescriptor = 25 // 描述符的类名和包名分别保存在strings表中
+)
+
+// 常量池项除tag以外的字节数
+var jimageConstantSizes = map[uint8]uint32{
+	CONSTANT_Class: 2, CONSTANT_Fieldref: 4, CONSTANT_Methodref: 4, CONSTANT_InterfaceMethodref: 4,
+	CONSTANT_String: 2, CONSTANT_Integer: 4, CONSTANT_Float: 4, CONSTANT_Long: 8, CONSTANT_Double: 8,
+	CONSTANT_NameAndType: 4, CONSTANT_MethodHandle: 3, CONSTANT_MethodType: 2, CONSTANT_Dynamic: 4,
+	CONSTANT_InvokeDynamic: 4, CONSTANT_Module: 2, CONSTANT_Package: 2,
+}
+
+// 读取 CompressIndexes 编码的整数：最高位为1时，接下来两位是总字节数，低5位是最高位的值；否则为4字节大端整数
+func readJImageCompressedInt(reader *JavaByteCodeReader) uint64 {
+	var header = reader.ReadUint8()
+	if header&0x80 == 0 {
+		return uint64(header)<<24 | uint64(reader.ReadUint8())<<16 | uint64(reader.ReadUint16())
+	}
+	var value = uint64(header & 0x1F)
+	for size := header >> 5 & 0x3; size > 1; size-- {
+		value = value<<8 | uint64(reader.ReadUint8())
+	}
+	return value
+}
+
+// 还原compact-cp插件处理过的class，与 StringSharingDecompressor.normalize 一致
+func (this *JImageClassEntry) expandSharedStrings(content []byte) (data []byte, err error) {
+	var reader = &JavaByteCodeReader{bytecode: content}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			data, err = nil, reader.recoverError(recovered)
+		}
+	}()
+	var writer bytes.Buffer
+	var writeUtf8 = func(value []byte) {
+		if len(value) > 0xFFFF {
+			reader.formatError(reader.offset, "shared string is too long: %d bytes", len(value))
+		}
+		writer.WriteByte(CONSTANT_Utf8)
+		binary.Write(&writer, binary.BigEndian, uint16(len(value)))
+		writer.Write(value)
+	}
+	writer.Write(reader.ReadBytes(8)) // magic, minor_version, major_version
+	var count = reader.ReadUint16()
+	binary.Write(&writer, binary.BigEndian, count)
+	for idx := 1; idx < int(count); idx++ {
+		reader.enter("constant pool entry #%d", idx)
+		switch tag := reader.ReadUint8(); tag {
+		case CONSTANT_Utf8:
+			writeUtf8(reader.ReadBytes(uint32(reader.ReadUint16())))
+		case jimageExternalizedString:
+			writeUtf8(this.getBytes(readJImageCompressedInt(reader)))
+		case jimageExternalizedStringDescriptor:
+			writeUtf8(this.expandDescriptor(reader))
+		default:
+			var size, ok = jimageConstantSizes[tag]
+			if !ok {
+				reader.formatError(reader.offset-1, "unknown tag %d", tag)
+			}
+			if tag == CONSTANT_Long || tag == CONSTANT_Double {
+				idx++
+			}
+			writer.WriteByte(tag)
+			writer.Write(reader.ReadBytes(size))
+		}
+		reader.leave()
+	}
+	writer.Write(reader.bytecode)
+	return writer.Bytes(), nil
+}
+
+// 还原描述符：每个 L 之后依次插入包名（非空时加 /）和类名，例如 (L;)V => (Ljava/lang/String;)V
+func (this *JImageClassEntry) expandDescriptor(reader *JavaByteCodeReader) []byte {
+	var descriptor = this.getBytes(readJImageCompressedInt(reader))
+	var indexes = &JavaByteCodeReader{bytecode: reader.ReadBytes(uint32(readJImageCompressedInt(reader)))}
+	var result = make([]byte, 0, len(descriptor)*2)
+	for _, c := range descriptor {
+		result = append(result, c)
+		if c != 'L' {
+			continue
+		}
+		if pkg := this.getBytes(readJImageCompressedInt(indexes)); len(pkg) > 0 {
+			result = append(append(result, pkg...), '/')
+		}
+		result = append(result, this.getBytes(readJImageCompressedInt(indexes))...)
+	}
+	return result
+}
+
+func (this *JImageClassEntry) ReadClass(classQulifierName string) ([]byte, ClassEntry, error) {
+	if err := this.open(); err != nil {
+		return nil, nil, err
+	}
+	var name = classFileName(classQulifierName)
+	var parent = ""
+	if idx := strings.LastIndex(name, "/"); idx >= 0 {
+		parent = name[:idx]
+	}
+	var module, ok = this.packages[parent]
+	if !ok {
+		return nil, nil, &ClassNotFoundError{className: classQulifierName}
+	}
+	var bytecode, err = this.ReadResource("/" + module + "/" + name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, &ClassNotFoundError{className: classQulifierName}
+		}
+		return nil, nil, err
+	}
+	return bytecode, this, nil
+}
+
+// 列出jimage中所有模块的class
+func (this *JImageClassEntry) ListClasses() ([]string, error) {
+	if err := this.open(); err != nil {
+		return nil, err
+	}
+	var classes = make([]string, 0, len(this.offsets))
+	for _, offset := range this.offsets {
+		var attributes = this.getAttributes(offset)
+		if this.getString(attributes[jimageAttributeExtension]) != "class" {
+			continue
+		}
+		var name = this.getString(attributes[jimageAttributeBase])
+		if parent := this.getString(attributes[jimageAttributeParent]); parent != "" {
+			name = parent + "/" + name
+		}
+		classes = append(classes, name)
+	}
+	return classes, nil
+}
+
+// 关闭打开的jimage，之后的读取都会返回 CLASSPATH_CLOSED 错误。Close不能与其他方法并发调用
+func (this *JImageClassEntry) Close() error {
+	this.once.Do(func() {}) // 没有打开过时，之后也不会再打开
+	var file = this.file
+	this.file, this.redirect, this.offsets, this.locations, this.strings, this.packages = nil, nil, nil, nil, nil, nil
+	this.openErr = &ClasspathError{segment: this.entrysAbsolutePath, kind: CLASSPATH_CLOSED}
+	if file == nil {
+		return nil
+	}
+	return file.Close()
+}
+
+func (this *JImageClassEntry) String() string {
+	return this.entrysAbsolutePath
+}
+
+// 构造时即读取jimage的索引部分，以便尽早发现无法读取的镜像文件
+func newJImageClassEntry(classpath string) (*JImageClassEntry, error) {
+	var absPath, _, err = statClasspath(classpath)
+	if err != nil {
+		return nil, err
+	}
+	var entry = &JImageClassEntry{entrysAbsolutePath: absPath, classpath: classpath}
+	if err = entry.open(); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// 判断jre目录是否为JDK 9+ 的布局（存在 lib/modules）
+func isJImageLayout(jreDir string) bool {
+	var stat, err = os.Stat(filepath.Join(jreDir, "lib", __JIMAGE_MODULES__))
+	return err == nil && !stat.IsDir()
+}
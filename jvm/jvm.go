@@ -13,12 +13,13 @@ const __DEBUG_ENABLE__ = true
 var debug func(v ...interface{}) = func(v ...interface{}) {}
 
 var info = log.New(os.Stdout, "[INFO] ", log.LstdFlags).Println
+var warn = log.New(os.Stderr, "[WARN] ", log.LstdFlags).Println
 var fatal = log.New(os.Stderr, "[ERROR] ", log.LstdFlags).Fatal // 会终止程序运行
 
 func init() {
 	if __DEBUG_ENABLE__ {
-		// 开启debug
-		debug = log.New(os.Stdout, "[DEBUG] ", log.LstdFlags).Println
+		// 开启debug，输出到标准错误，标准输出只保留程序自身的输出
+		debug = log.New(os.Stderr, "[DEBUG] ", log.LstdFlags).Println
 		debug("DEBUG MODE ENABLED")
 	}
 }
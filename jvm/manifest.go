@@ -0,0 +1,64 @@
+package jvm
+
+//lint:file-ignore ST1006 MYSTYLE
+// JAR manifest 解析模块，解析 META-INF/MANIFEST.MF 中的主属性
+
+import (
+	"fmt"
+	"strings"
+)
+
+const __MANIFEST_NAME__ = "META-INF/MANIFEST.MF" // manifest在压缩包中的路径
+
+// manifest中gava关心的主属性名称
+const (
+	MANIFEST_MAIN_CLASS = "Main-Class"
+	MANIFEST_CLASS_PATH = "Class-Path"
+)
+
+type Manifest struct {
+	mainAttributes map[string]string // 属性名称（小写） => 属性值
+}
+
+// 获取主属性，属性名称不区分大小写，不存在时返回空串
+func (this *Manifest) Get(name string) string {
+	return this.mainAttributes[strings.ToLower(name)]
+}
+
+// 获取入口类，例如 com.acme.Main
+func (this *Manifest) MainClass() string { return this.Get(MANIFEST_MAIN_CLASS) }
+
+// 获取 Class-Path 中以空格分隔的相对路径
+func (this *Manifest) ClassPath() []string { return strings.Fields(this.Get(MANIFEST_CLASS_PATH)) }
+
+// 解析manifest，支持 \r\n 换行和以单个空格开头的续行。
+// 第一个空行之后是各个entry的属性，gava目前只需要主属性
+func ParseManifest(data []byte) (*Manifest, error) {
+	var manifest = &Manifest{mainAttributes: make(map[string]string)}
+	var text = strings.ReplaceAll(string(data), "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	var lastName = ""
+	for lineNumber, line := range strings.Split(text, "\n") {
+		if line == "" {
+			if len(manifest.mainAttributes) > 0 {
+				break // 主属性结束
+			}
+			continue
+		}
+		if line[0] == ' ' {
+			// 续行，拼接到上一个属性值之后
+			if lastName == "" {
+				return nil, fmt.Errorf("invalid manifest continuation line %d", lineNumber+1)
+			}
+			manifest.mainAttributes[lastName] += line[1:]
+			continue
+		}
+		var idx = strings.Index(line, ": ")
+		if idx <= 0 {
+			return nil, fmt.Errorf("invalid manifest header at line %d => %s", lineNumber+1, line)
+		}
+		lastName = strings.ToLower(line[:idx])
+		manifest.mainAttributes[lastName] = line[idx+2:]
+	}
+	return manifest, nil
+}
@@ -0,0 +1,327 @@
+package jvm
+
+//lint:file-ignore ST1006 MYSTYLE
+// module-info.class 中描述模块的属性：Module、ModulePackages、ModuleMainClass
+
+// 读取指向Module常量的索引，索引不合法时抛出格式错误
+func readModuleIndex(reader *JavaByteCodeReader, cp ConstantPool, what string) uint16 {
+	var offset = reader.offset
+	var index = reader.ReadUint16()
+	if _, ok := cp.Information(index).(*ConstantModuleInfo); !ok {
+		reader.formatError(offset, "invalid %s index %d", what, index)
+	}
+	return index
+}
+
+// 读取指向Package常量的索引，索引不合法时抛出格式错误
+func readPackageIndex(reader *JavaByteCodeReader, cp ConstantPool, what string) uint16 {
+	var offset = reader.offset
+	var index = reader.ReadUint16()
+	if _, ok := cp.Information(index).(*ConstantPackageInfo); !ok {
+		reader.formatError(offset, "invalid %s index %d", what, index)
+	}
+	return index
+}
+
+// 读取可以为0的版本索引，不为0时必须指向Utf8常量
+func readVersionIndex(reader *JavaByteCodeReader, cp ConstantPool) uint16 {
+	var offset = reader.offset
+	var index = reader.ReadUint16()
+	if _, ok := cp.lookupUtf8(index); !ok && index != 0 {
+		reader.formatError(offset, "invalid version index %d", index)
+	}
+	return index
+}
+
+func (this *ConstantPool) getModuleName(moduleIndex uint16) string {
+	return this.informations[moduleIndex].(*ConstantModuleInfo).Name()
+}
+
+func (this *ConstantPool) getPackageName(packageIndex uint16) string {
+	return this.informations[packageIndex].(*ConstantPackageInfo).Name()
+}
+
+// requires表中的一项
+type ModuleRequire struct {
+	cp                   ConstantPool
+	requiresIndex        uint16
+	requiresFlags        uint16
+	requiresVersionIndex uint16 // 编译时没有记录依赖的版本时为0
+}
+
+// 获取依赖的模块名称，例如 java.base
+func (this *ModuleRequire) Name() string { return this.cp.getModuleName(this.requiresIndex) }
+
+func (this *ModuleRequire) AccessFlags() RequiresAccessFlags {
+	return RequiresAccessFlags(this.requiresFlags)
+}
+
+// 获取编译时依赖的模块版本，没有记录时返回空字符串
+func (this *ModuleRequire) Version() string {
+	if this.requiresVersionIndex == 0 {
+		return ""
+	}
+	return this.cp.getUtf8(this.requiresVersionIndex)
+}
+
+// exports或opens表中的一项
+type ModuleExport struct {
+	cp           ConstantPool
+	packageIndex uint16
+	flags        uint16
+	toIndexes    []uint16 // 限定导出的目标模块，为空时对所有模块导出
+}
+
+// 获取包名称（内部形式，例如 com/acme/api）
+func (this *ModuleExport) Package() string { return this.cp.getPackageName(this.packageIndex) }
+
+func (this *ModuleExport) AccessFlags() ExportsAccessFlags { return ExportsAccessFlags(this.flags) }
+
+// 获取限定导出的目标模块名称，对所有模块导出时返回nil
+func (this *ModuleExport) To() []string {
+	if len(this.toIndexes) == 0 {
+		return nil
+	}
+	var names = make([]string, len(this.toIndexes))
+	for idx, toIndex := range this.toIndexes {
+		names[idx] = this.cp.getModuleName(toIndex)
+	}
+	return names
+}
+
+// 判断是否只对部分模块导出
+func (this *ModuleExport) IsQualified() bool { return len(this.toIndexes) > 0 }
+
+// provides表中的一项
+type ModuleProvide struct {
+	cp           ConstantPool
+	serviceIndex uint16
+	withIndexes  []uint16
+}
+
+// 获取服务接口的全限定名称
+func (this *ModuleProvide) Service() string { return this.cp.getClassName(this.serviceIndex) }
+
+// 获取服务实现类的全限定名称
+func (this *ModuleProvide) Implementations() []string {
+	var names = make([]string, len(this.withIndexes))
+	for idx, withIndex := range this.withIndexes {
+		names[idx] = this.cp.getClassName(withIndex)
+	}
+	return names
+}
+
+type ModuleAttribute struct {
+	cp                 ConstantPool
+	name               string
+	length             uint32
+	moduleNameIndex    uint16
+	moduleFlags        uint16
+	moduleVersionIndex uint16 // 没有记录版本时为0
+	requires           []*ModuleRequire
+	exports            []*ModuleExport
+	opens              []*ModuleExport
+	uses               []uint16
+	provides           []*ModuleProvide
+}
+
+// 读取exports或opens表
+func readModuleExports(reader *JavaByteCodeReader, cp ConstantPool, kind string) []*ModuleExport {
+	var exports = make([]*ModuleExport, reader.ReadUint16())
+	for idx := range exports {
+		reader.enter("%s #%d", kind, idx)
+		var export = &ModuleExport{cp: cp}
+		export.packageIndex = readPackageIndex(reader, cp, "package")
+		export.flags = reader.ReadUint16()
+		export.toIndexes = make([]uint16, reader.ReadUint16())
+		for to := range export.toIndexes {
+			export.toIndexes[to] = readModuleIndex(reader, cp, "target module")
+		}
+		exports[idx] = export
+		reader.leave()
+	}
+	return exports
+}
+
+// 写入exports或opens表
+func writeModuleExports(writer *JavaByteCodeWriter, exports []*ModuleExport) {
+	writer.writeCount(len(exports), "exports")
+	for _, export := range exports {
+		writer.WriteUint16(export.packageIndex)
+		writer.WriteUint16(export.flags)
+		writer.WriteUint16s(export.toIndexes)
+	}
+}
+
+func (this *ModuleAttribute) ReadAttribute(reader *JavaByteCodeReader) {
+	this.moduleNameIndex = readModuleIndex(reader, this.cp, "module name")
+	this.moduleFlags = reader.ReadUint16()
+	this.moduleVersionIndex = readVersionIndex(reader, this.cp)
+	this.requires = make([]*ModuleRequire, reader.ReadUint16())
+	for idx := range this.requires {
+		reader.enter("requires #%d", idx)
+		var require = &ModuleRequire{cp: this.cp}
+		require.requiresIndex = readModuleIndex(reader, this.cp, "requires")
+		require.requiresFlags = reader.ReadUint16()
+		require.requiresVersionIndex = readVersionIndex(reader, this.cp)
+		this.requires[idx] = require
+		reader.leave()
+	}
+	this.exports = readModuleExports(reader, this.cp, "exports")
+	this.opens = readModuleExports(reader, this.cp, "opens")
+	this.uses = make([]uint16, reader.ReadUint16())
+	for idx := range this.uses {
+		this.uses[idx] = readClassIndex(reader, this.cp, "uses", false)
+	}
+	this.provides = make([]*ModuleProvide, reader.ReadUint16())
+	for idx := range this.provides {
+		reader.enter("provides #%d", idx)
+		var provide = &ModuleProvide{cp: this.cp}
+		provide.serviceIndex = readClassIndex(reader, this.cp, "service", false)
+		provide.withIndexes = make([]uint16, reader.ReadUint16())
+		for with := range provide.withIndexes {
+			provide.withIndexes[with] = readClassIndex(reader, this.cp, "implementation", false)
+		}
+		this.provides[idx] = provide
+		reader.leave()
+	}
+}
+
+func (this *ModuleAttribute) WriteAttribute(writer *JavaByteCodeWriter) {
+	writer.WriteUint16(this.moduleNameIndex)
+	writer.WriteUint16(this.moduleFlags)
+	writer.WriteUint16(this.moduleVersionIndex)
+	writer.writeCount(len(this.requires), "requires")
+	for _, require := range this.requires {
+		writer.WriteUint16(require.requiresIndex)
+		writer.WriteUint16(require.requiresFlags)
+		writer.WriteUint16(require.requiresVersionIndex)
+	}
+	writeModuleExports(writer, this.exports)
+	writeModuleExports(writer, this.opens)
+	writer.WriteUint16s(this.uses)
+	writer.writeCount(len(this.provides), "provides")
+	for _, provide := range this.provides {
+		writer.WriteUint16(provide.serviceIndex)
+		writer.WriteUint16s(provide.withIndexes)
+	}
+}
+
+func (this *ModuleAttribute) Name() string { return this.name }
+
+// 获取模块名称，例如 com.acme.app
+func (this *ModuleAttribute) ModuleName() string { return this.cp.getModuleName(this.moduleNameIndex) }
+
+func (this *ModuleAttribute) AccessFlags() ModuleAccessFlags {
+	return ModuleAccessFlags(this.moduleFlags)
+}
+
+// 获取模块版本，没有记录时返回空字符串
+func (this *ModuleAttribute) Version() string {
+	if this.moduleVersionIndex == 0 {
+		return ""
+	}
+	return this.cp.getUtf8(this.moduleVersionIndex)
+}
+
+func (this *ModuleAttribute) Requires() []*ModuleRequire { return this.requires }
+
+func (this *ModuleAttribute) Exports() []*ModuleExport { return this.exports }
+
+func (this *ModuleAttribute) Opens() []*ModuleExport { return this.opens }
+
+// 获取模块使用的服务接口的全限定名称
+func (this *ModuleAttribute) Uses() []string {
+	var names = make([]string, len(this.uses))
+	for idx, classIndex := range this.uses {
+		names[idx] = this.cp.getClassName(classIndex)
+	}
+	return names
+}
+
+func (this *ModuleAttribute) Provides() []*ModuleProvide { return this.provides }
+
+// 模块包含的所有包，包括没有导出的包
+type ModulePackagesAttribute struct {
+	cp       ConstantPool
+	name     string
+	length   uint32
+	packages []uint16
+}
+
+func (this *ModulePackagesAttribute) ReadAttribute(reader *JavaByteCodeReader) {
+	this.packages = make([]uint16, reader.ReadUint16())
+	for idx := range this.packages {
+		this.packages[idx] = readPackageIndex(reader, this.cp, "package")
+	}
+}
+
+func (this *ModulePackagesAttribute) WriteAttribute(writer *JavaByteCodeWriter) {
+	writer.WriteUint16s(this.packages)
+}
+
+func (this *ModulePackagesAttribute) Name() string { return this.name }
+
+// 获取包名称（内部形式，例如 com/acme/internal）
+func (this *ModulePackagesAttribute) Packages() []string {
+	var names = make([]string, len(this.packages))
+	for idx, packageIndex := range this.packages {
+		names[idx] = this.cp.getPackageName(packageIndex)
+	}
+	return names
+}
+
+// 模块的入口类
+type ModuleMainClassAttribute struct {
+	cp             ConstantPool
+	name           string
+	length         uint32
+	mainClassIndex uint16
+}
+
+func (this *ModuleMainClassAttribute) ReadAttribute(reader *JavaByteCodeReader) {
+	this.mainClassIndex = readClassIndex(reader, this.cp, "main class", false)
+}
+
+func (this *ModuleMainClassAttribute) WriteAttribute(writer *JavaByteCodeWriter) {
+	writer.WriteUint16(this.mainClassIndex)
+}
+
+func (this *ModuleMainClassAttribute) Name() string { return this.name }
+
+// 获取入口类的全限定名称，例如 com/acme/Main
+func (this *ModuleMainClassAttribute) MainClassName() string {
+	return this.cp.getClassName(this.mainClassIndex)
+}
+
+//+--------------------------------- JavaClass accessors -----------------------------+
+
+// 获取模块声明，不是 module-info.class 时返回nil
+func (this *JavaClass) Module() *ModuleAttribute {
+	for _, attribute := range this.attributes {
+		if module, ok := (*attribute).(*ModuleAttribute); ok {
+			return module
+		}
+	}
+	return nil
+}
+
+// 获取ModulePackages属性中记录的包，没有该属性时返回nil
+func (this *JavaClass) ModulePackages() []string {
+	for _, attribute := range this.attributes {
+		if packages, ok := (*attribute).(*ModulePackagesAttribute); ok {
+			return packages.Packages()
+		}
+	}
+	return nil
+}
+
+// 获取模块入口类的全限定名称，没有ModuleMainClass属性时返回空字符串
+func (this *JavaClass) ModuleMainClass() string {
+	for _, attribute := range this.attributes {
+		if mainClass, ok := (*attribute).(*ModuleMainClassAttribute); ok {
+			return mainClass.MainClassName()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,543 @@
+package jvm
+
+//lint:file-ignore ST1006 MYSTYLE
+// JPMS 模块图解析模块。在 --module-path 中查找模块，从根模块出发按照requires解析出参与运行的模块，
+// 并报告缺失的模块、循环依赖以及分裂包（同一个包出现在多个模块中）。参考 java.lang.module.Resolver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const __MODULE_INFO__ = "module-info"                     // 模块描述class
+const __JAVA_BASE__ = "java.base"                         // 所有模块都隐式依赖的模块
+const __AUTOMATIC_MODULE_NAME__ = "Automatic-Module-Name" // manifest中指定自动模块名称的属性
+
+// 由jar文件名推导自动模块名称，与 ModuleFinder.of 的规则一致
+var moduleVersionSuffix = regexp.MustCompile(`-(\d+(\.|$))`)
+var moduleNameSeparators = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// 由jar文件名推导自动模块名称，例如 commons-lang3-3.12.0.jar => commons.lang3
+func automaticModuleName(jarPath string) string {
+	var name = filepath.Base(jarPath)
+	name = name[:len(name)-len(filepath.Ext(name))]
+	if loc := moduleVersionSuffix.FindStringIndex(name); loc != nil {
+		name = name[:loc[0]]
+	}
+	return strings.Trim(moduleNameSeparators.ReplaceAllString(name, "."), ".")
+}
+
+//+--------------------------------- ModuleReference definition -----------------------------+
+
+// 模块路径上找到的一个模块
+type ModuleReference struct {
+	name      string
+	location  string // 模块所在的jar或目录
+	entry     ClassEntry
+	module    *ModuleAttribute // 自动模块为nil
+	mainClass string           // 例如 com.acme.Main
+	packages  []string         // 按照名称排序
+}
+
+func (this *ModuleReference) Name() string { return this.name }
+
+func (this *ModuleReference) Location() string { return this.location }
+
+// 获取读取模块中class的ClassEntry
+func (this *ModuleReference) Entry() ClassEntry { return this.entry }
+
+// 获取模块声明，自动模块返回nil
+func (this *ModuleReference) Module() *ModuleAttribute { return this.module }
+
+// 判断是否为自动模块，即没有 module-info.class 的jar
+func (this *ModuleReference) IsAutomatic() bool { return this.module == nil }
+
+// 获取模块的入口类，来自ModuleMainClass属性或者自动模块manifest的 Main-Class
+func (this *ModuleReference) MainClass() string { return this.mainClass }
+
+// 获取模块包含的包（内部形式，例如 com/acme/api）
+func (this *ModuleReference) Packages() []string { return this.packages }
+
+func (this *ModuleReference) String() string {
+	return this.name + " (" + this.location + ")"
+}
+
+// 判断目录是否为展开的模块，即根目录下存在 module-info.class
+func isExplodedModule(dir string) bool {
+	var stat, err = os.Stat(filepath.Join(dir, __MODULE_INFO__+__CLASS_FILE_SUFFIX__))
+	return err == nil && !stat.IsDir()
+}
+
+// 读取jar或目录中的模块，不包含 module-info.class 的目录不是模块，返回nil
+func newModuleReference(location string, entry ClassEntry, options ClasspathOptions) (*ModuleReference, error) {
+	var reference = &ModuleReference{location: location, entry: entry}
+	var bytecode, _, err = entry.ReadClass(__MODULE_INFO__)
+	if err == nil {
+		var javaClass *JavaClass
+		if javaClass, err = ParseJavaByteCodeWithOptions(bytecode, options.parseOptions()); err != nil {
+			return nil, fmt.Errorf("invalid module descriptor in %s => %w", location, err)
+		}
+		if reference.module = javaClass.Module(); reference.module == nil {
+			return nil, fmt.Errorf("module-info.class in %s has no Module attribute", location)
+		}
+		reference.name = reference.module.ModuleName()
+		reference.mainClass = strings.ReplaceAll(javaClass.ModuleMainClass(), "/", ".")
+		reference.packages = javaClass.ModulePackages()
+	} else if IsClassNotFound(err) {
+		var jar, ok = entry.(*CompressedClassEntry)
+		if !ok {
+			return nil, nil
+		}
+		// 自动模块，名称优先使用manifest中的 Automatic-Module-Name
+		var manifest *Manifest
+		if manifest, err = jar.Manifest(); err != nil {
+			return nil, fmt.Errorf("invalid or corrupt jarfile %s => %w", location, err)
+		}
+		reference.name = automaticModuleName(location)
+		if manifest != nil {
+			if name := manifest.Get(__AUTOMATIC_MODULE_NAME__); name != "" {
+				reference.name = name
+			}
+			reference.mainClass = manifest.MainClass()
+		}
+		if reference.name == "" {
+			return nil, fmt.Errorf("unable to derive module name from %s", location)
+		}
+	} else {
+		return nil, err
+	}
+	// 以实际包含的class补全ModulePackages中没有记录的包
+	if lister, ok := entry.(ClassLister); ok {
+		var classes []string
+		if classes, err = lister.ListClasses(); err != nil {
+			return nil, err
+		}
+		for _, class := range classes {
+			reference.packages = append(reference.packages, packageOf(class))
+		}
+	}
+	var seen = map[string]bool{"": true} // 模块中不允许使用无名包，module-info 本身也在其中
+	var packages = make([]string, 0, len(reference.packages))
+	for _, pkg := range reference.packages {
+		if !seen[pkg] {
+			seen[pkg] = true
+			packages = append(packages, pkg)
+		}
+	}
+	sort.Strings(packages)
+	reference.packages = packages
+	return reference, nil
+}
+
+// 读取模块路径上的一个jar或者展开的模块
+func findModule(path string, options ClasspathOptions) (*ModuleReference, error) {
+	var stat, err = os.Stat(path)
+	if err != nil {
+		return nil, &ClasspathError{segment: path, kind: CLASSPATH_NOT_FOUND, err: err}
+	}
+	var entry ClassEntry
+	if stat.IsDir() {
+		entry, err = newDirClassEntry(path)
+	} else if isCompressed(path) {
+		entry, err = newCompressedClassEntry(path, options.release())
+	} else {
+		return nil, &ClasspathError{segment: path, kind: CLASSPATH_INVALID}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newModuleReference(path, entry, options)
+}
+
+// 查找模块路径上的所有模块。模块路径的每个片段可以是jar、展开的模块，或者包含它们的目录。
+// 同名的模块只有最先找到的生效
+func FindModules(modulePath string) ([]*ModuleReference, error) {
+	return findModules(modulePath, ClasspathOptions{})
+}
+
+func findModules(modulePath string, options ClasspathOptions) ([]*ModuleReference, error) {
+	var modules = make([]*ModuleReference, 0)
+	var names = make(map[string]bool)
+	for _, segment := range strings.Split(modulePath, __OS_PATH_SEPARATOR__) {
+		if strings.TrimSpace(segment) == "" {
+			continue
+		}
+		var absPath, stat, err = statClasspath(segment)
+		if err != nil {
+			return nil, err
+		}
+		var candidates = []string{absPath}
+		if stat.IsDir() && !isExplodedModule(absPath) {
+			// 模块目录，其中的每个jar和展开的模块都是一个模块
+			var files, err = ioutil.ReadDir(absPath)
+			if err != nil {
+				return nil, &ClasspathError{segment: segment, kind: CLASSPATH_INVALID, err: err}
+			}
+			candidates = candidates[:0]
+			for _, file := range files {
+				var path = filepath.Join(absPath, file.Name())
+				if (file.IsDir() && isExplodedModule(path)) || (!file.IsDir() && isCompressed(path)) {
+					candidates = append(candidates, path)
+				}
+			}
+		}
+		for _, candidate := range candidates {
+			var reference, err = findModule(candidate, options)
+			if err != nil {
+				return nil, err
+			}
+			if reference == nil {
+				continue
+			}
+			if names[reference.name] {
+				debug("module shadowed => ", reference)
+				continue
+			}
+			names[reference.name] = true
+			modules = append(modules, reference)
+		}
+	}
+	return modules, nil
+}
+
+//+--------------------------------- ModuleResolutionError definition -----------------------------+
+
+// 模块解析时发现的所有问题
+type ModuleResolutionError struct {
+	missing       map[string][]string // 缺失的模块 => 依赖它的模块，缺失的是根模块时为空
+	cycles        [][]string          // 循环依赖，首尾为同一个模块，例如 [a b a]
+	splitPackages map[string][]string // 包 => 包含该包的模块
+}
+
+func (this *ModuleResolutionError) addMissing(name string, requiredBy string) {
+	var modules = this.missing[name]
+	if requiredBy != "" {
+		modules = append(modules, requiredBy)
+	}
+	this.missing[name] = modules
+}
+
+func (this *ModuleResolutionError) empty() bool {
+	return len(this.missing) == 0 && len(this.cycles) == 0 && len(this.splitPackages) == 0
+}
+
+func sortedKeys(m map[string][]string) []string {
+	var keys = make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (this *ModuleResolutionError) Error() string {
+	var messages = make([]string, 0)
+	for _, name := range sortedKeys(this.missing) {
+		var message = "module " + name + " not found"
+		if requiredBy := this.missing[name]; len(requiredBy) > 0 {
+			message += ", required by " + strings.Join(requiredBy, ", ")
+		}
+		messages = append(messages, message)
+	}
+	for _, cycle := range this.cycles {
+		messages = append(messages, "cycle detected: "+strings.Join(cycle, " -> "))
+	}
+	for _, pkg := range sortedKeys(this.splitPackages) {
+		messages = append(messages, fmt.Sprintf("package %s is split between modules %s",
+			strings.ReplaceAll(pkg, "/", "."), strings.Join(this.splitPackages[pkg], ", ")))
+	}
+	return strings.Join(messages, "; ")
+}
+
+// 获取缺失的模块以及依赖它们的模块
+func (this *ModuleResolutionError) MissingModules() map[string][]string { return this.missing }
+
+// 获取循环依赖，每个循环的首尾为同一个模块
+func (this *ModuleResolutionError) Cycles() [][]string { return this.cycles }
+
+// 获取分裂包以及包含它们的模块
+func (this *ModuleResolutionError) SplitPackages() map[string][]string { return this.splitPackages }
+
+//+--------------------------------- ModuleGraph definition -----------------------------+
+
+// 解析完成的模块图，只包含模块路径上的模块，系统模块由启动类路径提供
+type ModuleGraph struct {
+	modules []*ModuleReference // 按照解析顺序排列，第一个为根模块
+	names   map[string]*ModuleReference
+	reads   map[string][]string // 模块 => 可读的模块（包括系统模块），按照名称排序
+}
+
+func (this *ModuleGraph) Modules() []*ModuleReference { return this.modules }
+
+// 获取已解析的模块，系统模块和没有参与解析的模块返回nil
+func (this *ModuleGraph) Module(name string) *ModuleReference { return this.names[name] }
+
+// 获取模块可以读取的模块，包括 requires transitive 带来的隐式可读性
+func (this *ModuleGraph) Reads(name string) []string { return this.reads[name] }
+
+// 按照解析顺序组合所有模块的ClassEntry，作为用户类路径使用
+func (this *ModuleGraph) ClassEntry() ClassEntry {
+	var entrys = make([]ClassEntry, len(this.modules))
+	for idx, module := range this.modules {
+		entrys[idx] = module.entry
+	}
+	return newCompositeClassEntryOf(entrys)
+}
+
+// 获取运行时镜像中系统模块的包 => 模块名称。没有运行时镜像（JDK 8 的布局）时返回nil
+func systemPackages(system ClassEntry) map[string]string {
+	for _, leaf := range flattenClassEntry(system) {
+		if image, ok := leaf.(*JImageClassEntry); ok && image.open() == nil {
+			return image.packages
+		}
+	}
+	return nil
+}
+
+type moduleResolver struct {
+	packages  map[string]string // 系统模块的包 => 模块名称
+	system    map[string]bool   // 系统模块
+	available map[string]*ModuleReference
+	found     []*ModuleReference // 模块路径上的所有模块，按照查找顺序排列
+	graph     *ModuleGraph
+	problems  *ModuleResolutionError
+}
+
+// 判断是否为系统模块。没有运行时镜像时，平台类都在rt.jar中，java.* 和 jdk.* 模块视为由启动类路径提供
+func (this *moduleResolver) isSystem(name string) bool {
+	if this.packages == nil {
+		return name == __JAVA_BASE__ || strings.HasPrefix(name, "java.") || strings.HasPrefix(name, "jdk.")
+	}
+	return this.system[name]
+}
+
+func (this *moduleResolver) isResolved(name string) bool {
+	return this.graph.names[name] != nil || this.isSystem(name)
+}
+
+// 从根模块出发，按照广度优先的顺序解析requires。requires static 只在编译时必需，不参与解析
+func (this *moduleResolver) resolve(roots []string) {
+	var queue = make([]string, 0)
+	var queued = make(map[string]bool)
+	var enqueue = func(name string, requiredBy string) {
+		if queued[name] || this.isSystem(name) {
+			return
+		}
+		if this.available[name] == nil {
+			this.problems.addMissing(name, requiredBy)
+			return
+		}
+		queued[name] = true
+		queue = append(queue, name)
+	}
+	for _, root := range roots {
+		enqueue(root, "")
+	}
+	var automatic = false
+	for len(queue) > 0 {
+		var reference = this.available[queue[0]]
+		queue = queue[1:]
+		this.graph.modules = append(this.graph.modules, reference)
+		this.graph.names[reference.name] = reference
+		if reference.IsAutomatic() {
+			// 任何一个自动模块被解析时，模块路径上的所有自动模块都会被解析
+			if !automatic {
+				automatic = true
+				for _, other := range this.found {
+					if other.IsAutomatic() {
+						enqueue(other.name, reference.name)
+					}
+				}
+			}
+			continue
+		}
+		for _, require := range reference.module.requires {
+			if !require.AccessFlags().IsStaticPhase() {
+				enqueue(require.Name(), reference.name)
+			}
+		}
+	}
+}
+
+// 计算模块的可读性，requires transitive 会传递，自动模块可以读取所有模块
+func (this *moduleResolver) computeReads() {
+	for _, reference := range this.graph.modules {
+		var reads = make(map[string]bool)
+		if reference.IsAutomatic() {
+			for _, other := range this.graph.modules {
+				reads[other.name] = true
+			}
+			for name := range this.system {
+				reads[name] = true
+			}
+		} else {
+			var visited = make(map[string]bool)
+			for _, require := range reference.module.requires {
+				if this.isResolved(require.Name()) {
+					reads[require.Name()] = true
+					this.addImpliedReads(require.Name(), reads, visited)
+				}
+			}
+		}
+		reads[__JAVA_BASE__] = true
+		delete(reads, reference.name)
+		var names = make([]string, 0, len(reads))
+		for name := range reads {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		this.graph.reads[reference.name] = names
+	}
+}
+
+// 读取一个模块时，同时可以读取它 requires transitive 的模块。自动模块隐式地 requires transitive 所有自动模块
+func (this *moduleResolver) addImpliedReads(name string, reads map[string]bool, visited map[string]bool) {
+	var reference = this.graph.names[name]
+	if reference == nil || visited[name] {
+		return
+	}
+	visited[name] = true
+	if reference.IsAutomatic() {
+		for _, other := range this.graph.modules {
+			if other.IsAutomatic() {
+				reads[other.name] = true
+			}
+		}
+		return
+	}
+	for _, require := range reference.module.requires {
+		if require.AccessFlags().IsTransitive() && this.isResolved(require.Name()) {
+			reads[require.Name()] = true
+			this.addImpliedReads(require.Name(), reads, visited)
+		}
+	}
+}
+
+// 深度优先查找显式模块之间的循环依赖
+func (this *moduleResolver) findCycles() {
+	const visiting, done = 1, 2
+	var state = make(map[string]int)
+	var stack = make([]string, 0)
+	var visit func(reference *ModuleReference)
+	visit = func(reference *ModuleReference) {
+		state[reference.name] = visiting
+		stack = append(stack, reference.name)
+		for _, require := range reference.module.requires {
+			var target = this.graph.names[require.Name()]
+			if target == nil || target.IsAutomatic() {
+				continue
+			}
+			switch state[target.name] {
+			case 0:
+				visit(target)
+			case visiting:
+				var start = len(stack) - 1
+				for stack[start] != target.name {
+					start--
+				}
+				var cycle = append(append([]string{}, stack[start:]...), target.name)
+				this.problems.cycles = append(this.problems.cycles, cycle)
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[reference.name] = done
+	}
+	for _, reference := range this.graph.modules {
+		if !reference.IsAutomatic() && state[reference.name] == 0 {
+			visit(reference)
+		}
+	}
+}
+
+// 同一个包不能出现在多个模块中，包括系统模块
+func (this *moduleResolver) findSplitPackages() {
+	var owners = make(map[string][]string)
+	for _, reference := range this.graph.modules {
+		for _, pkg := range reference.packages {
+			if module, ok := this.packages[pkg]; ok && len(owners[pkg]) == 0 {
+				owners[pkg] = []string{module}
+			}
+			owners[pkg] = append(owners[pkg], reference.name)
+		}
+	}
+	for pkg, modules := range owners {
+		if len(modules) > 1 {
+			this.problems.splitPackages[pkg] = modules
+		}
+	}
+}
+
+// 从根模块出发解析模块路径上的模块，system为启动类路径，用于确定系统模块。
+// 缺失的模块、循环依赖和分裂包会一起通过 *ModuleResolutionError 报告
+func ResolveModules(system ClassEntry, modulePath string, roots ...string) (*ModuleGraph, error) {
+	return resolveModules(system, modulePath, ClasspathOptions{}, roots)
+}
+
+func resolveModules(system ClassEntry, modulePath string, options ClasspathOptions, roots []string) (*ModuleGraph, error) {
+	var found, err = findModules(modulePath, options)
+	if err != nil {
+		return nil, err
+	}
+	var resolver = &moduleResolver{
+		packages:  systemPackages(system),
+		system:    make(map[string]bool),
+		available: make(map[string]*ModuleReference),
+		found:     found,
+		graph:     &ModuleGraph{names: make(map[string]*ModuleReference), reads: make(map[string][]string)},
+		problems:  &ModuleResolutionError{missing: make(map[string][]string), splitPackages: make(map[string][]string)},
+	}
+	for _, module := range resolver.packages {
+		resolver.system[module] = true
+	}
+	for _, reference := range found {
+		if resolver.isSystem(reference.name) {
+			debug("module shadowed by system module => ", reference)
+			continue
+		}
+		resolver.available[reference.name] = reference
+	}
+	resolver.resolve(roots)
+	resolver.computeReads()
+	resolver.findCycles()
+	resolver.findSplitPackages()
+	if !resolver.problems.empty() {
+		return nil, resolver.problems
+	}
+	debug("module graph resolved => ", len(resolver.graph.modules), " modules")
+	return resolver.graph, nil
+}
+
+// 以 -m module[/mainclass] 方式构造classpath，此时忽略 -cp 和 CLASSPATH。
+// 用户类路径由 --module-path 中解析出的模块组成，没有指定入口类时使用根模块的ModuleMainClass
+func NewModuleClasspath(jreOption string, modulePath string, module string) (*Classpath, string, error) {
+	return NewModuleClasspathWithOptions(jreOption, modulePath, module, ClasspathOptions{})
+}
+
+// 使用指定的选项以 -m 方式构造classpath，模块路径上的多版本jar使用选项中的版本
+func NewModuleClasspathWithOptions(jreOption string, modulePath string, module string, options ClasspathOptions) (*Classpath, string, error) {
+	var name, mainClass = module, ""
+	if idx := strings.Index(module, "/"); idx >= 0 {
+		name, mainClass = module[:idx], module[idx+1:]
+	}
+	var classpath, err = newClasspath(jreOption, nil, options)
+	if err != nil {
+		return nil, "", err
+	}
+	var graph *ModuleGraph
+	if graph, err = resolveModules(classpath.bootClasspath, modulePath, options, []string{name}); err != nil {
+		return nil, "", err
+	}
+	if root := graph.Module(name); mainClass == "" && root != nil {
+		mainClass = root.MainClass()
+	}
+	if mainClass == "" {
+		return nil, "", fmt.Errorf("module %s does not have a ModuleMainClass attribute, use -m <module>/<main-class>", name)
+	}
+	classpath.setUserClasspath(graph.ClassEntry())
+	return classpath, mainClass, nil
+}
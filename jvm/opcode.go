@@ -0,0 +1,302 @@
+package jvm
+
+//lint:file-ignore ST1006 MYSTYLE
+// 字节码指令的操作码，名称与JVM规范中的助记符一致
+// 参考 https://docs.oracle.com/javase/specs/jvms/se21/html/jvms-6.html#jvms-6.5
+
+import "fmt"
+
+// 操作码
+type Opcode uint8
+
+const (
+	OP_nop             Opcode = 0x00
+	OP_aconst_null     Opcode = 0x01
+	OP_iconst_m1       Opcode = 0x02
+	OP_iconst_0        Opcode = 0x03
+	OP_iconst_1        Opcode = 0x04
+	OP_iconst_2        Opcode = 0x05
+	OP_iconst_3        Opcode = 0x06
+	OP_iconst_4        Opcode = 0x07
+	OP_iconst_5        Opcode = 0x08
+	OP_lconst_0        Opcode = 0x09
+	OP_lconst_1        Opcode = 0x0a
+	OP_fconst_0        Opcode = 0x0b
+	OP_fconst_1        Opcode = 0x0c
+	OP_fconst_2        Opcode = 0x0d
+	OP_dconst_0        Opcode = 0x0e
+	OP_dconst_1        Opcode = 0x0f
+	OP_bipush          Opcode = 0x10
+	OP_sipush          Opcode = 0x11
+	OP_ldc             Opcode = 0x12
+	OP_ldc_w           Opcode = 0x13
+	OP_ldc2_w          Opcode = 0x14
+	OP_iload           Opcode = 0x15
+	OP_lload           Opcode = 0x16
+	OP_fload           Opcode = 0x17
+	OP_dload           Opcode = 0x18
+	OP_aload           Opcode = 0x19
+	OP_iload_0         Opcode = 0x1a
+	OP_iload_1         Opcode = 0x1b
+	OP_iload_2         Opcode = 0x1c
+	OP_iload_3         Opcode = 0x1d
+	OP_lload_0         Opcode = 0x1e
+	OP_lload_1         Opcode = 0x1f
+	OP_lload_2         Opcode = 0x20
+	OP_lload_3         Opcode = 0x21
+	OP_fload_0         Opcode = 0x22
+	OP_fload_1         Opcode = 0x23
+	OP_fload_2         Opcode = 0x24
+	OP_fload_3         Opcode = 0x25
+	OP_dload_0         Opcode = 0x26
+	OP_dload_1         Opcode = 0x27
+	OP_dload_2         Opcode = 0x28
+	OP_dload_3         Opcode = 0x29
+	OP_aload_0         Opcode = 0x2a
+	OP_aload_1         Opcode = 0x2b
+	OP_aload_2         Opcode = 0x2c
+	OP_aload_3         Opcode = 0x2d
+	OP_iaload          Opcode = 0x2e
+	OP_laload          Opcode = 0x2f
+	OP_faload          Opcode = 0x30
+	OP_daload          Opcode = 0x31
+	OP_aaload          Opcode = 0x32
+	OP_baload          Opcode = 0x33
+	OP_caload          Opcode = 0x34
+	OP_saload          Opcode = 0x35
+	OP_istore          Opcode = 0x36
+	OP_lstore          Opcode = 0x37
+	OP_fstore          Opcode = 0x38
+	OP_dstore          Opcode = 0x39
+	OP_astore          Opcode = 0x3a
+	OP_istore_0        Opcode = 0x3b
+	OP_istore_1        Opcode = 0x3c
+	OP_istore_2        Opcode = 0x3d
+	OP_istore_3        Opcode = 0x3e
+	OP_lstore_0        Opcode = 0x3f
+	OP_lstore_1        Opcode = 0x40
+	OP_lstore_2        Opcode = 0x41
+	OP_lstore_3        Opcode = 0x42
+	OP_fstore_0        Opcode = 0x43
+	OP_fstore_1        Opcode = 0x44
+	OP_fstore_2        Opcode = 0x45
+	OP_fstore_3        Opcode = 0x46
+	OP_dstore_0        Opcode = 0x47
+	OP_dstore_1        Opcode = 0x48
+	OP_dstore_2        Opcode = 0x49
+	OP_dstore_3        Opcode = 0x4a
+	OP_astore_0        Opcode = 0x4b
+	OP_astore_1        Opcode = 0x4c
+	OP_astore_2        Opcode = 0x4d
+	OP_astore_3        Opcode = 0x4e
+	OP_iastore         Opcode = 0x4f
+	OP_lastore         Opcode = 0x50
+	OP_fastore         Opcode = 0x51
+	OP_dastore         Opcode = 0x52
+	OP_aastore         Opcode = 0x53
+	OP_bastore         Opcode = 0x54
+	OP_castore         Opcode = 0x55
+	OP_sastore         Opcode = 0x56
+	OP_pop             Opcode = 0x57
+	OP_pop2            Opcode = 0x58
+	OP_dup             Opcode = 0x59
+	OP_dup_x1          Opcode = 0x5a
+	OP_dup_x2          Opcode = 0x5b
+	OP_dup2            Opcode = 0x5c
+	OP_dup2_x1         Opcode = 0x5d
+	OP_dup2_x2         Opcode = 0x5e
+	OP_swap            Opcode = 0x5f
+	OP_iadd            Opcode = 0x60
+	OP_ladd            Opcode = 0x61
+	OP_fadd            Opcode = 0x62
+	OP_dadd            Opcode = 0x63
+	OP_isub            Opcode = 0x64
+	OP_lsub            Opcode = 0x65
+	OP_fsub            Opcode = 0x66
+	OP_dsub            Opcode = 0x67
+	OP_imul            Opcode = 0x68
+	OP_lmul            Opcode = 0x69
+	OP_fmul            Opcode = 0x6a
+	OP_dmul            Opcode = 0x6b
+	OP_idiv            Opcode = 0x6c
+	OP_ldiv            Opcode = 0x6d
+	OP_fdiv            Opcode = 0x6e
+	OP_ddiv            Opcode = 0x6f
+	OP_irem            Opcode = 0x70
+	OP_lrem            Opcode = 0x71
+	OP_frem            Opcode = 0x72
+	OP_drem            Opcode = 0x73
+	OP_ineg            Opcode = 0x74
+	OP_lneg            Opcode = 0x75
+	OP_fneg            Opcode = 0x76
+	OP_dneg            Opcode = 0x77
+	OP_ishl            Opcode = 0x78
+	OP_lshl            Opcode = 0x79
+	OP_ishr            Opcode = 0x7a
+	OP_lshr            Opcode = 0x7b
+	OP_iushr           Opcode = 0x7c
+	OP_lushr           Opcode = 0x7d
+	OP_iand            Opcode = 0x7e
+	OP_land            Opcode = 0x7f
+	OP_ior             Opcode = 0x80
+	OP_lor             Opcode = 0x81
+	OP_ixor            Opcode = 0x82
+	OP_lxor            Opcode = 0x83
+	OP_iinc            Opcode = 0x84
+	OP_i2l             Opcode = 0x85
+	OP_i2f             Opcode = 0x86
+	OP_i2d             Opcode = 0x87
+	OP_l2i             Opcode = 0x88
+	OP_l2f             Opcode = 0x89
+	OP_l2d             Opcode = 0x8a
+	OP_f2i             Opcode = 0x8b
+	OP_f2l             Opcode = 0x8c
+	OP_f2d             Opcode = 0x8d
+	OP_d2i             Opcode = 0x8e
+	OP_d2l             Opcode = 0x8f
+	OP_d2f             Opcode = 0x90
+	OP_i2b             Opcode = 0x91
+	OP_i2c             Opcode = 0x92
+	OP_i2s             Opcode = 0x93
+	OP_lcmp            Opcode = 0x94
+	OP_fcmpl           Opcode = 0x95
+	OP_fcmpg           Opcode = 0x96
+	OP_dcmpl           Opcode = 0x97
+	OP_dcmpg           Opcode = 0x98
+	OP_ifeq            Opcode = 0x99
+	OP_ifne            Opcode = 0x9a
+	OP_iflt            Opcode = 0x9b
+	OP_ifge            Opcode = 0x9c
+	OP_ifgt            Opcode = 0x9d
+	OP_ifle            Opcode = 0x9e
+	OP_if_icmpeq       Opcode = 0x9f
+	OP_if_icmpne       Opcode = 0xa0
+	OP_if_icmplt       Opcode = 0xa1
+	OP_if_icmpge       Opcode = 0xa2
+	OP_if_icmpgt       Opcode = 0xa3
+	OP_if_icmple       Opcode = 0xa4
+	OP_if_acmpeq       Opcode = 0xa5
+	OP_if_acmpne       Opcode = 0xa6
+	OP_goto            Opcode = 0xa7
+	OP_jsr             Opcode = 0xa8
+	OP_ret             Opcode = 0xa9
+	OP_tableswitch     Opcode = 0xaa
+	OP_lookupswitch    Opcode = 0xab
+	OP_ireturn         Opcode = 0xac
+	OP_lreturn         Opcode = 0xad
+	OP_freturn         Opcode = 0xae
+	OP_dreturn         Opcode = 0xaf
+	OP_areturn         Opcode = 0xb0
+	OP_return          Opcode = 0xb1
+	OP_getstatic       Opcode = 0xb2
+	OP_putstatic       Opcode = 0xb3
+	OP_getfield        Opcode = 0xb4
+	OP_putfield        Opcode = 0xb5
+	OP_invokevirtual   Opcode = 0xb6
+	OP_invokespecial   Opcode = 0xb7
+	OP_invokestatic    Opcode = 0xb8
+	OP_invokeinterface Opcode = 0xb9
+	OP_invokedynamic   Opcode = 0xba
+	OP_new             Opcode = 0xbb
+	OP_newarray        Opcode = 0xbc
+	OP_anewarray       Opcode = 0xbd
+	OP_arraylength     Opcode = 0xbe
+	OP_athrow          Opcode = 0xbf
+	OP_checkcast       Opcode = 0xc0
+	OP_instanceof      Opcode = 0xc1
+	OP_monitorenter    Opcode = 0xc2
+	OP_monitorexit     Opcode = 0xc3
+	OP_wide            Opcode = 0xc4
+	OP_multianewarray  Opcode = 0xc5
+	OP_ifnull          Opcode = 0xc6
+	OP_ifnonnull       Opcode = 0xc7
+	OP_goto_w          Opcode = 0xc8
+	OP_jsr_w           Opcode = 0xc9
+)
+
+// newarray指令的数组类型
+const (
+	T_BOOLEAN = 4
+	T_CHAR    = 5
+	T_FLOAT   = 6
+	T_DOUBLE  = 7
+	T_BYTE    = 8
+	T_SHORT   = 9
+	T_INT     = 10
+	T_LONG    = 11
+)
+
+var opcodeNames = [...]string{
+	"nop", "aconst_null", "iconst_m1", "iconst_0", "iconst_1", "iconst_2", "iconst_3", "iconst_4",
+	"iconst_5", "lconst_0", "lconst_1", "fconst_0", "fconst_1", "fconst_2", "dconst_0", "dconst_1",
+	"bipush", "sipush", "ldc", "ldc_w", "ldc2_w", "iload", "lload", "fload",
+	"dload", "aload", "iload_0", "iload_1", "iload_2", "iload_3", "lload_0", "lload_1",
+	"lload_2", "lload_3", "fload_0", "fload_1", "fload_2", "fload_3", "dload_0", "dload_1",
+	"dload_2", "dload_3", "aload_0", "aload_1", "aload_2", "aload_3", "iaload", "laload",
+	"faload", "daload", "aaload", "baload", "caload", "saload", "istore", "lstore",
+	"fstore", "dstore", "astore", "istore_0", "istore_1", "istore_2", "istore_3", "lstore_0",
+	"lstore_1", "lstore_2", "lstore_3", "fstore_0", "fstore_1", "fstore_2", "fstore_3", "dstore_0",
+	"dstore_1", "dstore_2", "dstore_3", "astore_0", "astore_1", "astore_2", "astore_3", "iastore",
+	"lastore", "fastore", "dastore", "aastore", "bastore", "castore", "sastore", "pop",
+	"pop2", "dup", "dup_x1", "dup_x2", "dup2", "dup2_x1", "dup2_x2", "swap",
+	"iadd", "ladd", "fadd", "dadd", "isub", "lsub", "fsub", "dsub",
+	"imul", "lmul", "fmul", "dmul", "idiv", "ldiv", "fdiv", "ddiv",
+	"irem", "lrem", "frem", "drem", "ineg", "lneg", "fneg", "dneg",
+	"ishl", "lshl", "ishr", "lshr", "iushr", "lushr", "iand", "land",
+	"ior", "lor", "ixor", "lxor", "iinc", "i2l", "i2f", "i2d",
+	"l2i", "l2f", "l2d", "f2i", "f2l", "f2d", "d2i", "d2l",
+	"d2f", "i2b", "i2c", "i2s", "lcmp", "fcmpl", "fcmpg", "dcmpl",
+	"dcmpg", "ifeq", "ifne", "iflt", "ifge", "ifgt", "ifle", "if_icmpeq",
+	"if_icmpne", "if_icmplt", "if_icmpge", "if_icmpgt", "if_icmple", "if_acmpeq", "if_acmpne", "goto",
+	"jsr", "ret", "tableswitch", "lookupswitch", "ireturn", "lreturn", "freturn", "dreturn",
+	"areturn", "return", "getstatic", "putstatic", "getfield", "putfield", "invokevirtual", "invokespecial",
+	"invokestatic", "invokeinterface", "invokedynamic", "new", "newarray", "anewarray", "arraylength", "athrow",
+	"checkcast", "instanceof", "monitorenter", "monitorexit", "wide", "multianewarray", "ifnull", "ifnonnull",
+	"goto_w", "jsr_w",
+}
+
+// 获取助记符，例如 invokevirtual
+func (this Opcode) String() string {
+	if int(this) < len(opcodeNames) {
+		return opcodeNames[this]
+	}
+	return fmt.Sprintf("opcode(0x%x)", uint8(this))
+}
+
+// 指令操作数的种类，决定了ClassBuilder中使用哪个方法生成该指令
+type opcodeKind int
+
+const (
+	kindNone     opcodeKind = iota // 没有操作数
+	kindInt                        // bipush sipush newarray
+	kindVar                        // 局部变量的load store和ret
+	kindJump                       // 跳转
+	kindType                       // 操作数为类的 new anewarray checkcast instanceof
+	kindField                      // 字段访问
+	kindMethod                     // 方法调用，不包括invokedynamic
+	kindSpecial                    // ldc iinc switch invokedynamic multianewarray wide，有专门的方法
+	kindReserved                   // 不是合法的操作码
+)
+
+func (this Opcode) kind() opcodeKind {
+	switch {
+	case this >= OP_bipush && this <= OP_sipush, this == OP_newarray:
+		return kindInt
+	case this >= OP_iload && this <= OP_aload, this >= OP_istore && this <= OP_astore, this == OP_ret:
+		return kindVar
+	case this >= OP_ifeq && this <= OP_jsr, this >= OP_ifnull && this <= OP_jsr_w:
+		return kindJump
+	case this == OP_new, this == OP_anewarray, this == OP_checkcast, this == OP_instanceof:
+		return kindType
+	case this >= OP_getstatic && this <= OP_putfield:
+		return kindField
+	case this >= OP_invokevirtual && this <= OP_invokeinterface:
+		return kindMethod
+	case this >= OP_ldc && this <= OP_ldc2_w, this == OP_iinc, this == OP_tableswitch, this == OP_lookupswitch,
+		this == OP_invokedynamic, this == OP_multianewarray, this == OP_wide:
+		return kindSpecial
+	case this > OP_jsr_w:
+		return kindReserved
+	}
+	return kindNone
+}
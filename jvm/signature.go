@@ -0,0 +1,340 @@
+package jvm
+
+//lint:file-ignore ST1006 MYSTYLE
+// 泛型签名解析。Signature属性中记录了类、方法、字段的泛型信息，这里将其解析为类型树
+// 参考 https://docs.oracle.com/javase/specs/jvms/se21/html/jvms-4.html#jvms-4.7.9.1
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 签名中的类型：*BaseTypeSignature、*ClassTypeSignature、*TypeVariableSignature 或 *ArrayTypeSignature
+type TypeSignature interface {
+	String() string // Java源码形式，例如 java.util.List<? extends T>
+}
+
+// 基本类型，Tag为描述符字符，例如 I；方法返回值为void时为 V
+type BaseTypeSignature struct {
+	Tag byte
+}
+
+func (this *BaseTypeSignature) String() string {
+	switch this.Tag {
+	case 'B':
+		return "byte"
+	case 'C':
+		return "char"
+	case 'D':
+		return "double"
+	case 'F':
+		return "float"
+	case 'I':
+		return "int"
+	case 'J':
+		return "long"
+	case 'S':
+		return "short"
+	case 'Z':
+		return "boolean"
+	default:
+		return "void"
+	}
+}
+
+// 类型实参，Wildcard为 0（精确类型）、'*'（?）、'+'（? extends）或 '-'（? super）
+type TypeArgument struct {
+	Wildcard byte
+	Type     TypeSignature // Wildcard为'*'时为nil
+}
+
+func (this *TypeArgument) String() string {
+	switch this.Wildcard {
+	case '*':
+		return "?"
+	case '+':
+		return "? extends " + this.Type.String()
+	case '-':
+		return "? super " + this.Type.String()
+	default:
+		return this.Type.String()
+	}
+}
+
+// 类名中的一段，例如 Map<K, V>.Entry<K, V> 中的 Map<K, V> 和 Entry<K, V>
+type SimpleClassTypeSignature struct {
+	Name          string
+	TypeArguments []*TypeArgument
+}
+
+func (this *SimpleClassTypeSignature) String() string {
+	if len(this.TypeArguments) == 0 {
+		return this.Name
+	}
+	var arguments = make([]string, len(this.TypeArguments))
+	for idx, argument := range this.TypeArguments {
+		arguments[idx] = argument.String()
+	}
+	return this.Name + "<" + strings.Join(arguments, ", ") + ">"
+}
+
+// 类类型
+type ClassTypeSignature struct {
+	Package string                      // 包名，例如 java/util/，默认包为空字符串
+	Classes []*SimpleClassTypeSignature // 外部类在前，内部类在后
+}
+
+// 获取类的全限定名称，例如 java/util/Map$Entry
+func (this *ClassTypeSignature) ClassName() string {
+	var names = make([]string, len(this.Classes))
+	for idx, class := range this.Classes {
+		names[idx] = class.Name
+	}
+	return this.Package + strings.Join(names, "$")
+}
+
+func (this *ClassTypeSignature) String() string {
+	var classes = make([]string, len(this.Classes))
+	for idx, class := range this.Classes {
+		classes[idx] = class.String()
+	}
+	return strings.ReplaceAll(this.Package, "/", ".") + strings.Join(classes, ".")
+}
+
+// 类型变量，例如 T
+type TypeVariableSignature struct {
+	Name string
+}
+
+func (this *TypeVariableSignature) String() string { return this.Name }
+
+// 数组类型
+type ArrayTypeSignature struct {
+	Component TypeSignature
+}
+
+func (this *ArrayTypeSignature) String() string { return this.Component.String() + "[]" }
+
+// 类型形参，例如 T extends Number & Comparable<T>
+type TypeParameter struct {
+	Name            string
+	ClassBound      TypeSignature // 没有类上界时为nil，例如 <T::Ljava/lang/Comparable<TT;>;>
+	InterfaceBounds []TypeSignature
+}
+
+func (this *TypeParameter) String() string {
+	var bounds = make([]string, 0, len(this.InterfaceBounds)+1)
+	if this.ClassBound != nil {
+		bounds = append(bounds, this.ClassBound.String())
+	}
+	for _, bound := range this.InterfaceBounds {
+		bounds = append(bounds, bound.String())
+	}
+	if len(bounds) == 0 {
+		return this.Name
+	}
+	return this.Name + " extends " + strings.Join(bounds, " & ")
+}
+
+// 类签名
+type ClassSignature struct {
+	TypeParameters  []*TypeParameter
+	SuperClass      *ClassTypeSignature
+	SuperInterfaces []*ClassTypeSignature
+}
+
+// 方法签名
+type MethodSignature struct {
+	TypeParameters []*TypeParameter
+	Parameters     []TypeSignature
+	Result         TypeSignature   // void时为Tag为V的 *BaseTypeSignature
+	Throws         []TypeSignature // *ClassTypeSignature 或 *TypeVariableSignature
+}
+
+// 签名格式错误
+type SignatureFormatError struct {
+	signature string
+	offset    int
+	message   string
+}
+
+func (this *SignatureFormatError) Error() string {
+	return fmt.Sprintf("invalid signature %q: %s at offset %d", this.signature, this.message, this.offset)
+}
+
+func (this *SignatureFormatError) Signature() string { return this.signature }
+
+func (this *SignatureFormatError) Offset() int { return this.offset }
+
+//+--------------------------------- signature parser -----------------------------+
+
+type signatureParser struct {
+	textReader
+}
+
+// 标识符不能包含 . ; [ / < > :
+func (this *signatureParser) identifier() string {
+	return this.textReader.identifier(".;[/<>:")
+}
+
+func (this *signatureParser) typeParameters() []*TypeParameter {
+	if !this.accept('<') {
+		return nil
+	}
+	var parameters = make([]*TypeParameter, 0)
+	for this.peek() != '>' {
+		var parameter = &TypeParameter{Name: this.identifier()}
+		this.expect(':')
+		if c := this.peek(); c == 'L' || c == 'T' || c == '[' {
+			parameter.ClassBound = this.referenceType()
+		}
+		for this.accept(':') {
+			parameter.InterfaceBounds = append(parameter.InterfaceBounds, this.referenceType())
+		}
+		parameters = append(parameters, parameter)
+	}
+	if len(parameters) == 0 {
+		this.fail("empty type parameters")
+	}
+	this.pos++
+	return parameters
+}
+
+func (this *signatureParser) referenceType() TypeSignature {
+	switch this.peek() {
+	case 'L':
+		return this.classType()
+	case 'T':
+		this.pos++
+		var variable = &TypeVariableSignature{Name: this.identifier()}
+		this.expect(';')
+		return variable
+	case '[':
+		var dimensions = this.arrayDimensions()
+		var result = this.javaType()
+		for ; dimensions > 0; dimensions-- {
+			result = &ArrayTypeSignature{Component: result}
+		}
+		return result
+	default:
+		this.fail("expect reference type")
+		return nil
+	}
+}
+
+func (this *signatureParser) javaType() TypeSignature {
+	if tag := this.baseType(); tag != 0 {
+		return &BaseTypeSignature{Tag: tag}
+	}
+	return this.referenceType()
+}
+
+func (this *signatureParser) classType() *ClassTypeSignature {
+	this.expect('L')
+	var class = &ClassTypeSignature{}
+	var name = this.identifier()
+	for this.accept('/') {
+		class.Package += name + "/"
+		name = this.identifier()
+	}
+	for {
+		var simple = &SimpleClassTypeSignature{Name: name}
+		if this.peek() == '<' {
+			simple.TypeArguments = this.typeArguments()
+		}
+		class.Classes = append(class.Classes, simple)
+		if !this.accept('.') {
+			break
+		}
+		name = this.identifier()
+	}
+	this.expect(';')
+	return class
+}
+
+func (this *signatureParser) typeArguments() []*TypeArgument {
+	this.expect('<')
+	var arguments = make([]*TypeArgument, 0)
+	for this.peek() != '>' {
+		switch c := this.peek(); c {
+		case '*':
+			this.pos++
+			arguments = append(arguments, &TypeArgument{Wildcard: c})
+		case '+', '-':
+			this.pos++
+			arguments = append(arguments, &TypeArgument{Wildcard: c, Type: this.referenceType()})
+		default:
+			arguments = append(arguments, &TypeArgument{Type: this.referenceType()})
+		}
+	}
+	if len(arguments) == 0 {
+		this.fail("empty type arguments")
+	}
+	this.pos++
+	return arguments
+}
+
+// 解析整个签名，parse返回后必须恰好读完
+func parseSignature(signature string, parse func(parser *signatureParser)) error {
+	var parser = &signatureParser{textReader{text: signature, newError: func(offset int, message string) error {
+		return &SignatureFormatError{signature: signature, offset: offset, message: message}
+	}}}
+	return parser.run(func() { parse(parser) })
+}
+
+// 解析类签名，例如 <T:Ljava/lang/Object;>Ljava/lang/Object;Ljava/lang/Comparable<TT;>;
+func ParseClassSignature(signature string) (*ClassSignature, error) {
+	var result = &ClassSignature{}
+	var err = parseSignature(signature, func(parser *signatureParser) {
+		result.TypeParameters = parser.typeParameters()
+		result.SuperClass = parser.classType()
+		for parser.peek() == 'L' {
+			result.SuperInterfaces = append(result.SuperInterfaces, parser.classType())
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// 解析方法签名，例如 <T:Ljava/lang/Object;>(Ljava/util/List<TT;>;)TT;^Ljava/io/IOException;
+func ParseMethodSignature(signature string) (*MethodSignature, error) {
+	var result = &MethodSignature{}
+	var err = parseSignature(signature, func(parser *signatureParser) {
+		result.TypeParameters = parser.typeParameters()
+		parser.expect('(')
+		result.Parameters = make([]TypeSignature, 0)
+		for parser.peek() != ')' {
+			result.Parameters = append(result.Parameters, parser.javaType())
+		}
+		parser.pos++
+		if parser.accept('V') {
+			result.Result = &BaseTypeSignature{Tag: 'V'}
+		} else {
+			result.Result = parser.javaType()
+		}
+		for parser.accept('^') {
+			if parser.peek() != 'L' && parser.peek() != 'T' {
+				parser.fail("expect class or type variable")
+			}
+			result.Throws = append(result.Throws, parser.referenceType())
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// 解析字段签名，例如 Ljava/util/Map<Ljava/lang/String;+Ljava/lang/Number;>;
+func ParseFieldSignature(signature string) (TypeSignature, error) {
+	var result TypeSignature
+	var err = parseSignature(signature, func(parser *signatureParser) {
+		result = parser.referenceType()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,266 @@
+package jvm
+
+//lint:file-ignore ST1006 MYSTYLE
+// StackMapTable 属性解析。每个栈映射帧描述了某个字节码位置上局部变量表和操作数栈的类型，供类型检查验证器使用
+// 参考 https://docs.oracle.com/javase/specs/jvms/se21/html/jvms-4.html#jvms-4.7.4
+
+import "fmt"
+
+// verification_type_info 的tag值
+const (
+	ITEM_Top               = 0
+	ITEM_Integer           = 1
+	ITEM_Float             = 2
+	ITEM_Double            = 3
+	ITEM_Long              = 4
+	ITEM_Null              = 5
+	ITEM_UninitializedThis = 6
+	ITEM_Object            = 7
+	ITEM_Uninitialized     = 8
+)
+
+// 验证类型
+type VerificationTypeInfo struct {
+	cp         ConstantPool
+	tag        uint8
+	cpoolIndex uint16 // ITEM_Object：类型对应的class常量索引
+	offset     uint16 // ITEM_Uninitialized：创建该对象的new指令的位置
+}
+
+func readVerificationTypeInfo(reader *JavaByteCodeReader, cp ConstantPool) *VerificationTypeInfo {
+	var offset = reader.offset
+	var info = &VerificationTypeInfo{cp: cp, tag: reader.ReadUint8()}
+	switch info.tag {
+	case ITEM_Top, ITEM_Integer, ITEM_Float, ITEM_Double, ITEM_Long, ITEM_Null, ITEM_UninitializedThis:
+	case ITEM_Object:
+		info.cpoolIndex = reader.ReadUint16()
+		if _, ok := cp.Information(info.cpoolIndex).(*ConstantClassInfo); !ok {
+			reader.formatError(offset+1, "invalid class index %d", info.cpoolIndex)
+		}
+	case ITEM_Uninitialized:
+		info.offset = reader.ReadUint16()
+	default:
+		reader.formatError(offset, "unknown verification type tag %d", info.tag)
+	}
+	return info
+}
+
+func readVerificationTypeInfos(reader *JavaByteCodeReader, cp ConstantPool, count int) []*VerificationTypeInfo {
+	var infos = make([]*VerificationTypeInfo, count)
+	for idx := range infos {
+		infos[idx] = readVerificationTypeInfo(reader, cp)
+	}
+	return infos
+}
+
+func (this *VerificationTypeInfo) write(writer *JavaByteCodeWriter) {
+	writer.WriteUint8(this.tag)
+	switch this.tag {
+	case ITEM_Object:
+		writer.WriteUint16(this.cpoolIndex)
+	case ITEM_Uninitialized:
+		writer.WriteUint16(this.offset)
+	}
+}
+
+func writeVerificationTypeInfos(writer *JavaByteCodeWriter, infos []*VerificationTypeInfo) {
+	for _, info := range infos {
+		info.write(writer)
+	}
+}
+
+func (this *VerificationTypeInfo) Tag() uint8 { return this.tag }
+
+// 获取ITEM_Object的类名，例如 java/lang/String
+func (this *VerificationTypeInfo) ClassName() string {
+	if this.tag != ITEM_Object {
+		return ""
+	}
+	return this.cp.getClassName(this.cpoolIndex)
+}
+
+// 获取ITEM_Uninitialized对应的new指令的位置
+func (this *VerificationTypeInfo) Offset() uint16 { return this.offset }
+
+func (this *VerificationTypeInfo) String() string {
+	switch this.tag {
+	case ITEM_Top:
+		return "top"
+	case ITEM_Integer:
+		return "int"
+	case ITEM_Float:
+		return "float"
+	case ITEM_Double:
+		return "double"
+	case ITEM_Long:
+		return "long"
+	case ITEM_Null:
+		return "null"
+	case ITEM_UninitializedThis:
+		return "uninitializedThis"
+	case ITEM_Object:
+		return this.ClassName()
+	default:
+		return fmt.Sprintf("uninitialized(%d)", this.offset)
+	}
+}
+
+// 栈映射帧的种类
+type StackMapFrameKind int
+
+const (
+	SAME_FRAME                              StackMapFrameKind = iota + 1 // frame_type 0-63
+	SAME_LOCALS_1_STACK_ITEM_FRAME                                       // frame_type 64-127
+	SAME_LOCALS_1_STACK_ITEM_FRAME_EXTENDED                              // frame_type 247
+	CHOP_FRAME                                                           // frame_type 248-250
+	SAME_FRAME_EXTENDED                                                  // frame_type 251
+	APPEND_FRAME                                                         // frame_type 252-254
+	FULL_FRAME                                                           // frame_type 255
+)
+
+func (this StackMapFrameKind) String() string {
+	switch this {
+	case SAME_FRAME:
+		return "same"
+	case SAME_LOCALS_1_STACK_ITEM_FRAME:
+		return "same_locals_1_stack_item"
+	case SAME_LOCALS_1_STACK_ITEM_FRAME_EXTENDED:
+		return "same_locals_1_stack_item_extended"
+	case CHOP_FRAME:
+		return "chop"
+	case SAME_FRAME_EXTENDED:
+		return "same_extended"
+	case APPEND_FRAME:
+		return "append"
+	case FULL_FRAME:
+		return "full"
+	default:
+		return "unknown"
+	}
+}
+
+// 栈映射帧
+type StackMapFrame struct {
+	frameType   uint8
+	kind        StackMapFrameKind
+	offsetDelta uint16
+	offset      int                     // 帧对应的字节码绝对位置
+	chopped     int                     // chop帧移除的局部变量个数
+	locals      []*VerificationTypeInfo // append帧为新增的局部变量，full帧为全部局部变量
+	stack       []*VerificationTypeInfo // 操作数栈
+}
+
+func (this *StackMapFrame) FrameType() uint8 { return this.frameType }
+
+func (this *StackMapFrame) Kind() StackMapFrameKind { return this.kind }
+
+func (this *StackMapFrame) OffsetDelta() uint16 { return this.offsetDelta }
+
+// 获取帧对应的字节码绝对位置
+func (this *StackMapFrame) Offset() int { return this.offset }
+
+// 获取chop帧移除的局部变量个数
+func (this *StackMapFrame) ChoppedLocals() int { return this.chopped }
+
+// 获取append帧新增的局部变量，或者full帧的全部局部变量
+func (this *StackMapFrame) Locals() []*VerificationTypeInfo { return this.locals }
+
+// 获取操作数栈，只有same_locals_1_stack_item和full帧不为空
+func (this *StackMapFrame) Stack() []*VerificationTypeInfo { return this.stack }
+
+func readStackMapFrame(reader *JavaByteCodeReader, cp ConstantPool) *StackMapFrame {
+	var offset = reader.offset
+	var frame = &StackMapFrame{frameType: reader.ReadUint8()}
+	switch frameType := frame.frameType; {
+	case frameType <= 63:
+		frame.kind = SAME_FRAME
+		frame.offsetDelta = uint16(frameType)
+	case frameType <= 127:
+		frame.kind = SAME_LOCALS_1_STACK_ITEM_FRAME
+		frame.offsetDelta = uint16(frameType - 64)
+		frame.stack = readVerificationTypeInfos(reader, cp, 1)
+	case frameType <= 246:
+		reader.formatError(offset, "reserved frame type %d", frameType)
+	case frameType == 247:
+		frame.kind = SAME_LOCALS_1_STACK_ITEM_FRAME_EXTENDED
+		frame.offsetDelta = reader.ReadUint16()
+		frame.stack = readVerificationTypeInfos(reader, cp, 1)
+	case frameType <= 250:
+		frame.kind = CHOP_FRAME
+		frame.offsetDelta = reader.ReadUint16()
+		frame.chopped = int(251 - frameType)
+	case frameType == 251:
+		frame.kind = SAME_FRAME_EXTENDED
+		frame.offsetDelta = reader.ReadUint16()
+	case frameType <= 254:
+		frame.kind = APPEND_FRAME
+		frame.offsetDelta = reader.ReadUint16()
+		frame.locals = readVerificationTypeInfos(reader, cp, int(frameType-251))
+	default:
+		frame.kind = FULL_FRAME
+		frame.offsetDelta = reader.ReadUint16()
+		frame.locals = readVerificationTypeInfos(reader, cp, int(reader.ReadUint16()))
+		frame.stack = readVerificationTypeInfos(reader, cp, int(reader.ReadUint16()))
+	}
+	return frame
+}
+
+// 按照frame_type写入，same和same_locals_1_stack_item帧的offset_delta包含在frame_type中
+func (this *StackMapFrame) write(writer *JavaByteCodeWriter) {
+	writer.WriteUint8(this.frameType)
+	switch this.kind {
+	case SAME_FRAME:
+	case SAME_LOCALS_1_STACK_ITEM_FRAME:
+		writeVerificationTypeInfos(writer, this.stack)
+	case SAME_LOCALS_1_STACK_ITEM_FRAME_EXTENDED:
+		writer.WriteUint16(this.offsetDelta)
+		writeVerificationTypeInfos(writer, this.stack)
+	case CHOP_FRAME, SAME_FRAME_EXTENDED:
+		writer.WriteUint16(this.offsetDelta)
+	case APPEND_FRAME:
+		writer.WriteUint16(this.offsetDelta)
+		writeVerificationTypeInfos(writer, this.locals)
+	case FULL_FRAME:
+		writer.WriteUint16(this.offsetDelta)
+		writer.writeCount(len(this.locals), "locals")
+		writeVerificationTypeInfos(writer, this.locals)
+		writer.writeCount(len(this.stack), "stack items")
+		writeVerificationTypeInfos(writer, this.stack)
+	}
+}
+
+type StackMapTableAttribute struct {
+	cp      ConstantPool
+	name    string
+	length  uint32
+	entries []*StackMapFrame
+}
+
+func (this *StackMapTableAttribute) ReadAttribute(reader *JavaByteCodeReader) {
+	var count = reader.ReadUint16()
+	this.entries = make([]*StackMapFrame, count)
+	var offset = -1
+	for idx := range this.entries {
+		reader.enter("frame #%d", idx)
+		var frame = readStackMapFrame(reader, this.cp)
+		// 第一帧的位置为offset_delta，之后每一帧的位置为 上一帧位置 + offset_delta + 1
+		offset += int(frame.offsetDelta) + 1
+		frame.offset = offset
+		this.entries[idx] = frame
+		reader.leave()
+	}
+}
+
+func (this *StackMapTableAttribute) WriteAttribute(writer *JavaByteCodeWriter) {
+	writer.writeCount(len(this.entries), "frames")
+	for idx, frame := range this.entries {
+		writer.enter("frame #%d", idx)
+		frame.write(writer)
+		writer.leave()
+	}
+}
+
+func (this *StackMapTableAttribute) Name() string { return this.name }
+
+// 获取所有的栈映射帧
+func (this *StackMapTableAttribute) Entries() []*StackMapFrame { return this.entries }
@@ -0,0 +1,312 @@
+package jvm
+
+//lint:file-ignore ST1006 MYSTYLE
+// 描述类之间结构关系的属性：InnerClasses、EnclosingMethod、NestHost、NestMembers、PermittedSubclasses、Record
+
+// 内部类表中的一项
+type InnerClassEntry struct {
+	cp                    ConstantPool
+	innerClassInfoIndex   uint16
+	outerClassInfoIndex   uint16 // 顶层类、局部类和匿名类为0
+	innerNameIndex        uint16 // 匿名类为0
+	innerClassAccessFlags uint16
+}
+
+// 获取内部类的全限定名称，例如 com/acme/Outer$Inner
+func (this *InnerClassEntry) InnerClassName() string {
+	return this.cp.getClassName(this.innerClassInfoIndex)
+}
+
+// 获取外部类的全限定名称，局部类和匿名类返回空字符串
+func (this *InnerClassEntry) OuterClassName() string {
+	if this.outerClassInfoIndex == 0 {
+		return ""
+	}
+	return this.cp.getClassName(this.outerClassInfoIndex)
+}
+
+// 获取源码中的简单名称，匿名类返回空字符串
+func (this *InnerClassEntry) InnerName() string {
+	if this.innerNameIndex == 0 {
+		return ""
+	}
+	return this.cp.getUtf8(this.innerNameIndex)
+}
+
+// 获取源码中声明的访问标识符
+func (this *InnerClassEntry) AccessFlags() InnerClassAccessFlags {
+	return InnerClassAccessFlags(this.innerClassAccessFlags)
+}
+
+type InnerClassesAttribute struct {
+	cp      ConstantPool
+	name    string
+	length  uint32
+	classes []*InnerClassEntry
+}
+
+func (this *InnerClassesAttribute) ReadAttribute(reader *JavaByteCodeReader) {
+	this.classes = make([]*InnerClassEntry, reader.ReadUint16())
+	for idx := range this.classes {
+		reader.enter("inner class #%d", idx)
+		var entry = &InnerClassEntry{cp: this.cp}
+		entry.innerClassInfoIndex = readClassIndex(reader, this.cp, "inner class info", false)
+		entry.outerClassInfoIndex = readClassIndex(reader, this.cp, "outer class info", true)
+		var offset = reader.offset
+		entry.innerNameIndex = reader.ReadUint16()
+		if _, ok := this.cp.lookupUtf8(entry.innerNameIndex); !ok && entry.innerNameIndex != 0 {
+			reader.formatError(offset, "invalid inner name index %d", entry.innerNameIndex)
+		}
+		entry.innerClassAccessFlags = reader.ReadUint16()
+		this.classes[idx] = entry
+		reader.leave()
+	}
+}
+
+func (this *InnerClassesAttribute) WriteAttribute(writer *JavaByteCodeWriter) {
+	writer.writeCount(len(this.classes), "inner classes")
+	for _, entry := range this.classes {
+		writer.WriteUint16(entry.innerClassInfoIndex)
+		writer.WriteUint16(entry.outerClassInfoIndex)
+		writer.WriteUint16(entry.innerNameIndex)
+		writer.WriteUint16(entry.innerClassAccessFlags)
+	}
+}
+
+func (this *InnerClassesAttribute) Name() string { return this.name }
+
+func (this *InnerClassesAttribute) Classes() []*InnerClassEntry { return this.classes }
+
+// 局部类和匿名类所在的类和方法
+type EnclosingMethodAttribute struct {
+	cp          ConstantPool
+	name        string
+	length      uint32
+	classIndex  uint16
+	methodIndex uint16 // NameAndType常量索引，不在方法中（例如在字段初始化中）时为0
+}
+
+func (this *EnclosingMethodAttribute) ReadAttribute(reader *JavaByteCodeReader) {
+	this.classIndex = readClassIndex(reader, this.cp, "class", false)
+	var offset = reader.offset
+	this.methodIndex = reader.ReadUint16()
+	if _, ok := this.cp.Information(this.methodIndex).(*ConstantNameAndTypeInfo); !ok && this.methodIndex != 0 {
+		reader.formatError(offset, "invalid method index %d", this.methodIndex)
+	}
+}
+
+func (this *EnclosingMethodAttribute) WriteAttribute(writer *JavaByteCodeWriter) {
+	writer.WriteUint16(this.classIndex)
+	writer.WriteUint16(this.methodIndex)
+}
+
+func (this *EnclosingMethodAttribute) Name() string { return this.name }
+
+// 获取外围类的全限定名称
+func (this *EnclosingMethodAttribute) ClassName() string {
+	return this.cp.getClassName(this.classIndex)
+}
+
+// 获取外围方法的名称和描述符，不在方法中时返回空字符串
+func (this *EnclosingMethodAttribute) MethodNameAndDescriptor() (string, string) {
+	if this.methodIndex == 0 {
+		return "", ""
+	}
+	return this.cp.getNameAndType(this.methodIndex)
+}
+
+// 所属nest的宿主类
+type NestHostAttribute struct {
+	cp             ConstantPool
+	name           string
+	length         uint32
+	hostClassIndex uint16
+}
+
+func (this *NestHostAttribute) ReadAttribute(reader *JavaByteCodeReader) {
+	this.hostClassIndex = readClassIndex(reader, this.cp, "host class", false)
+}
+
+func (this *NestHostAttribute) WriteAttribute(writer *JavaByteCodeWriter) {
+	writer.WriteUint16(this.hostClassIndex)
+}
+
+func (this *NestHostAttribute) Name() string { return this.name }
+
+func (this *NestHostAttribute) HostClassName() string {
+	return this.cp.getClassName(this.hostClassIndex)
+}
+
+// 由class常量索引组成的属性的公共部分
+type ClassListAttribute struct {
+	cp      ConstantPool
+	name    string
+	length  uint32
+	classes []uint16
+}
+
+func (this *ClassListAttribute) ReadAttribute(reader *JavaByteCodeReader) {
+	this.classes = make([]uint16, reader.ReadUint16())
+	for idx := range this.classes {
+		this.classes[idx] = readClassIndex(reader, this.cp, "class", false)
+	}
+}
+
+func (this *ClassListAttribute) WriteAttribute(writer *JavaByteCodeWriter) {
+	writer.WriteUint16s(this.classes)
+}
+
+func (this *ClassListAttribute) Name() string { return this.name }
+
+// 获取所有类的全限定名称
+func (this *ClassListAttribute) ClassNames() []string {
+	var names = make([]string, len(this.classes))
+	for idx, classIndex := range this.classes {
+		names[idx] = this.cp.getClassName(classIndex)
+	}
+	return names
+}
+
+// nest宿主类声明的nest成员
+type NestMembersAttribute struct{ ClassListAttribute }
+
+// sealed类允许的直接子类
+type PermittedSubclassesAttribute struct{ ClassListAttribute }
+
+// record的组件
+type RecordComponent struct {
+	cp              ConstantPool
+	nameIndex       uint16
+	descriptorIndex uint16
+	attributes      []*Attribute // 例如 Signature 和注解
+}
+
+func (this *RecordComponent) Name() string { return this.cp.getUtf8(this.nameIndex) }
+
+func (this *RecordComponent) Descriptor() string { return this.cp.getUtf8(this.descriptorIndex) }
+
+func (this *RecordComponent) Attributes() []*Attribute { return this.attributes }
+
+// 获取组件上运行时可见和不可见的注解
+func (this *RecordComponent) Annotations() []*Annotation { return collectAnnotations(this.attributes) }
+
+type RecordAttribute struct {
+	cp         ConstantPool
+	name       string
+	length     uint32
+	components []*RecordComponent
+}
+
+func (this *RecordAttribute) ReadAttribute(reader *JavaByteCodeReader) {
+	this.components = make([]*RecordComponent, reader.ReadUint16())
+	for idx := range this.components {
+		reader.enter("record component #%d", idx)
+		var component = &RecordComponent{cp: this.cp}
+		component.nameIndex = readUtf8Index(reader, this.cp, "name")
+		component.descriptorIndex = readUtf8Index(reader, this.cp, "descriptor")
+		component.attributes = readAttributes(reader, this.cp)
+		this.components[idx] = component
+		reader.leave()
+	}
+}
+
+func (this *RecordAttribute) WriteAttribute(writer *JavaByteCodeWriter) {
+	writer.writeCount(len(this.components), "record components")
+	for idx, component := range this.components {
+		writer.enter("record component #%d", idx)
+		writer.WriteUint16(component.nameIndex)
+		writer.WriteUint16(component.descriptorIndex)
+		writeAttributes(writer, component.attributes)
+		writer.leave()
+	}
+}
+
+func (this *RecordAttribute) Name() string { return this.name }
+
+func (this *RecordAttribute) Components() []*RecordComponent { return this.components }
+
+//+--------------------------------- JavaClass accessors -----------------------------+
+
+// 获取内部类表，没有InnerClasses属性时返回nil
+func (this *JavaClass) InnerClasses() []*InnerClassEntry {
+	for _, attribute := range this.attributes {
+		if innerClasses, ok := (*attribute).(*InnerClassesAttribute); ok {
+			return innerClasses.classes
+		}
+	}
+	return nil
+}
+
+// 获取局部类和匿名类的外围类和方法，其他类返回nil
+func (this *JavaClass) EnclosingMethod() *EnclosingMethodAttribute {
+	for _, attribute := range this.attributes {
+		if enclosingMethod, ok := (*attribute).(*EnclosingMethodAttribute); ok {
+			return enclosingMethod
+		}
+	}
+	return nil
+}
+
+// 获取nest宿主类的全限定名称，没有NestHost属性的类自身就是宿主
+func (this *JavaClass) NestHost() string {
+	for _, attribute := range this.attributes {
+		if nestHost, ok := (*attribute).(*NestHostAttribute); ok {
+			return nestHost.HostClassName()
+		}
+	}
+	return this.ClassName()
+}
+
+// 获取nest宿主类声明的成员，非宿主类返回nil
+func (this *JavaClass) NestMembers() []string {
+	for _, attribute := range this.attributes {
+		if nestMembers, ok := (*attribute).(*NestMembersAttribute); ok {
+			return nestMembers.ClassNames()
+		}
+	}
+	return nil
+}
+
+// 判断两个类是否属于同一个nest，nestmate之间可以互相访问private成员。
+// 宿主类参与比较时要求其NestMembers中声明了对方
+func (this *JavaClass) IsNestmateOf(other *JavaClass) bool {
+	if this.NestHost() != other.NestHost() {
+		return false
+	}
+	var host, member = this, other
+	if other.ClassName() == other.NestHost() {
+		host, member = other, this
+	}
+	if host.ClassName() != host.NestHost() || host == member {
+		return true // 双方都不是宿主，或者是同一个类
+	}
+	for _, name := range host.NestMembers() {
+		if name == member.ClassName() {
+			return true
+		}
+	}
+	return false
+}
+
+// 获取sealed类允许的直接子类，非sealed类返回nil
+func (this *JavaClass) PermittedSubclasses() []string {
+	for _, attribute := range this.attributes {
+		if permitted, ok := (*attribute).(*PermittedSubclassesAttribute); ok {
+			return permitted.ClassNames()
+		}
+	}
+	return nil
+}
+
+// 判断是否为sealed类
+func (this *JavaClass) IsSealed() bool { return this.PermittedSubclasses() != nil }
+
+// 获取record的组件，非record类返回nil
+func (this *JavaClass) RecordComponents() []*RecordComponent {
+	for _, attribute := range this.attributes {
+		if record, ok := (*attribute).(*RecordAttribute); ok {
+			return record.components
+		}
+	}
+	return nil
+}
@@ -0,0 +1,407 @@
+package jvm
+
+//lint:file-ignore ST1006 MYSTYLE
+// class文件的写出，与ParseJavaByteCode相反：把JavaClass序列化为符合规范的字节码
+// 参考 https://docs.oracle.com/javase/specs/jvms/se21/html/jvms-4.html
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf16"
+)
+
+// Java字节码写入
+type JavaByteCodeWriter struct {
+	bytes.Buffer
+	constantPool *ConstantPoolBuilder // 写入过程中引用的常量（例如属性名称）从这里分配索引
+	structures   []string             // 正在写入的结构，由外到内，用于错误信息
+}
+
+func newJavaByteCodeWriter(constantPool *ConstantPoolBuilder) *JavaByteCodeWriter {
+	return &JavaByteCodeWriter{constantPool: constantPool}
+}
+
+// 进入一个结构，例如 method #2
+func (this *JavaByteCodeWriter) enter(format string, args ...interface{}) {
+	this.structures = append(this.structures, fmt.Sprintf(format, args...))
+}
+
+// 离开当前结构
+func (this *JavaByteCodeWriter) leave() {
+	this.structures = this.structures[:len(this.structures)-1]
+}
+
+// 抛出写入错误，由WriteJavaByteCode转换为error返回
+func (this *JavaByteCodeWriter) fail(format string, args ...interface{}) {
+	var structure = "class file"
+	if len(this.structures) > 0 {
+		structure = strings.Join(this.structures, ": ")
+	}
+	panic(&ClassWriteError{structure: structure, message: fmt.Sprintf(format, args...)})
+}
+
+// 写入8位无符号整数 1 Byte
+func (this *JavaByteCodeWriter) WriteUint8(value uint8) { this.WriteByte(value) }
+
+// 写入16位无符号整数 2 Bytes
+func (this *JavaByteCodeWriter) WriteUint16(value uint16) {
+	var data [2]byte
+	binary.BigEndian.PutUint16(data[:], value)
+	this.Write(data[:])
+}
+
+// 写入32位无符号整数 4 Bytes
+func (this *JavaByteCodeWriter) WriteUint32(value uint32) {
+	var data [4]byte
+	binary.BigEndian.PutUint32(data[:], value)
+	this.Write(data[:])
+}
+
+// 写入64位无符号整数 8 Bytes
+func (this *JavaByteCodeWriter) WriteUint64(value uint64) {
+	var data [8]byte
+	binary.BigEndian.PutUint64(data[:], value)
+	this.Write(data[:])
+}
+
+// 写入uint16的数组，开头为数组大小，与ReadUint16s对应
+func (this *JavaByteCodeWriter) WriteUint16s(values []uint16) {
+	this.writeCount(len(values), "entries")
+	for _, value := range values {
+		this.WriteUint16(value)
+	}
+}
+
+// 写入字节数据
+func (this *JavaByteCodeWriter) WriteBytes(data []byte) { this.Write(data) }
+
+// 写入u2表示的表大小，超过65535时抛出写入错误
+func (this *JavaByteCodeWriter) writeCount(count int, what string) {
+	if count > math.MaxUint16 {
+		this.fail("too many %s: %d", what, count)
+	}
+	this.WriteUint16(uint16(count))
+}
+
+// 写入u1表示的表大小，超过255时抛出写入错误
+func (this *JavaByteCodeWriter) writeCount8(count int, what string) {
+	if count > math.MaxUint8 {
+		this.fail("too many %s: %d", what, count)
+	}
+	this.WriteUint8(uint8(count))
+}
+
+// class文件写入错误，例如常量池或者某个表超出了u2的范围
+type ClassWriteError struct {
+	structure string
+	message   string
+}
+
+func (this *ClassWriteError) Error() string {
+	return fmt.Sprintf("%s: %s", this.structure, this.message)
+}
+
+// 出错时正在写入的结构，例如 method #2: attribute Code
+func (this *ClassWriteError) Structure() string { return this.structure }
+
+// 将写入过程中的panic转换为error，其他panic继续抛出
+func recoverWriteError(recovered interface{}) error {
+	if err, ok := recovered.(*ClassWriteError); ok {
+		return err
+	}
+	panic(recovered)
+}
+
+// 编码为MUTF8，与__decodeMUtf8相反：按UTF-16编码单元编码，
+// \u0000编码为两个字节 C0 80，补充平面的字符编码为两个代理项，每个代理项三个字节
+func __encodeMUtf8(value string) []byte {
+	var chararr = utf16.Encode([]rune(value))
+	var bytearr = make([]byte, 0, len(chararr))
+	for _, c := range chararr {
+		switch {
+		case c != 0 && c <= 0x7F:
+			bytearr = append(bytearr, byte(c))
+		case c <= 0x7FF:
+			bytearr = append(bytearr, byte(0xC0|c>>6&0x1F), byte(0x80|c&0x3F))
+		default:
+			bytearr = append(bytearr, byte(0xE0|c>>12&0x0F), byte(0x80|c>>6&0x3F), byte(0x80|c&0x3F))
+		}
+	}
+	return bytearr
+}
+
+//+--------------------------------- constant pool builder -----------------------------+
+
+// 常量池构造器，按照添加顺序分配索引，内容相同的常量只保留一份，long和double占用两个索引
+type ConstantPoolBuilder struct {
+	data     bytes.Buffer
+	count    int               // 下一个可用的索引，也就是constant_pool_count
+	indexes  map[string]uint16 // tag和内容 => 索引
+	loadable map[uint16]uint8  // 可以被ldc系列指令加载的常量 => 占用的操作数栈槽位
+	err      error             // 第一次失败的原因
+}
+
+func NewConstantPoolBuilder() *ConstantPoolBuilder {
+	return &ConstantPoolBuilder{count: 1, indexes: make(map[string]uint16), loadable: make(map[uint16]uint8)}
+}
+
+// 以已有的常量池为基础构造，已有常量的索引保持不变，之后添加的常量追加在末尾
+func newConstantPoolBuilderOf(cp ConstantPool) *ConstantPoolBuilder {
+	var builder = NewConstantPoolBuilder()
+	for _, information := range cp.informations {
+		if information != nil { // 索引0以及long和double之后不可用的位置
+			builder.add(information, true)
+		}
+	}
+	return builder
+}
+
+// 获取常量的tag
+func constantTag(information ConstantInformation) uint8 {
+	switch information.(type) {
+	case *ConstantIntegerInfo:
+		return CONSTANT_Integer
+	case *ConstantFloatInfo:
+		return CONSTANT_Float
+	case *ConstantLongInfo:
+		return CONSTANT_Long
+	case *ConstantDoubleInfo:
+		return CONSTANT_Double
+	case *ConstantUtf8Info:
+		return CONSTANT_Utf8
+	case *ConstantStringInfo:
+		return CONSTANT_String
+	case *ConstantClassInfo:
+		return CONSTANT_Class
+	case *ConstantFieldrefInfo:
+		return CONSTANT_Fieldref
+	case *ConstantMethodrefInfo:
+		return CONSTANT_Methodref
+	case *ConstantInterfaceMethodrefInfo:
+		return CONSTANT_InterfaceMethodref
+	case *ConstantNameAndTypeInfo:
+		return CONSTANT_NameAndType
+	case *ConstantMethodTypeInfo:
+		return CONSTANT_MethodType
+	case *ConstantMethodHandleInfo:
+		return CONSTANT_MethodHandle
+	case *ConstantInvokeDynamicInfo:
+		return CONSTANT_InvokeDynamic
+	case *ConstantDynamicInfo:
+		return CONSTANT_Dynamic
+	case *ConstantModuleInfo:
+		return CONSTANT_Module
+	case *ConstantPackageInfo:
+		return CONSTANT_Package
+	}
+	panic(fmt.Sprintf("unknown constant %T", information))
+}
+
+// 添加常量，内容相同的常量已经存在时返回其索引。force为true时总是追加，用于保留已有常量池的索引
+func (this *ConstantPoolBuilder) add(information ConstantInformation, force bool) (index uint16) {
+	if this.err != nil {
+		return 0
+	}
+	var entry = newJavaByteCodeWriter(this)
+	entry.enter("constant pool entry #%d", this.count)
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			this.err, index = recoverWriteError(recovered), 0
+		}
+	}()
+	entry.WriteUint8(constantTag(information))
+	information.WriteInformation(entry)
+	var key = entry.String()
+	if existing, ok := this.indexes[key]; ok && !force {
+		return existing
+	}
+	var slots = 1
+	switch information.(type) {
+	case *ConstantLongInfo, *ConstantDoubleInfo:
+		slots = 2 // long和double之后的索引不可用
+	}
+	if this.count+slots > math.MaxUint16 {
+		entry.fail("constant pool overflow")
+	}
+	index = uint16(this.count)
+	this.data.Write(entry.Bytes())
+	this.count += slots
+	if _, ok := this.indexes[key]; !ok {
+		this.indexes[key] = index
+	}
+	if isLoadableConstant(information) {
+		this.loadable[index] = uint8(slots)
+	}
+	return index
+}
+
+// 获取第一次添加失败的原因，例如常量池超过65535项或者Utf8常量过长
+func (this *ConstantPoolBuilder) Err() error { return this.err }
+
+// 获取constant_pool_count，即最大的索引加1
+func (this *ConstantPoolBuilder) Count() uint16 { return uint16(this.count) }
+
+// 获取所有常量的字节码，不包括开头的constant_pool_count
+func (this *ConstantPoolBuilder) Bytes() []byte { return this.data.Bytes() }
+
+func (this *ConstantPoolBuilder) Utf8(value string) uint16 {
+	return this.add(&ConstantUtf8Info{stringValue: value}, false)
+}
+
+func (this *ConstantPoolBuilder) Integer(value JInt) uint16 {
+	return this.add(&ConstantIntegerInfo{intValue: value}, false)
+}
+
+func (this *ConstantPoolBuilder) Float(value JFloat) uint16 {
+	return this.add(&ConstantFloatInfo{floatValue: value}, false)
+}
+
+func (this *ConstantPoolBuilder) Long(value JLong) uint16 {
+	return this.add(&ConstantLongInfo{longValue: value}, false)
+}
+
+func (this *ConstantPoolBuilder) Double(value JDouble) uint16 {
+	return this.add(&ConstantDoubleInfo{doubleValue: value}, false)
+}
+
+// 添加Class常量，name为全限定名称，例如 java/lang/String
+func (this *ConstantPoolBuilder) Class(name string) uint16 {
+	return this.add(&ConstantClassInfo{nameIndex: this.Utf8(name)}, false)
+}
+
+func (this *ConstantPoolBuilder) String(value string) uint16 {
+	return this.add(&ConstantStringInfo{stringIndex: this.Utf8(value)}, false)
+}
+
+func (this *ConstantPoolBuilder) NameAndType(name string, descriptor string) uint16 {
+	return this.add(&ConstantNameAndTypeInfo{nameIndex: this.Utf8(name), descriptorIndex: this.Utf8(descriptor)}, false)
+}
+
+func (this *ConstantPoolBuilder) memberref(class string, name string, descriptor string) ConstantMemberrefInfo {
+	return ConstantMemberrefInfo{classIndex: this.Class(class), nameAndTypeIndex: this.NameAndType(name, descriptor)}
+}
+
+func (this *ConstantPoolBuilder) Fieldref(class string, name string, descriptor string) uint16 {
+	return this.add(&ConstantFieldrefInfo{this.memberref(class, name, descriptor)}, false)
+}
+
+func (this *ConstantPoolBuilder) Methodref(class string, name string, descriptor string) uint16 {
+	return this.add(&ConstantMethodrefInfo{this.memberref(class, name, descriptor)}, false)
+}
+
+func (this *ConstantPoolBuilder) InterfaceMethodref(class string, name string, descriptor string) uint16 {
+	return this.add(&ConstantInterfaceMethodrefInfo{this.memberref(class, name, descriptor)}, false)
+}
+
+// 添加方法句柄，reference为Fieldref、Methodref或InterfaceMethodref常量的索引
+func (this *ConstantPoolBuilder) MethodHandle(kind MethodHandleKind, reference uint16) uint16 {
+	return this.add(&ConstantMethodHandleInfo{referenceKind: kind, referenceIndex: reference}, false)
+}
+
+func (this *ConstantPoolBuilder) MethodType(descriptor string) uint16 {
+	return this.add(&ConstantMethodTypeInfo{descriptorIndex: this.Utf8(descriptor)}, false)
+}
+
+// 添加动态常量，bootstrapMethod为BootstrapMethods属性中的下标
+func (this *ConstantPoolBuilder) Dynamic(bootstrapMethod uint16, name string, descriptor string) uint16 {
+	var index = this.add(&ConstantDynamicInfo{bootstrapMethodAttrIndex: bootstrapMethod, nameAndTypeIndex: this.NameAndType(name, descriptor)}, false)
+	if index != 0 && (descriptor == "J" || descriptor == "D") {
+		this.loadable[index] = 2 // long和double类型的动态常量需要使用ldc2_w加载
+	}
+	return index
+}
+
+// 添加invokedynamic调用点，bootstrapMethod为BootstrapMethods属性中的下标
+func (this *ConstantPoolBuilder) InvokeDynamic(bootstrapMethod uint16, name string, descriptor string) uint16 {
+	return this.add(&ConstantInvokeDynamicInfo{bootstrapMethodAttrIndex: bootstrapMethod, nameAndTypeIndex: this.NameAndType(name, descriptor)}, false)
+}
+
+// 添加Module常量，name为模块名称，例如 java.base
+func (this *ConstantPoolBuilder) Module(name string) uint16 {
+	return this.add(&ConstantModuleInfo{nameIndex: this.Utf8(name)}, false)
+}
+
+// 添加Package常量，name为内部形式的包名称，例如 java/lang
+func (this *ConstantPoolBuilder) Package(name string) uint16 {
+	return this.add(&ConstantPackageInfo{nameIndex: this.Utf8(name)}, false)
+}
+
+//+--------------------------------- class file writer -----------------------------+
+
+// 写入属性表，属性名称从常量池中分配索引，属性长度按照写入的内容重新计算
+func writeAttributes(writer *JavaByteCodeWriter, attributes []*Attribute) {
+	writer.writeCount(len(attributes), "attributes")
+	for _, attribute := range attributes {
+		var name = (*attribute).Name()
+		writer.enter("attribute %s", name)
+		var body = newJavaByteCodeWriter(writer.constantPool)
+		body.structures = append(body.structures, writer.structures...)
+		(*attribute).WriteAttribute(body)
+		if body.Len() > math.MaxUint32 {
+			writer.fail("attribute is too large: %d bytes", body.Len())
+		}
+		writer.WriteUint16(writer.constantPool.Utf8(name))
+		writer.WriteUint32(uint32(body.Len()))
+		writer.WriteBytes(body.Bytes())
+		writer.leave()
+	}
+}
+
+// 写入字段或者方法表
+func writeMembers(writer *JavaByteCodeWriter, members []*MemberInformation, kind string) {
+	writer.writeCount(len(members), kind+"s")
+	for idx, member := range members {
+		writer.enter("%s #%d", kind, idx)
+		writer.WriteUint16(member.accessFlags)
+		writer.WriteUint16(member.nameIndex)
+		writer.WriteUint16(member.descriptorIndex)
+		writeAttributes(writer, member.attributes)
+		writer.leave()
+	}
+}
+
+// 按照read的顺序写入常量池之后的内容
+func (this *JavaClass) write(writer *JavaByteCodeWriter) {
+	writer.enter("class declaration")
+	writer.WriteUint16(this.accessFlags)
+	writer.WriteUint16(this.thisClass)
+	writer.WriteUint16(this.superClass)
+	writer.leave()
+	writer.enter("interfaces")
+	writer.WriteUint16s(this.interfaceClass)
+	writer.leave()
+	writeMembers(writer, this.fields, "field")
+	writeMembers(writer, this.methods, "method")
+	writeAttributes(writer, this.attributes)
+}
+
+// 把JavaClass序列化为class文件。常量池中已有的常量保持原来的索引，属性名称等缺少的常量追加在末尾，
+// 因此解析得到的JavaClass写出后再次解析得到相同的内容，未识别的属性原样写出
+func WriteJavaByteCode(javaClass *JavaClass) ([]byte, error) {
+	return javaClass.serialize(newConstantPoolBuilderOf(javaClass.constantPool))
+}
+
+// 使用给定的常量池序列化，JavaClass中的常量池索引都指向constantPool
+func (this *JavaClass) serialize(constantPool *ConstantPoolBuilder) (bytecode []byte, err error) {
+	var body = newJavaByteCodeWriter(constantPool)
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			bytecode, err = nil, recoverWriteError(recovered)
+		}
+	}()
+	this.write(body)
+	if err = constantPool.Err(); err != nil {
+		return nil, err
+	}
+	var writer = newJavaByteCodeWriter(constantPool)
+	writer.WriteUint32(this.magic)
+	writer.WriteUint16(this.minorVersion)
+	writer.WriteUint16(this.majorVersion)
+	writer.WriteUint16(constantPool.Count())
+	writer.WriteBytes(constantPool.Bytes())
+	writer.WriteBytes(body.Bytes())
+	return writer.Bytes(), nil
+}
@@ -3,11 +3,71 @@ package main
 import (
 	"fmt"
 	"gava/jvm"
+	"os"
 )
 
 func main() {
 
 	var command = jvm.ParseCommand()
-	fmt.Println(command)
-	fmt.Println(jvm.SYS_JAVA_JRE_HOME)
+	var classpath *jvm.Classpath
+	var err error
+	var options = jvm.ClasspathOptions{Lenient: command.Lenient, EnablePreview: command.EnablePreview}
+	if command.Module != "" {
+		classpath, command.EntryPointClass, err = jvm.NewModuleClasspathWithOptions(command.XjreOption, command.ModulePath, command.Module, options)
+	} else if command.Jar != "" {
+		classpath, command.EntryPointClass, err = jvm.NewJarClasspathWithOptions(command.XjreOption, command.Jar, options)
+	} else {
+		classpath, err = jvm.NewClasspathWithOptions(command.XjreOption, command.ClassPath, options)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+	if command.Tool == jvm.TOOL_CLASSPATH {
+		if err = runClasspathTool(command, classpath); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	fmt.Println(classpath)
+	fmt.Println(command.EntryPointClass)
+}
+
+// gava classpath describe | gava classpath which class | gava classpath --duplicates
+func runClasspathTool(command jvm.Command, classpath *jvm.Classpath) error {
+	var describe = len(command.Args) == 1 && command.Args[0] == "describe"
+	var which = len(command.Args) == 2 && command.Args[0] == "which"
+	var duplicates = len(command.Args) == 0 && command.Duplicates
+	if !describe && !which && !duplicates {
+		return fmt.Errorf("usage: gava classpath [options...] describe | which class | --duplicates")
+	}
+	if describe {
+		classpath.Describe(os.Stdout)
+		return nil
+	}
+	if duplicates {
+		var duplicates, err = jvm.FindDuplicateClasses(classpath)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d shadowed classes:\n", len(duplicates))
+		for _, duplicate := range duplicates {
+			fmt.Println(duplicate.ClassName())
+			for idx, entry := range duplicate.Entrys() {
+				if idx == 0 {
+					fmt.Println("    served by  ", entry)
+				} else {
+					fmt.Println("    shadowed in", entry)
+				}
+			}
+		}
+		return nil
+	}
+	var tier, entry, err = classpath.Which(command.Args[1])
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s => %s (%s)\n", command.Args[1], entry, tier)
+	return nil
 }
@@ -0,0 +1,98 @@
+package class_test
+
+import (
+	"gava/jvm"
+	"testing"
+)
+
+// Runtime[In]VisibleAnnotations 属性内容
+func annotationsBody(annotations ...[]byte) []byte {
+	var b = new(classBytes)
+	b.u2(uint16(len(annotations)))
+	for _, annotation := range annotations {
+		b.Write(annotation)
+	}
+	return b.Bytes()
+}
+
+func TestParseAnnotations(ctx *testing.T) {
+	var cp = newTestConstantPool()
+	// @Path("/users")
+	var path = new(classBytes)
+	path.u2(cp.utf8("Ljavax/ws/rs/Path;")).u2(1).u2(cp.utf8("value")).u1('s').u2(cp.utf8("/users"))
+	// @Column(length=255, big=1L, nullable=false, fetch=FetchType.LAZY, type=String.class,
+	//         index=@Index(name="idx"), names={"a", "b"}, sep='/')
+	var column = new(classBytes)
+	column.u2(cp.utf8("Ljavax/persistence/Column;")).u2(8)
+	column.u2(cp.utf8("length")).u1('I').u2(cp.integer(255))
+	column.u2(cp.utf8("big")).u1('J').u2(cp.long(1))
+	column.u2(cp.utf8("nullable")).u1('Z').u2(cp.integer(0))
+	column.u2(cp.utf8("fetch")).u1('e').u2(cp.utf8("Ljavax/persistence/FetchType;")).u2(cp.utf8("LAZY"))
+	column.u2(cp.utf8("type")).u1('c').u2(cp.utf8("Ljava/lang/String;"))
+	column.u2(cp.utf8("index")).u1('@').u2(cp.utf8("Ljavax/persistence/Index;")).u2(1).u2(cp.utf8("name")).u1('s').u2(cp.utf8("idx"))
+	column.u2(cp.utf8("names")).u1('[').u2(2).u1('s').u2(cp.utf8("a")).u1('s').u2(cp.utf8("b"))
+	column.u2(cp.utf8("sep")).u1('C').u2(cp.integer('/'))
+	// 参数注解 @NotNull 和注解默认值 "none"
+	var params = new(classBytes)
+	params.u1(2).u2(0).u2(1).u2(cp.utf8("Ljavax/validation/NotNull;")).u2(0)
+	var defaultValue = new(classBytes)
+	defaultValue.u1('s').u2(cp.utf8("none"))
+	// 方法返回值上的类型注解 @NonNull（method_return，target_type 0x14），带一步数组路径
+	var typeAnnotation = new(classBytes)
+	typeAnnotation.u2(1).u1(0x14).u1(1).u1(0).u1(0).u2(cp.utf8("LNonNull;")).u2(0)
+
+	var field = cp.member(0x0002, "name", "Ljava/lang/String;", cp.attribute("RuntimeVisibleAnnotations", annotationsBody(column.Bytes())))
+	var method = cp.member(0x0401, "find", "(Ljava/lang/String;)[Ljava/lang/String;",
+		cp.attribute("RuntimeInvisibleParameterAnnotations", params.Bytes()),
+		cp.attribute("AnnotationDefault", defaultValue.Bytes()),
+		cp.attribute("RuntimeVisibleTypeAnnotations", typeAnnotation.Bytes()),
+	)
+	var bytecode = cp.build("com/acme/UserResource", [][]byte{field}, [][]byte{method},
+		cp.attribute("RuntimeVisibleAnnotations", annotationsBody(path.Bytes())))
+
+	var javaClass, err = jvm.ParseJavaByteCode(bytecode)
+	if err != nil {
+		ctx.Fatal(err)
+	}
+	var annotations = javaClass.Annotations()
+	if len(annotations) != 1 || annotations[0].TypeName() != "Ljavax/ws/rs/Path;" || annotations[0].Element("value").Value() != "/users" {
+		ctx.Fatal("unexpected class annotations => ", annotations)
+	}
+
+	var columnAnnotation = javaClass.FindField("name", "Ljava/lang/String;").Annotations()[0]
+	var expect = `@Ljavax/persistence/Column;(length=255, big=1, nullable=false, fetch=Ljavax/persistence/FetchType;.LAZY, ` +
+		`type=Ljava/lang/String;.class, index=@Ljavax/persistence/Index;(name="idx"), names={"a", "b"}, sep=47)`
+	if columnAnnotation.String() != expect {
+		ctx.Fatal("unexpected annotation => ", columnAnnotation)
+	}
+	if columnAnnotation.Element("length").Value() != jvm.JInt(255) || columnAnnotation.Element("big").Value() != jvm.JLong(1) ||
+		columnAnnotation.Element("sep").Value() != jvm.JChar('/') || columnAnnotation.Element("nullable").Value() != jvm.JBoolean(false) {
+		ctx.Fatal("unexpected element values => ", columnAnnotation)
+	}
+	if fetch := columnAnnotation.Element("fetch").Value().(*jvm.EnumConstValue); fetch.ConstName != "LAZY" {
+		ctx.Fatal("unexpected enum => ", fetch)
+	}
+
+	var find = javaClass.FindMethod("find", "(Ljava/lang/String;)[Ljava/lang/String;")
+	for _, attribute := range find.Attributes() {
+		switch attribute := (*attribute).(type) {
+		case *jvm.RuntimeInvisibleParameterAnnotationsAttribute:
+			var parameters = attribute.ParameterAnnotations()
+			if len(parameters) != 2 || len(parameters[0]) != 0 || parameters[1][0].TypeName() != "Ljavax/validation/NotNull;" {
+				ctx.Fatal("unexpected parameter annotations => ", parameters)
+			}
+		case *jvm.AnnotationDefaultAttribute:
+			if attribute.DefaultValue().Value() != "none" {
+				ctx.Fatal("unexpected default => ", attribute.DefaultValue())
+			}
+		case *jvm.RuntimeVisibleTypeAnnotationsAttribute:
+			var typeAnnotations = attribute.TypeAnnotations()
+			if len(typeAnnotations) != 1 || typeAnnotations[0].TargetType() != 0x14 || len(typeAnnotations[0].TypePath()) != 1 ||
+				typeAnnotations[0].Annotation().TypeName() != "LNonNull;" {
+				ctx.Fatal("unexpected type annotations => ", typeAnnotations)
+			}
+		default:
+			ctx.Fatal("unexpected attribute => ", attribute)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package class_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"gava/jvm"
+	"strings"
+	"testing"
+)
+
+// 生成只有一个静态方法 run 的类，返回run的字节码
+func assemble(ctx *testing.T, descriptor string, emit func(builder *jvm.ClassBuilder, code *jvm.CodeBuilder)) []byte {
+	var builder = jvm.NewClassBuilder(jvm.ACC_PUBLIC|jvm.ACC_SUPER, "com/acme/Generated", "java/lang/Object")
+	emit(builder, builder.Method(jvm.ACC_PUBLIC|jvm.ACC_STATIC, "run", descriptor).Code())
+	var javaClass, err = builder.Build()
+	if err != nil {
+		ctx.Fatal(err)
+	}
+	return javaClass.FindMethod("run", descriptor).CodeAttribute().Code()
+}
+
+func nops(code *jvm.CodeBuilder, count int) {
+	for ; count > 0; count-- {
+		code.Insn(jvm.OP_nop)
+	}
+}
+
+func TestAssembleJump(ctx *testing.T) {
+	// 0: iload_0; 1: ifeq +5; 4: iconst_1; 5: ireturn; 6: iconst_0; 7: ireturn
+	var code = assemble(ctx, "(Z)I", func(_ *jvm.ClassBuilder, code *jvm.CodeBuilder) {
+		var zero = code.NewLabel()
+		code.Insn(jvm.OP_iload_0).JumpInsn(jvm.OP_ifeq, zero).Insn(jvm.OP_iconst_1).Insn(jvm.OP_ireturn)
+		code.Mark(zero).Insn(jvm.OP_iconst_0).Insn(jvm.OP_ireturn).Maxs(1, 0)
+	})
+	var expect = []byte{0x1a, 0x99, 0x00, 0x05, 0x04, 0xac, 0x03, 0xac}
+	if !bytes.Equal(code, expect) {
+		ctx.Fatalf("unexpected code => % x", code)
+	}
+}
+
+// 不生成StackMapTable，默认使用不要求StackMapTable的49版本
+func TestAssembleVersion(ctx *testing.T) {
+	var branch = func(builder *jvm.ClassBuilder) *jvm.ClassBuilder {
+		var code = builder.Method(jvm.ACC_PUBLIC|jvm.ACC_STATIC, "run", "(Z)I").Code()
+		var zero = code.NewLabel()
+		code.Insn(jvm.OP_iload_0).JumpInsn(jvm.OP_ifeq, zero).Insn(jvm.OP_iconst_1).Insn(jvm.OP_ireturn)
+		code.Mark(zero).Insn(jvm.OP_iconst_0).Insn(jvm.OP_ireturn).Maxs(1, 1)
+		return builder
+	}
+	for _, c := range []struct {
+		builder *jvm.ClassBuilder
+		major   uint16
+	}{
+		{branch(jvm.NewClassBuilder(jvm.ACC_PUBLIC|jvm.ACC_SUPER, "com/acme/Generated", "java/lang/Object")), 49},
+		{branch(jvm.NewClassBuilder(jvm.ACC_PUBLIC|jvm.ACC_SUPER, "com/acme/Generated", "java/lang/Object").Version(52, 0)), 52},
+	} {
+		var javaClass, err = c.builder.Build()
+		if err != nil {
+			ctx.Fatal(err)
+		}
+		if javaClass.MajorVersion() != c.major || javaClass.MinorVersion() != 0 {
+			ctx.Fatal("unexpected version => ", javaClass.MajorVersion(), javaClass.MinorVersion())
+		}
+		for _, attribute := range javaClass.FindMethod("run", "(Z)I").CodeAttribute().Attributes() {
+			if _, ok := (*attribute).(*jvm.StackMapTableAttribute); ok {
+				ctx.Fatal("unexpected StackMapTable in version ", c.major)
+			}
+		}
+	}
+
+	// invokedynamic需要51及以上的版本
+	var indy = func(builder *jvm.ClassBuilder) *jvm.ClassBuilder {
+		var cp = builder.ConstantPool()
+		var handle = cp.MethodHandle(jvm.REF_invokeStatic, cp.Methodref("com/acme/Bootstrap", "bootstrap",
+			"(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/invoke/MethodType;)Ljava/lang/invoke/CallSite;"))
+		builder.Method(jvm.ACC_PUBLIC|jvm.ACC_STATIC, "run", "()V").Code().
+			InvokeDynamic(builder.BootstrapMethod(handle), "run", "()V").Insn(jvm.OP_return)
+		return builder
+	}
+	var _, err = indy(jvm.NewClassBuilder(jvm.ACC_PUBLIC|jvm.ACC_SUPER, "com/acme/Generated", "java/lang/Object")).Build()
+	var writeError *jvm.ClassWriteError
+	if !errors.As(err, &writeError) || !strings.Contains(err.Error(), "requires class file version 51") {
+		ctx.Fatal("unexpected error => ", err)
+	}
+	if _, err = indy(jvm.NewClassBuilder(jvm.ACC_PUBLIC|jvm.ACC_SUPER, "com/acme/Generated", "java/lang/Object").Version(51, 0)).Build(); err != nil {
+		ctx.Fatal(err)
+	}
+}
+
+func TestAssembleWideJump(ctx *testing.T) {
+	// 向前跳过40000条nop，goto加宽为goto_w
+	var forward = assemble(ctx, "()V", func(_ *jvm.ClassBuilder, code *jvm.CodeBuilder) {
+		var end = code.NewLabel()
+		code.JumpInsn(jvm.OP_goto, end)
+		nops(code, 40000)
+		code.Mark(end).Insn(jvm.OP_return)
+	})
+	if jvm.Opcode(forward[0]) != jvm.OP_goto_w || int32(binary.BigEndian.Uint32(forward[1:])) != 40005 || len(forward) != 40006 {
+		ctx.Fatalf("unexpected forward jump => % x", forward[:5])
+	}
+
+	// 向后跳转，偏移量为负数
+	var backward = assemble(ctx, "()V", func(_ *jvm.ClassBuilder, code *jvm.CodeBuilder) {
+		var start = code.NewLabel()
+		code.Mark(start)
+		nops(code, 40000)
+		code.JumpInsn(jvm.OP_goto, start)
+	})
+	if jvm.Opcode(backward[40000]) != jvm.OP_goto_w || int32(binary.BigEndian.Uint32(backward[40001:])) != -40000 {
+		ctx.Fatalf("unexpected backward jump => % x", backward[40000:])
+	}
+
+	// 条件跳转改为反向条件跳过goto_w：ifne +8; goto_w L
+	var conditional = assemble(ctx, "(I)V", func(_ *jvm.ClassBuilder, code *jvm.CodeBuilder) {
+		var end = code.NewLabel()
+		code.Insn(jvm.OP_iload_0).JumpInsn(jvm.OP_ifeq, end)
+		nops(code, 40000)
+		code.Mark(end).Insn(jvm.OP_return).Maxs(1, 0)
+	})
+	var expect = []byte{0x1a, byte(jvm.OP_ifne), 0x00, 0x08, byte(jvm.OP_goto_w)}
+	if !bytes.Equal(conditional[:5], expect) || int32(binary.BigEndian.Uint32(conditional[5:])) != 40005 {
+		ctx.Fatalf("unexpected conditional jump => % x", conditional[:9])
+	}
+	// 跳转目标为return
+	if jvm.Opcode(conditional[4+40005]) != jvm.OP_return {
+		ctx.Fatal("unexpected jump target")
+	}
+}
+
+func TestAssembleSwitch(ctx *testing.T) {
+	var code = assemble(ctx, "(I)I", func(_ *jvm.ClassBuilder, code *jvm.CodeBuilder) {
+		var one, two, other = code.NewLabel(), code.NewLabel(), code.NewLabel()
+		code.Insn(jvm.OP_iload_0).TableSwitch(1, other, one, two)
+		code.Mark(one).Insn(jvm.OP_iconst_1).Insn(jvm.OP_ireturn)
+		code.Mark(two).Insn(jvm.OP_iconst_2).Insn(jvm.OP_ireturn)
+		code.Mark(other).Insn(jvm.OP_iload_0).LookupSwitch(other, []int32{100, -5}, []*jvm.Label{two, one}).Maxs(1, 0)
+	})
+	// tableswitch在偏移量1，填充2个字节后default从4开始
+	var expect = []byte{0x1a, 0xaa, 0, 0, 0, 0, 0, 27, 0, 0, 0, 1, 0, 0, 0, 2, 0, 0, 0, 23, 0, 0, 0, 25,
+		0x04, 0xac, 0x05, 0xac}
+	if !bytes.Equal(code[:28], expect) {
+		ctx.Fatalf("unexpected tableswitch => % x", code[:28])
+	}
+	// lookupswitch在偏移量29，填充2个字节，key按照升序排列
+	expect = []byte{0x1a, 0xab, 0, 0, 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 2,
+		0xff, 0xff, 0xff, 0xfb, 0xff, 0xff, 0xff, 0xfb, 0, 0, 0, 100, 0xff, 0xff, 0xff, 0xfd}
+	if !bytes.Equal(code[28:], expect) {
+		ctx.Fatalf("unexpected lookupswitch => % x", code[28:])
+	}
+}
+
+func TestAssembleOperands(ctx *testing.T) {
+	var builder = jvm.NewClassBuilder(jvm.ACC_PUBLIC, "com/acme/Generated", "java/lang/Object")
+	var cp = builder.ConstantPool()
+	// 类名占用 #1 ~ #4，整数常量占用 #5 ~ #304
+	for i := 0; i < 300; i++ {
+		cp.Integer(jvm.JInt(100000 + i))
+	}
+	var code = builder.Method(jvm.ACC_STATIC, "run", "(J)V").Code()
+	code.Ldc(cp.Integer(100000)).Ldc(cp.Integer(100299)).Ldc(cp.Long(7))
+	code.VarInsn(jvm.OP_lload, 0).VarInsn(jvm.OP_dstore, 300).Iinc(1, 1).Iinc(2, 1000)
+	code.IntInsn(jvm.OP_bipush, -1).IntInsn(jvm.OP_sipush, 1000).IntInsn(jvm.OP_newarray, jvm.T_INT)
+	code.MethodInsn(jvm.OP_invokeinterface, "java/util/List", "add", "(ILjava/lang/Object;)V", true)
+	code.Insn(jvm.OP_return).Maxs(4, 0)
+	var javaClass, err = builder.Build()
+	if err != nil {
+		ctx.Fatal(err)
+	}
+	var run = javaClass.FindMethod("run", "(J)V").CodeAttribute()
+	var prefix = []byte{
+		byte(jvm.OP_ldc), 5,
+		byte(jvm.OP_ldc_w), 0x01, 0x30,
+		byte(jvm.OP_ldc2_w), 0x01, 0x33,
+		byte(jvm.OP_lload), 0,
+		byte(jvm.OP_wide), byte(jvm.OP_dstore), 0x01, 0x2c,
+		byte(jvm.OP_iinc), 1, 1,
+		byte(jvm.OP_wide), byte(jvm.OP_iinc), 0, 2, 0x03, 0xe8,
+		byte(jvm.OP_bipush), 0xff, byte(jvm.OP_sipush), 0x03, 0xe8, byte(jvm.OP_newarray), jvm.T_INT,
+		byte(jvm.OP_invokeinterface),
+	}
+	if !bytes.Equal(run.Code()[:len(prefix)], prefix) || run.Code()[len(prefix)+2] != 3 {
+		ctx.Fatalf("unexpected code => % x", run.Code())
+	}
+	// dstore 300 使用300和301两个槽位
+	if run.MaxLocals() != 302 || run.MaxStack() != 4 {
+		ctx.Fatal("unexpected maxs => ", run.MaxStack(), run.MaxLocals())
+	}
+}
+
+func TestAssembleTryCatch(ctx *testing.T) {
+	var builder = jvm.NewClassBuilder(jvm.ACC_PUBLIC, "com/acme/Generated", "java/lang/Object")
+	var code = builder.Method(jvm.ACC_PUBLIC, "run", "()V").Throws("java/io/IOException").Code()
+	var start, end, handler = code.NewLabel(), code.NewLabel(), code.NewLabel()
+	code.Mark(start).Insn(jvm.OP_aload_0).MethodInsn(jvm.OP_invokevirtual, "java/lang/Object", "hashCode", "()I", false).Insn(jvm.OP_pop)
+	code.Mark(end).Insn(jvm.OP_return)
+	code.Mark(handler).Insn(jvm.OP_athrow).TryCatch(start, end, handler, "java/lang/RuntimeException").Maxs(1, 1)
+	builder.Method(jvm.ACC_PUBLIC|jvm.ACC_ABSTRACT, "size", "()I")
+	builder.Field(jvm.ACC_PUBLIC|jvm.ACC_STATIC|jvm.ACC_FINAL, "LIMIT", "I").ConstantValue(builder.ConstantPool().Integer(10))
+
+	var javaClass, err = builder.Build()
+	if err != nil {
+		ctx.Fatal(err)
+	}
+	var table = javaClass.FindMethod("run", "()V").CodeAttribute().ExceptionTables()
+	var cp = javaClass.ConstantPool()
+	if len(table) != 1 || table[0].StartPC() != 0 || table[0].EndPC() != 5 || table[0].HandlerPC() != 6 ||
+		cp.Information(table[0].CatchType()).(*jvm.ConstantClassInfo).Name() != "java/lang/RuntimeException" {
+		ctx.Fatal("unexpected exception table => ", table)
+	}
+	if javaClass.FindMethod("size", "()I").CodeAttribute() != nil || javaClass.FindField("LIMIT", "I") == nil {
+		ctx.Fatal("unexpected members")
+	}
+
+	// 生成的JavaClass可以再次写出
+	var bytecode, _ = builder.Bytes()
+	var written []byte
+	if written, err = jvm.WriteJavaByteCode(javaClass); err != nil || !bytes.Equal(written, bytecode) {
+		ctx.Fatal("unexpected written bytes => ", err)
+	}
+}
+
+func TestAssembleErrors(ctx *testing.T) {
+	var cases = []struct {
+		name    string
+		emit    func(builder *jvm.ClassBuilder)
+		message string
+	}{
+		{"unmarked label", func(builder *jvm.ClassBuilder) {
+			var code = builder.Method(jvm.ACC_STATIC, "run", "()V").Code()
+			code.JumpInsn(jvm.OP_goto, code.NewLabel())
+		}, "label is not marked"},
+		{"opcode kind", func(builder *jvm.ClassBuilder) {
+			builder.Method(jvm.ACC_STATIC, "run", "()V").Code().JumpInsn(jvm.OP_return, nil)
+		}, "JumpInsn cannot emit return"},
+		{"operand range", func(builder *jvm.ClassBuilder) {
+			builder.Method(jvm.ACC_STATIC, "run", "()V").Code().IntInsn(jvm.OP_bipush, 128)
+		}, "operand 128 is out of range for bipush"},
+		{"not loadable", func(builder *jvm.ClassBuilder) {
+			builder.Method(jvm.ACC_STATIC, "run", "()V").Code().Ldc(builder.ConstantPool().Utf8("x"))
+		}, "is not loadable"},
+		{"abstract code", func(builder *jvm.ClassBuilder) {
+			builder.Method(jvm.ACC_ABSTRACT, "run", "()V").Code().Insn(jvm.OP_return)
+		}, "abstract or native method cannot have code"},
+		{"missing code", func(builder *jvm.ClassBuilder) {
+			builder.Method(jvm.ACC_STATIC, "run", "()V")
+		}, "missing code"},
+		{"descriptor", func(builder *jvm.ClassBuilder) {
+			builder.Method(jvm.ACC_STATIC, "run", "(V)V")
+		}, "invalid descriptor"},
+		{"code too long", func(builder *jvm.ClassBuilder) {
+			nops(builder.Method(jvm.ACC_STATIC, "run", "()V").Code(), 65536)
+		}, "code length 65536 is out of range"},
+	}
+	for _, c := range cases {
+		var builder = jvm.NewClassBuilder(jvm.ACC_PUBLIC, "com/acme/Generated", "java/lang/Object")
+		c.emit(builder)
+		var _, err = builder.Build()
+		var writeError *jvm.ClassWriteError
+		if !errors.As(err, &writeError) || !strings.Contains(err.Error(), c.message) {
+			ctx.Fatal(c.name, " => unexpected error ", err)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package class_test
+
+import (
+	"errors"
+	"gava/jvm"
+	"strings"
+	"testing"
+)
+
+const metafactoryDescriptor = "(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/invoke/MethodType;" +
+	"Ljava/lang/invoke/MethodType;Ljava/lang/invoke/MethodHandle;Ljava/lang/invoke/MethodType;)Ljava/lang/invoke/CallSite;"
+
+// Runnable r = () -> {}; 对应的常量池和引导方法
+func lambdaClass(bootstrapMethods bool) []byte {
+	var cp = newTestConstantPool()
+	var metafactory = cp.methodHandle(6, cp.methodref("java/lang/invoke/LambdaMetafactory", "metafactory", metafactoryDescriptor))
+	var samType = cp.methodType("()V")
+	var implementation = cp.methodHandle(6, cp.methodref("com/acme/Lambda", "lambda$main$0", "()V"))
+	cp.dynamic(18, 0, "run", "()Ljava/lang/Runnable;")
+	cp.utf8("after") // 方法句柄之后的常量需要保持对齐
+	if !bootstrapMethods {
+		return cp.build("com/acme/Lambda", nil, nil)
+	}
+	return cp.build("com/acme/Lambda", nil, nil, cp.bootstrapMethods([]uint16{metafactory, samType, implementation, samType}))
+}
+
+func TestParseBootstrapMethods(ctx *testing.T) {
+	var javaClass = mustParse(ctx, lambdaClass(true))
+	var constants = javaClass.ConstantPool()
+	var invokeDynamic *jvm.ConstantInvokeDynamicInfo
+	var after bool
+	for idx := 1; idx < constants.Size(); idx++ {
+		switch info := constants.Information(uint16(idx)).(type) {
+		case *jvm.ConstantInvokeDynamicInfo:
+			invokeDynamic = info
+		case *jvm.ConstantUtf8Info:
+			after = after || info.String() == "after"
+		}
+	}
+	if invokeDynamic == nil || !after {
+		ctx.Fatal("unexpected constant pool => ", invokeDynamic, after)
+	}
+	if name, descriptor := invokeDynamic.NameAndDescriptor(); name != "run" || descriptor != "()Ljava/lang/Runnable;" {
+		ctx.Fatal("unexpected call site => ", name, descriptor)
+	}
+	var bootstrap = invokeDynamic.BootstrapMethod()
+	if bootstrap == nil || bootstrap != javaClass.BootstrapMethods()[0] {
+		ctx.Fatal("invokedynamic not linked to bootstrap method")
+	}
+	var handle = bootstrap.MethodHandle()
+	if class, name, descriptor := handle.Member(); handle.ReferenceKind() != jvm.REF_invokeStatic ||
+		class != "java/lang/invoke/LambdaMetafactory" || name != "metafactory" || descriptor != metafactoryDescriptor {
+		ctx.Fatal("unexpected bootstrap method => ", handle.ReferenceKind(), class, name, descriptor)
+	}
+	var arguments = bootstrap.Arguments()
+	if len(arguments) != 3 {
+		ctx.Fatal("unexpected arguments => ", arguments)
+	}
+	if samType, ok := arguments[0].(*jvm.ConstantMethodTypeInfo); !ok || samType.Descriptor() != "()V" {
+		ctx.Fatal("unexpected sam type => ", arguments[0])
+	}
+	if implementation, ok := arguments[1].(*jvm.ConstantMethodHandleInfo); !ok || implementation.ReferenceKind().String() != "REF_invokeStatic" {
+		ctx.Fatal("unexpected implementation => ", arguments[1])
+	} else if _, name, _ := implementation.Member(); name != "lambda$main$0" {
+		ctx.Fatal("unexpected implementation method => ", name)
+	}
+	// 不属于常量池的方法句柄没有引用的成员
+	var detached = &jvm.ConstantMethodHandleInfo{}
+	if class, name, descriptor := detached.Member(); detached.Reference() != nil || class != "" || name != "" || descriptor != "" {
+		ctx.Fatal("unexpected detached member => ", class, name, descriptor)
+	}
+}
+
+func TestParseInvalidBootstrapMethods(ctx *testing.T) {
+	var _, err = jvm.ParseJavaByteCode(lambdaClass(false))
+	var formatError *jvm.ClassFormatError
+	if !errors.As(err, &formatError) || !strings.Contains(err.Error(), "missing BootstrapMethods attribute") {
+		ctx.Fatal("unexpected error => ", err)
+	}
+
+	// REF_getField 引用了方法
+	var cp = newTestConstantPool()
+	cp.methodHandle(1, cp.methodref("com/acme/Lambda", "run", "()V"))
+	_, err = jvm.ParseJavaByteCode(cp.build("com/acme/Lambda", nil, nil))
+	if !errors.As(err, &formatError) || !strings.Contains(err.Error(), "invalid reference index") {
+		ctx.Fatal("unexpected error => ", err)
+	}
+}
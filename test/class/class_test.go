@@ -1,22 +1,58 @@
 package class_test
 
 import (
-	"fmt"
 	"gava/jvm"
-	"io/ioutil"
 	"testing"
 )
 
-func TestReadClass(ctx *testing.T) {
-	var bytecode, err = ioutil.ReadFile("/Users/huobingnan/tmp/gava-class-test/Hello.class")
-	if err != nil {
-		ctx.Fatal(err)
-	}
-	var javaClass *jvm.JavaClass
-	javaClass, err = jvm.ParseJavaByteCode(bytecode)
+// 使用ClassBuilder生成
+//
+//	public class Hello {
+//	    public static void main(String[] args) { System.out.println("Hello, World"); }
+//	}
+func helloClass(ctx *testing.T) []byte {
+	var builder = jvm.NewClassBuilder(jvm.ACC_PUBLIC|jvm.ACC_SUPER, "Hello", "java/lang/Object").SourceFile("Hello.java")
+	builder.Method(jvm.ACC_PUBLIC, "<init>", "()V").Code().
+		Insn(jvm.OP_aload_0).
+		MethodInsn(jvm.OP_invokespecial, "java/lang/Object", "<init>", "()V", false).
+		Insn(jvm.OP_return).
+		Maxs(1, 1)
+	builder.Method(jvm.ACC_PUBLIC|jvm.ACC_STATIC, "main", "([Ljava/lang/String;)V").Code().
+		FieldInsn(jvm.OP_getstatic, "java/lang/System", "out", "Ljava/io/PrintStream;").
+		Ldc(builder.ConstantPool().String("Hello, World")).
+		MethodInsn(jvm.OP_invokevirtual, "java/io/PrintStream", "println", "(Ljava/lang/String;)V", false).
+		Insn(jvm.OP_return).
+		Maxs(2, 1)
+	var bytecode, err = builder.Bytes()
 	if err != nil {
 		ctx.Fatal(err)
 	}
-	fmt.Printf("%v\n", javaClass)
+	return bytecode
+}
 
+func TestReadClass(ctx *testing.T) {
+	var javaClass = mustParse(ctx, helloClass(ctx))
+	if javaClass.ClassName() != "Hello" || javaClass.SuperClassName() != "java/lang/Object" || javaClass.MajorVersion() != 49 {
+		ctx.Fatal("unexpected class => ", javaClass.ClassName(), javaClass.SuperClassName(), javaClass.MajorVersion())
+	}
+	if !javaClass.AccessFlags().IsPublic() || len(javaClass.Methods()) != 2 || len(javaClass.Fields()) != 0 {
+		ctx.Fatal("unexpected members => ", javaClass.Methods(), javaClass.Fields())
+	}
+	var main = javaClass.FindMethod("main", "([Ljava/lang/String;)V")
+	if main == nil || !main.MethodAccessFlags().IsStatic() {
+		ctx.Fatal("missing main method")
+	}
+	// getstatic #a; ldc #b; invokevirtual #c; return
+	var code = main.CodeAttribute().Code()
+	if len(code) != 9 || jvm.Opcode(code[0]) != jvm.OP_getstatic || jvm.Opcode(code[3]) != jvm.OP_ldc ||
+		jvm.Opcode(code[5]) != jvm.OP_invokevirtual || jvm.Opcode(code[8]) != jvm.OP_return {
+		ctx.Fatalf("unexpected code => % x", code)
+	}
+	var cp = javaClass.ConstantPool()
+	if str, ok := cp.Information(uint16(code[4])).(*jvm.ConstantStringInfo); !ok || str.String() != "Hello, World" {
+		ctx.Fatal("unexpected ldc constant => ", cp.Information(uint16(code[4])))
+	}
+	if slots, err := main.LocalSlots(); err != nil || slots != 1 {
+		ctx.Fatal("unexpected local slots => ", slots, err)
+	}
 }
@@ -0,0 +1,109 @@
+package class_test
+
+import (
+	"errors"
+	"gava/jvm"
+	"strings"
+	"testing"
+)
+
+func TestParseMethodDescriptor(ctx *testing.T) {
+	var descriptor, err = jvm.ParseMethodDescriptor("(IJ[Ljava/lang/String;D[[Z)V")
+	if err != nil {
+		ctx.Fatal(err)
+	}
+	if len(descriptor.Parameters) != 5 || descriptor.Return.Tag != 'V' {
+		ctx.Fatal("unexpected descriptor => ", descriptor)
+	}
+	var array = descriptor.Parameters[2]
+	if !array.IsReference() || array.Tag != '[' || array.Component.ClassName != "java/lang/String" {
+		ctx.Fatal("unexpected array parameter => ", array)
+	}
+	if descriptor.String() != "void (int, long, java.lang.String[], double, boolean[][])" {
+		ctx.Fatal("unexpected string => ", descriptor.String())
+	}
+	if descriptor.Descriptor() != "(IJ[Ljava/lang/String;D[[Z)V" {
+		ctx.Fatal("unexpected descriptor => ", descriptor.Descriptor())
+	}
+	// int 1 + long 2 + String[] 1 + double 2 + boolean[][] 1
+	if descriptor.ParameterSlots() != 7 {
+		ctx.Fatal("unexpected parameter slots => ", descriptor.ParameterSlots())
+	}
+	if descriptor.ArgumentSlots(jvm.MethodAccessFlags(jvm.ACC_PUBLIC|jvm.ACC_STATIC)) != 7 ||
+		descriptor.ArgumentSlots(jvm.MethodAccessFlags(jvm.ACC_PUBLIC)) != 8 {
+		ctx.Fatal("unexpected argument slots")
+	}
+	if jvm.HasReceiverSlot(jvm.MethodAccessFlags(jvm.ACC_STATIC)) || !jvm.HasReceiverSlot(0) {
+		ctx.Fatal("unexpected receiver slot")
+	}
+
+	var field *jvm.DescriptorType
+	if field, err = jvm.ParseFieldDescriptor("[[J"); err != nil {
+		ctx.Fatal(err)
+	}
+	if field.String() != "long[][]" || field.Slots() != 1 || field.Component.Component.Slots() != 2 {
+		ctx.Fatal("unexpected field descriptor => ", field)
+	}
+}
+
+func TestParseInvalidDescriptor(ctx *testing.T) {
+	var cases = []struct {
+		descriptor string
+		method     bool
+		offset     int
+		message    string
+	}{
+		{"(I", true, 2, "unexpected end"},
+		{"IV", true, 0, "expect '('"},
+		{"(V)V", true, 1, "unexpected 'V'"},
+		{"()", true, 2, "unexpected end"},
+		{"()VV", true, 3, "unexpected trailing characters"},
+		{"(Ljava/lang/String)V", true, 20, "expect ';'"}, // ) 是类名中的合法字符
+		{"Ljava//String;", false, 6, "expect identifier"},
+		{"Ljava.lang.String;", false, 5, "expect ';'"},
+		{"L;", false, 1, "expect identifier"},
+		{"V", false, 0, "unexpected 'V'"},
+		{strings.Repeat("[", 256) + "I", false, 0, "more than 255 dimensions"},
+		{"(" + strings.Repeat("J", 128) + ")V", true, 129, "more than 255 slots"},
+	}
+	for _, c := range cases {
+		var err error
+		if c.method {
+			_, err = jvm.ParseMethodDescriptor(c.descriptor)
+		} else {
+			_, err = jvm.ParseFieldDescriptor(c.descriptor)
+		}
+		var formatError *jvm.DescriptorFormatError
+		if !errors.As(err, &formatError) {
+			ctx.Fatal(c.descriptor, " => expect DescriptorFormatError ", err)
+		}
+		if formatError.Descriptor() != c.descriptor || formatError.Offset() != c.offset || !strings.Contains(err.Error(), c.message) {
+			ctx.Fatal(c.descriptor, " => unexpected error ", formatError.Offset(), " ", err)
+		}
+	}
+}
+
+func TestMemberLocalSlots(ctx *testing.T) {
+	var javaClass = mustParse(ctx, shapeClass())
+	var run, area = javaClass.FindMethod("run", "()V"), javaClass.FindMethod("area", "()D")
+	// run 有Code属性，max_locals为1；area 是抽象方法，只有this
+	for _, method := range []*jvm.MemberInformation{run, area} {
+		var slots, err = method.LocalSlots()
+		if err != nil || slots != 1 {
+			ctx.Fatal(method.Name(), " => unexpected local slots ", slots, err)
+		}
+	}
+	var field, err = javaClass.FindField("count", "I").FieldDescriptor()
+	if err != nil || field.Tag != 'I' {
+		ctx.Fatal("unexpected field descriptor => ", field, err)
+	}
+
+	// 实例方法的this和两个int参数需要3个槽位，max_locals只有2
+	var cp = newTestConstantPool()
+	javaClass = mustParse(ctx, cp.build("com/acme/Broken", nil, [][]byte{
+		cp.member(0x0001, "add", "(II)I", cp.code(2, 2, []byte{0x03, 0xAC})),
+	}))
+	if _, err = javaClass.FindMethod("add", "(II)I").LocalSlots(); err == nil || !strings.Contains(err.Error(), "max_locals 2") {
+		ctx.Fatal("expect max_locals error => ", err)
+	}
+}
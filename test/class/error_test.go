@@ -0,0 +1,83 @@
+package class_test
+
+import (
+	"errors"
+	"gava/jvm"
+	"strings"
+	"testing"
+)
+
+func TestParseMinimalClass(ctx *testing.T) {
+	var _, err = jvm.ParseJavaByteCode(minimalClass(52))
+	if err != nil {
+		ctx.Fatal(err)
+	}
+}
+
+func TestParseBadMagic(ctx *testing.T) {
+	var bytecode = minimalClass(52)
+	bytecode[0] = 0xCB
+	var _, err = jvm.ParseJavaByteCode(bytecode)
+	var formatError *jvm.ClassFormatError
+	if !errors.As(err, &formatError) || formatError.Offset() != 0 || formatError.Structure() != "class file header" {
+		ctx.Fatal("unexpected error => ", err)
+	}
+}
+
+func TestParseUnsupportedVersion(ctx *testing.T) {
+	var _, err = jvm.ParseJavaByteCode(minimalClass(99))
+	var versionError *jvm.UnsupportedClassVersionError
+	if !errors.As(err, &versionError) || versionError.MajorVersion() != 99 || versionError.Offset() != 4 {
+		ctx.Fatal("unexpected error => ", err)
+	}
+}
+
+func TestParseUnknownConstantTag(ctx *testing.T) {
+	var bytecode = minimalClass(52)
+	bytecode[10] = 21 // 第一个常量的tag
+	var _, err = jvm.ParseJavaByteCode(bytecode)
+	var formatError *jvm.ClassFormatError
+	if !errors.As(err, &formatError) {
+		ctx.Fatal("unexpected error => ", err)
+	}
+	if err.Error() != "constant pool entry #1: unknown tag 21 at offset 0xa" {
+		ctx.Fatal("unexpected message => ", err)
+	}
+}
+
+func TestParseTruncatedClass(ctx *testing.T) {
+	var bytecode = minimalClass(52)
+	// 任意位置截断都应返回截断错误，而不是panic
+	for size := 0; size < len(bytecode); size++ {
+		var _, err = jvm.ParseJavaByteCode(bytecode[:size])
+		var truncated *jvm.ClassTruncatedError
+		if !errors.As(err, &truncated) || truncated.Offset() > size {
+			ctx.Fatal(size, " => unexpected error ", err)
+		}
+	}
+	var _, err = jvm.ParseJavaByteCode(bytecode[:14])
+	if err == nil || !strings.HasPrefix(err.Error(), "constant pool entry #1: truncated class file") {
+		ctx.Fatal("unexpected message => ", err)
+	}
+}
+
+func TestParseExtraBytes(ctx *testing.T) {
+	var bytecode = append(minimalClass(52), 0)
+	var _, err = jvm.ParseJavaByteCode(bytecode)
+	var formatError *jvm.ClassFormatError
+	if !errors.As(err, &formatError) || formatError.Offset() != len(bytecode)-1 {
+		ctx.Fatal("unexpected error => ", err)
+	}
+}
+
+func TestParseMalformedUtf8(ctx *testing.T) {
+	var bytecode = classWithConstants(0, 52, 1, func(class *classBytes) {
+		class.u1(1).u2(2).u1(0xE4).u1(0xB8) // #5 三字节编码缺少最后一个字节
+	})
+	var _, err = jvm.ParseJavaByteCode(bytecode)
+	var formatError *jvm.ClassFormatError
+	if !errors.As(err, &formatError) || !strings.Contains(err.Error(), "malformed input") ||
+		formatError.Structure() != "constant pool entry #5" {
+		ctx.Fatal("unexpected error => ", err)
+	}
+}
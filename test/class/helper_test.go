@@ -0,0 +1,238 @@
+package class_test
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// 手工拼装class文件的字节
+type classBytes struct {
+	bytes.Buffer
+}
+
+func (this *classBytes) u1(v uint8) *classBytes {
+	this.WriteByte(v)
+	return this
+}
+
+func (this *classBytes) u2(v uint16) *classBytes {
+	binary.Write(&this.Buffer, binary.BigEndian, v)
+	return this
+}
+
+func (this *classBytes) u4(v uint32) *classBytes {
+	binary.Write(&this.Buffer, binary.BigEndian, v)
+	return this
+}
+
+func (this *classBytes) utf8(s string) *classBytes {
+	this.u1(1).u2(uint16(len(s)))
+	this.WriteString(s)
+	return this
+}
+
+// 最小的class文件：public class Hello extends java.lang.Object，没有字段、方法和属性
+func minimalClass(major uint16) []byte {
+	return classWithConstants(0, major, 0, nil)
+}
+
+// 在最小的class文件的常量池末尾（#5开始）追加count个常量
+func classWithConstants(minor uint16, major uint16, count uint16, constants func(*classBytes)) []byte {
+	var class = new(classBytes)
+	class.u4(0xCAFEBABE).u2(minor).u2(major)
+	class.u2(5 + count)                        // 常量池大小
+	class.utf8("Hello").u1(7).u2(1)            // #1 #2
+	class.utf8("java/lang/Object").u1(7).u2(3) // #3 #4
+	if constants != nil {
+		constants(class)
+	}
+	class.u2(0x0021).u2(2).u2(4)  // 访问标识符、本类、超类
+	class.u2(0).u2(0).u2(0).u2(0) // 接口、字段、方法、属性
+	return class.Bytes()
+}
+
+//	public abstract class com.acme.Shape implements Runnable {
+//	    private static final int count;
+//	    public void run() { return; }
+//	    public abstract double area();
+//	}
+func shapeClass() []byte {
+	var class = new(classBytes)
+	class.u4(0xCAFEBABE).u2(0).u2(61)
+	class.u2(14)                                               // 常量池大小
+	class.utf8("com/acme/Shape").u1(7).u2(1)                   // #1 #2
+	class.utf8("java/lang/Object").u1(7).u2(3)                 // #3 #4
+	class.utf8("java/lang/Runnable").u1(7).u2(5)               // #5 #6
+	class.utf8("count").utf8("I")                              // #7 #8
+	class.utf8("run").utf8("()V").utf8("Code")                 // #9 #10 #11
+	class.utf8("area").utf8("()D")                             // #12 #13
+	class.u2(0x0421).u2(2).u2(4)                               // public abstract super
+	class.u2(1).u2(6)                                          // 接口
+	class.u2(1).u2(0x001A).u2(7).u2(8).u2(0)                   // 字段 private static final
+	class.u2(2)                                                // 方法
+	class.u2(0x0001).u2(9).u2(10).u2(1)                        // run
+	class.u2(11).u4(13).u2(0).u2(1).u4(1).u1(0xB1).u2(0).u2(0) // Code: return
+	class.u2(0x0401).u2(12).u2(13).u2(0)                       // area
+	class.u2(0)                                                // 属性
+	return class.Bytes()
+}
+
+// 测试用常量池，按照添加顺序分配索引，Utf8和Class常量去重
+type testConstantPool struct {
+	data    classBytes
+	count   uint16
+	indexes map[string]uint16
+}
+
+func newTestConstantPool() *testConstantPool {
+	return &testConstantPool{count: 1, indexes: make(map[string]uint16)}
+}
+
+// 添加一个常量，write写入tag和内容
+func (this *testConstantPool) add(write func(*classBytes)) uint16 {
+	write(&this.data)
+	this.count++
+	return this.count - 1
+}
+
+func (this *testConstantPool) utf8(s string) uint16 {
+	if idx, ok := this.indexes["utf8:"+s]; ok {
+		return idx
+	}
+	var idx = this.add(func(b *classBytes) { b.utf8(s) })
+	this.indexes["utf8:"+s] = idx
+	return idx
+}
+
+func (this *testConstantPool) class(name string) uint16 {
+	if idx, ok := this.indexes["class:"+name]; ok {
+		return idx
+	}
+	var nameIndex = this.utf8(name)
+	var idx = this.add(func(b *classBytes) { b.u1(7).u2(nameIndex) })
+	this.indexes["class:"+name] = idx
+	return idx
+}
+
+func (this *testConstantPool) nameAndType(name string, descriptor string) uint16 {
+	var nameIndex, descriptorIndex = this.utf8(name), this.utf8(descriptor)
+	return this.add(func(b *classBytes) { b.u1(12).u2(nameIndex).u2(descriptorIndex) })
+}
+
+func (this *testConstantPool) methodref(class string, name string, descriptor string) uint16 {
+	var classIndex, nameAndType = this.class(class), this.nameAndType(name, descriptor)
+	return this.add(func(b *classBytes) { b.u1(10).u2(classIndex).u2(nameAndType) })
+}
+
+func (this *testConstantPool) methodHandle(kind uint8, reference uint16) uint16 {
+	return this.add(func(b *classBytes) { b.u1(15).u1(kind).u2(reference) })
+}
+
+func (this *testConstantPool) methodType(descriptor string) uint16 {
+	var descriptorIndex = this.utf8(descriptor)
+	return this.add(func(b *classBytes) { b.u1(16).u2(descriptorIndex) })
+}
+
+// CONSTANT_InvokeDynamic（tag 18）或 CONSTANT_Dynamic（tag 17）
+func (this *testConstantPool) dynamic(tag uint8, bootstrap uint16, name string, descriptor string) uint16 {
+	var nameAndType = this.nameAndType(name, descriptor)
+	return this.add(func(b *classBytes) { b.u1(tag).u2(bootstrap).u2(nameAndType) })
+}
+
+// BootstrapMethods属性，每个引导方法为 方法句柄索引 + 静态参数索引
+func (this *testConstantPool) bootstrapMethods(methods ...[]uint16) []byte {
+	var b = new(classBytes)
+	b.u2(uint16(len(methods)))
+	for _, method := range methods {
+		b.u2(method[0]).u2(uint16(len(method) - 1))
+		for _, argument := range method[1:] {
+			b.u2(argument)
+		}
+	}
+	return this.attribute("BootstrapMethods", b.Bytes())
+}
+
+func (this *testConstantPool) module(name string) uint16 {
+	var nameIndex = this.utf8(name)
+	return this.add(func(b *classBytes) { b.u1(19).u2(nameIndex) })
+}
+
+func (this *testConstantPool) pkg(name string) uint16 {
+	var nameIndex = this.utf8(name)
+	return this.add(func(b *classBytes) { b.u1(20).u2(nameIndex) })
+}
+
+func (this *testConstantPool) integer(v int32) uint16 {
+	return this.add(func(b *classBytes) { b.u1(3).u4(uint32(v)) })
+}
+
+// long常量占用两个索引
+func (this *testConstantPool) long(v int64) uint16 {
+	var idx = this.add(func(b *classBytes) { b.u1(5).u4(uint32(v >> 32)).u4(uint32(v)) })
+	this.count++
+	return idx
+}
+
+// 属性：名称索引、长度、内容
+func (this *testConstantPool) attribute(name string, body []byte) []byte {
+	var b = new(classBytes)
+	b.u2(this.utf8(name)).u4(uint32(len(body)))
+	b.Write(body)
+	return b.Bytes()
+}
+
+// 字段或方法
+func (this *testConstantPool) member(flags uint16, name string, descriptor string, attributes ...[]byte) []byte {
+	var b = new(classBytes)
+	b.u2(flags).u2(this.utf8(name)).u2(this.utf8(descriptor)).u2(uint16(len(attributes)))
+	for _, attribute := range attributes {
+		b.Write(attribute)
+	}
+	return b.Bytes()
+}
+
+// Code属性
+func (this *testConstantPool) code(maxStack uint16, maxLocals uint16, code []byte, attributes ...[]byte) []byte {
+	var b = new(classBytes)
+	b.u2(maxStack).u2(maxLocals).u4(uint32(len(code)))
+	b.Write(code)
+	b.u2(0).u2(uint16(len(attributes)))
+	for _, attribute := range attributes {
+		b.Write(attribute)
+	}
+	return this.attribute("Code", b.Bytes())
+}
+
+// 以java/lang/Object为超类的class文件
+func (this *testConstantPool) build(name string, fields [][]byte, methods [][]byte, attributes ...[]byte) []byte {
+	var thisClass, superClass = this.class(name), this.class("java/lang/Object")
+	var class = new(classBytes)
+	class.u4(0xCAFEBABE).u2(0).u2(61).u2(this.count)
+	class.Write(this.data.Bytes())
+	class.u2(0x0021).u2(thisClass).u2(superClass).u2(0)
+	for _, members := range [][][]byte{fields, methods} {
+		class.u2(uint16(len(members)))
+		for _, member := range members {
+			class.Write(member)
+		}
+	}
+	class.u2(uint16(len(attributes)))
+	for _, attribute := range attributes {
+		class.Write(attribute)
+	}
+	return class.Bytes()
+}
+
+// module-info.class，访问标识符为ACC_MODULE，没有超类、字段和方法
+func (this *testConstantPool) buildModule(attributes ...[]byte) []byte {
+	var thisClass = this.class("module-info")
+	var class = new(classBytes)
+	class.u4(0xCAFEBABE).u2(0).u2(61).u2(this.count)
+	class.Write(this.data.Bytes())
+	class.u2(0x8000).u2(thisClass).u2(0).u2(0).u2(0).u2(0)
+	class.u2(uint16(len(attributes)))
+	for _, attribute := range attributes {
+		class.Write(attribute)
+	}
+	return class.Bytes()
+}
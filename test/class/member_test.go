@@ -0,0 +1,78 @@
+package class_test
+
+import (
+	"errors"
+	"gava/jvm"
+	"testing"
+)
+
+func TestJavaClassNames(ctx *testing.T) {
+	var javaClass, err = jvm.ParseJavaByteCode(shapeClass())
+	if err != nil {
+		ctx.Fatal(err)
+	}
+	if javaClass.ClassName() != "com/acme/Shape" || javaClass.SuperClassName() != "java/lang/Object" {
+		ctx.Fatal("unexpected names => ", javaClass.ClassName(), " ", javaClass.SuperClassName())
+	}
+	var interfaces = javaClass.InterfaceNames()
+	if len(interfaces) != 1 || interfaces[0] != "java/lang/Runnable" {
+		ctx.Fatal("unexpected interfaces => ", interfaces)
+	}
+	var flags = javaClass.AccessFlags()
+	if !flags.IsPublic() || !flags.IsAbstract() || !flags.IsSuper() || flags.IsInterface() || flags.IsEnum() {
+		ctx.Fatal("unexpected class flags => ", flags)
+	}
+}
+
+func TestJavaClassMembers(ctx *testing.T) {
+	var javaClass, err = jvm.ParseJavaByteCode(shapeClass())
+	if err != nil {
+		ctx.Fatal(err)
+	}
+	var field = javaClass.FindField("count", "I")
+	if field == nil || field.Name() != "count" || field.Descriptor() != "I" {
+		ctx.Fatal("unexpected field => ", field)
+	}
+	var fieldFlags = field.FieldAccessFlags()
+	if !fieldFlags.IsPrivate() || !fieldFlags.IsStatic() || !fieldFlags.IsFinal() || fieldFlags.IsPublic() {
+		ctx.Fatal("unexpected field flags => ", fieldFlags)
+	}
+
+	var run = javaClass.FindMethod("run", "()V")
+	if run == nil || !run.MethodAccessFlags().IsPublic() || run.MethodAccessFlags().IsAbstract() {
+		ctx.Fatal("unexpected method => ", run)
+	}
+	var code = run.CodeAttribute()
+	if code == nil || code.MaxLocals() != 1 || len(code.Code()) != 1 || code.Code()[0] != 0xB1 {
+		ctx.Fatal("unexpected code => ", code)
+	}
+	var area = javaClass.FindMethod("area", "()D")
+	if area == nil || !area.MethodAccessFlags().IsAbstract() || area.CodeAttribute() != nil {
+		ctx.Fatal("unexpected abstract method => ", area)
+	}
+	if javaClass.FindMethod("run", "()I") != nil || javaClass.FindField("run", "()V") != nil {
+		ctx.Fatal("lookup should match both name and descriptor")
+	}
+}
+
+func TestParseInvalidReferences(ctx *testing.T) {
+	var cases = []struct {
+		name   string
+		patch  func([]byte)
+		expect string
+	}{
+		// #2 CONSTANT_Class 指向 #4（Class常量，而不是Utf8）
+		{"class name", func(b []byte) { b[29] = 4 }, "constant pool entry #2: invalid name index 4 at offset 0x1b"},
+		// 超类指向 #3（Utf8常量，而不是Class）
+		{"super class", func(b []byte) { b[0x7D] = 3 }, "class declaration: invalid class index 3 at offset 0x7c"},
+	}
+	for _, c := range cases {
+		var bytecode = shapeClass()
+		c.patch(bytecode)
+		var _, err = jvm.ParseJavaByteCode(bytecode)
+		var formatError *jvm.ClassFormatError
+		if !errors.As(err, &formatError) || err.Error() != c.expect {
+			ctx.Fatal(c.name, " => unexpected error ", err)
+		}
+	}
+}
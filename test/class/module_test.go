@@ -0,0 +1,117 @@
+package class_test
+
+import (
+	"errors"
+	"gava/jvm"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+//	open module com.acme.app @ 1.0 {
+//	    requires transitive com.acme.lib;
+//	    requires static com.acme.tools;
+//	    requires java.base;
+//	    exports com.acme.api;
+//	    exports com.acme.spi to com.acme.impl, com.acme.test;
+//	    opens com.acme.model to com.acme.orm;
+//	    uses com.acme.spi.Plugin;
+//	    provides com.acme.spi.Plugin with com.acme.internal.DefaultPlugin;
+//	}
+func TestParseModuleInfo(ctx *testing.T) {
+	var cp = newTestConstantPool()
+	var app, lib, tools, base = cp.module("com.acme.app"), cp.module("com.acme.lib"), cp.module("com.acme.tools"), cp.module("java.base")
+	var impl, test, orm = cp.module("com.acme.impl"), cp.module("com.acme.test"), cp.module("com.acme.orm")
+	var api, spi, model, internal = cp.pkg("com/acme/api"), cp.pkg("com/acme/spi"), cp.pkg("com/acme/model"), cp.pkg("com/acme/internal")
+	var plugin, defaultPlugin, main = cp.class("com/acme/spi/Plugin"), cp.class("com/acme/internal/DefaultPlugin"), cp.class("com/acme/api/Main")
+	var version, baseVersion = cp.utf8("1.0"), cp.utf8("17")
+
+	var module = new(classBytes)
+	module.u2(app).u2(0x0020).u2(version)
+	module.u2(3)
+	module.u2(lib).u2(0x0020).u2(0)
+	module.u2(tools).u2(0x0040).u2(0)
+	module.u2(base).u2(0x8000).u2(baseVersion)
+	module.u2(2)
+	module.u2(api).u2(0).u2(0)
+	module.u2(spi).u2(0).u2(2).u2(impl).u2(test)
+	module.u2(1)
+	module.u2(model).u2(0).u2(1).u2(orm)
+	module.u2(1).u2(plugin)
+	module.u2(1).u2(plugin).u2(1).u2(defaultPlugin)
+	var packages = new(classBytes)
+	packages.u2(4).u2(api).u2(spi).u2(model).u2(internal)
+
+	var javaClass = mustParse(ctx, cp.buildModule(
+		cp.attribute("Module", module.Bytes()),
+		cp.attribute("ModulePackages", packages.Bytes()),
+		cp.attribute("ModuleMainClass", new(classBytes).u2(main).Bytes()),
+	))
+	if !javaClass.AccessFlags().IsModule() || javaClass.ClassName() != "module-info" || javaClass.SuperClassName() != "" {
+		ctx.Fatal("unexpected module-info => ", javaClass.ClassName(), javaClass.SuperClassName())
+	}
+	var attribute = javaClass.Module()
+	if attribute == nil || attribute.ModuleName() != "com.acme.app" || attribute.Version() != "1.0" || !attribute.AccessFlags().IsOpen() {
+		ctx.Fatal("unexpected module => ", attribute)
+	}
+
+	var requires = attribute.Requires()
+	if len(requires) != 3 {
+		ctx.Fatal("unexpected requires => ", requires)
+	}
+	if requires[0].Name() != "com.acme.lib" || !requires[0].AccessFlags().IsTransitive() || requires[0].Version() != "" {
+		ctx.Fatal("unexpected requires => ", requires[0].Name())
+	}
+	if requires[1].Name() != "com.acme.tools" || !requires[1].AccessFlags().IsStaticPhase() {
+		ctx.Fatal("unexpected requires => ", requires[1].Name())
+	}
+	if requires[2].Name() != "java.base" || !requires[2].AccessFlags().IsMandated() || requires[2].Version() != "17" {
+		ctx.Fatal("unexpected requires => ", requires[2].Name(), requires[2].Version())
+	}
+
+	var exports = attribute.Exports()
+	if len(exports) != 2 || exports[0].Package() != "com/acme/api" || exports[0].IsQualified() || exports[0].To() != nil {
+		ctx.Fatal("unexpected exports => ", exports)
+	}
+	if !reflect.DeepEqual(exports[1].To(), []string{"com.acme.impl", "com.acme.test"}) {
+		ctx.Fatal("unexpected qualified exports => ", exports[1].To())
+	}
+	var opens = attribute.Opens()
+	if len(opens) != 1 || opens[0].Package() != "com/acme/model" || !reflect.DeepEqual(opens[0].To(), []string{"com.acme.orm"}) {
+		ctx.Fatal("unexpected opens => ", opens)
+	}
+	if !reflect.DeepEqual(attribute.Uses(), []string{"com/acme/spi/Plugin"}) {
+		ctx.Fatal("unexpected uses => ", attribute.Uses())
+	}
+	var provides = attribute.Provides()
+	if len(provides) != 1 || provides[0].Service() != "com/acme/spi/Plugin" ||
+		!reflect.DeepEqual(provides[0].Implementations(), []string{"com/acme/internal/DefaultPlugin"}) {
+		ctx.Fatal("unexpected provides => ", provides)
+	}
+
+	if !reflect.DeepEqual(javaClass.ModulePackages(), []string{"com/acme/api", "com/acme/spi", "com/acme/model", "com/acme/internal"}) {
+		ctx.Fatal("unexpected packages => ", javaClass.ModulePackages())
+	}
+	if javaClass.ModuleMainClass() != "com/acme/api/Main" {
+		ctx.Fatal("unexpected main class => ", javaClass.ModuleMainClass())
+	}
+}
+
+func TestParseModuleInfoRejectsInvalidIndex(ctx *testing.T) {
+	// exports 引用的是Module常量而不是Package常量
+	var cp = newTestConstantPool()
+	var app = cp.module("com.acme.app")
+	var module = new(classBytes)
+	module.u2(app).u2(0).u2(0)
+	module.u2(0)
+	module.u2(1).u2(app).u2(0).u2(0)
+	module.u2(0).u2(0).u2(0)
+	var _, err = jvm.ParseJavaByteCode(cp.buildModule(cp.attribute("Module", module.Bytes())))
+	var formatError *jvm.ClassFormatError
+	if !errors.As(err, &formatError) {
+		ctx.Fatal("expect ClassFormatError => ", err)
+	}
+	if formatError.Structure() != "attribute Module: exports #0" || !strings.Contains(err.Error(), "invalid package index") {
+		ctx.Fatal("unexpected error => ", err)
+	}
+}
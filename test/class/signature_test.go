@@ -0,0 +1,155 @@
+package class_test
+
+import (
+	"bytes"
+	"errors"
+	"gava/jvm"
+	"strings"
+	"testing"
+)
+
+func TestParseFieldSignature(ctx *testing.T) {
+	var cases = []struct {
+		signature string
+		expect    string
+	}{
+		{"Ljava/util/List<Ljava/lang/String;>;", "java.util.List<java.lang.String>"},
+		{"Ljava/util/Map<Ljava/lang/String;+Ljava/lang/Number;>;", "java.util.Map<java.lang.String, ? extends java.lang.Number>"},
+		{"Ljava/util/List<-TT;>;", "java.util.List<? super T>"},
+		{"Ljava/lang/Class<*>;", "java.lang.Class<?>"},
+		{"[[Ljava/util/Optional<[I>;", "java.util.Optional<int[]>[][]"},
+		{"TT;", "T"},
+		{"Ljava/util/Map<TK;TV;>.Entry<TK;TV;>;", "java.util.Map<K, V>.Entry<K, V>"},
+		{"LDefault;", "Default"},
+	}
+	for _, c := range cases {
+		var signature, err = jvm.ParseFieldSignature(c.signature)
+		if err != nil {
+			ctx.Fatal(c.signature, " => ", err)
+		}
+		if signature.String() != c.expect {
+			ctx.Fatal(c.signature, " => unexpected type ", signature)
+		}
+	}
+	var signature, _ = jvm.ParseFieldSignature("Ljava/util/Map<TK;TV;>.Entry<TK;TV;>;")
+	var class = signature.(*jvm.ClassTypeSignature)
+	if class.ClassName() != "java/util/Map$Entry" || class.Package != "java/util/" || len(class.Classes[1].TypeArguments) != 2 {
+		ctx.Fatal("unexpected class type => ", class.ClassName())
+	}
+}
+
+func TestParseClassAndMethodSignature(ctx *testing.T) {
+	var class, err = jvm.ParseClassSignature(
+		"<K::Ljava/lang/Comparable<TK;>;V:Ljava/lang/Number;:Ljava/io/Serializable;>Ljava/util/AbstractMap<TK;TV;>;Ljava/lang/Cloneable;")
+	if err != nil {
+		ctx.Fatal(err)
+	}
+	if len(class.TypeParameters) != 2 || class.TypeParameters[0].ClassBound != nil ||
+		class.TypeParameters[0].String() != "K extends java.lang.Comparable<K>" ||
+		class.TypeParameters[1].String() != "V extends java.lang.Number & java.io.Serializable" {
+		ctx.Fatal("unexpected type parameters => ", class.TypeParameters)
+	}
+	if class.SuperClass.String() != "java.util.AbstractMap<K, V>" || len(class.SuperInterfaces) != 1 {
+		ctx.Fatal("unexpected super types => ", class.SuperClass, class.SuperInterfaces)
+	}
+
+	method, err := jvm.ParseMethodSignature("<T:Ljava/lang/Object;>(ILjava/util/List<TT;>;)TT;^Ljava/io/IOException;^TE;")
+	if err != nil {
+		ctx.Fatal(err)
+	}
+	if len(method.Parameters) != 2 || method.Parameters[0].String() != "int" || method.Result.String() != "T" ||
+		len(method.Throws) != 2 || method.Throws[1].String() != "E" {
+		ctx.Fatal("unexpected method signature => ", method.Parameters, method.Result, method.Throws)
+	}
+	method, err = jvm.ParseMethodSignature("()V")
+	if err != nil || method.Result.String() != "void" || len(method.Parameters) != 0 {
+		ctx.Fatal("unexpected void method => ", err)
+	}
+}
+
+func TestParseInvalidSignature(ctx *testing.T) {
+	for _, signature := range []string{"", "I", "Ljava/util/List<>;", "Ljava/lang/String", "TT;X", "[V"} {
+		var _, err = jvm.ParseFieldSignature(signature)
+		var formatError *jvm.SignatureFormatError
+		if !errors.As(err, &formatError) {
+			ctx.Fatal(signature, " => unexpected error ", err)
+		}
+	}
+	var _, err = jvm.ParseMethodSignature("(I)V^I")
+	if err == nil {
+		ctx.Fatal("expect error for primitive throws")
+	}
+	// 数组维数的限制与描述符一致
+	var formatError *jvm.SignatureFormatError
+	if _, err = jvm.ParseFieldSignature(strings.Repeat("[", 256) + "I"); !errors.As(err, &formatError) ||
+		formatError.Offset() != 0 || !strings.Contains(err.Error(), "more than 255 dimensions") {
+		ctx.Fatal("unexpected error => ", err)
+	}
+}
+
+func TestParseSignatureAttributes(ctx *testing.T) {
+	var cp = newTestConstantPool()
+	var signature = func(s string) []byte { return cp.attribute("Signature", new(classBytes).u2(cp.utf8(s)).Bytes()) }
+	// MethodParameters: name 和匿名的 mandated 参数
+	var parameters = new(classBytes)
+	parameters.u1(2).u2(cp.utf8("name")).u2(0x0010).u2(0).u2(0x8000)
+	var localTypes = new(classBytes)
+	localTypes.u2(1).u2(0).u2(1).u2(cp.utf8("names")).u2(cp.utf8("Ljava/util/List<Ljava/lang/String;>;")).u2(2)
+	var field = cp.member(0x0002, "tags", "Ljava/util/Set;", signature("Ljava/util/Set<Ljava/lang/String;>;"))
+	var method = cp.member(0x0001, "find", "(Ljava/lang/String;I)V",
+		cp.attribute("MethodParameters", parameters.Bytes()),
+		cp.code(1, 3, []byte{0xB1}, cp.attribute("LocalVariableTypeTable", localTypes.Bytes())),
+	)
+	var smap = []byte("SMAP\nHello.jsp\nJSP\n*E\n")
+	var javaClass = mustParse(ctx, cp.build("com/acme/Dto", [][]byte{field}, [][]byte{method},
+		signature("<T:Ljava/lang/Object;>Ljava/lang/Object;"),
+		cp.attribute("SourceDebugExtension", smap),
+	))
+	if javaClass.Signature() != "<T:Ljava/lang/Object;>Ljava/lang/Object;" {
+		ctx.Fatal("unexpected class signature => ", javaClass.Signature())
+	}
+	var tags = javaClass.FindField("tags", "Ljava/util/Set;")
+	if fieldType, err := jvm.ParseFieldSignature(tags.Signature()); err != nil || fieldType.String() != "java.util.Set<java.lang.String>" {
+		ctx.Fatal("unexpected field signature => ", tags.Signature(), err)
+	}
+	var find = javaClass.FindMethod("find", "(Ljava/lang/String;I)V")
+	if find.Signature() != "" {
+		ctx.Fatal("unexpected method signature => ", find.Signature())
+	}
+	var names = find.ParameterNames()
+	if len(names) != 2 || names[0] != "name" || names[1] != "" {
+		ctx.Fatal("unexpected parameter names => ", names)
+	}
+	if !find.Parameters()[0].AccessFlags().IsFinal() || !find.Parameters()[1].AccessFlags().IsMandated() {
+		ctx.Fatal("unexpected parameter flags")
+	}
+	var table = (*find.CodeAttribute().Attributes()[0]).(*jvm.LocalVariableTypeTableAttribute)
+	if entry := table.Entries()[0]; entry.Name() != "names" || entry.Index() != 2 || entry.Signature() != "Ljava/util/List<Ljava/lang/String;>;" {
+		ctx.Fatal("unexpected local variable type => ", entry.Name(), entry.Signature())
+	}
+	for _, attribute := range javaClass.Attributes() {
+		if extension, ok := (*attribute).(*jvm.SourceDebugExtensionAttribute); ok {
+			if value, err := extension.DebugExtensionString(); err != nil || value != string(smap) || !bytes.Equal(extension.DebugExtension(), smap) {
+				ctx.Fatal("unexpected debug extension => ", value, err)
+			}
+		}
+	}
+}
+
+func TestParseBinarySourceDebugExtension(ctx *testing.T) {
+	// debug_extension不要求是合法的MUTF8，0xFF和\x00都应原样保留
+	var cp = newTestConstantPool()
+	var extension = []byte{'S', 'M', 'A', 'P', 0x00, 0xFF, 0xC0}
+	var bytecode = cp.build("com/acme/Binary", nil, nil, cp.attribute("SourceDebugExtension", extension))
+	var javaClass = mustParse(ctx, bytecode)
+	var attribute = (*javaClass.Attributes()[0]).(*jvm.SourceDebugExtensionAttribute)
+	if !bytes.Equal(attribute.DebugExtension(), extension) {
+		ctx.Fatalf("unexpected debug extension => % x", attribute.DebugExtension())
+	}
+	if _, err := attribute.DebugExtensionString(); err == nil {
+		ctx.Fatal("expect malformed mutf8 error")
+	}
+	if written, err := jvm.WriteJavaByteCode(javaClass); err != nil || !bytes.Equal(written, bytecode) {
+		ctx.Fatal("unexpected written bytes => ", err)
+	}
+}
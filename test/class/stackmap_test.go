@@ -0,0 +1,88 @@
+package class_test
+
+import (
+	"errors"
+	"gava/jvm"
+	"strings"
+	"testing"
+)
+
+// 包含一个方法 m()V 的class，方法的Code属性带有给定的StackMapTable
+func stackMapClass(frames func(cp *testConstantPool, b *classBytes)) []byte {
+	var cp = newTestConstantPool()
+	var body = new(classBytes)
+	frames(cp, body)
+	var method = cp.member(0x0001, "m", "()V", cp.code(2, 4, []byte{0xB1}, cp.attribute("StackMapTable", body.Bytes())))
+	return cp.build("Frames", nil, [][]byte{method})
+}
+
+func TestParseStackMapTable(ctx *testing.T) {
+	var bytecode = stackMapClass(func(cp *testConstantPool, b *classBytes) {
+		var str = cp.class("java/lang/String")
+		b.u2(7)
+		b.u1(3)                                       // same
+		b.u1(64 + 2).u1(jvm.ITEM_Integer)             // same_locals_1_stack_item
+		b.u1(247).u2(100).u1(jvm.ITEM_Object).u2(str) // same_locals_1_stack_item_extended
+		b.u1(249).u2(0)                               // chop 2
+		b.u1(251).u2(300)                             // same_extended
+		b.u1(253).u2(1).u1(jvm.ITEM_Long)             // append 2
+		b.u1(jvm.ITEM_Uninitialized).u2(7)
+		b.u1(255).u2(2).u2(2) // full
+		b.u1(jvm.ITEM_UninitializedThis).u1(jvm.ITEM_Null)
+		b.u2(1).u1(jvm.ITEM_Float)
+	})
+	var javaClass, err = jvm.ParseJavaByteCode(bytecode)
+	if err != nil {
+		ctx.Fatal(err)
+	}
+	var code = javaClass.FindMethod("m", "()V").CodeAttribute()
+	var table, ok = (*code.Attributes()[0]).(*jvm.StackMapTableAttribute)
+	if !ok {
+		ctx.Fatal("unexpected attribute => ", *code.Attributes()[0])
+	}
+	var expect = []struct {
+		kind   jvm.StackMapFrameKind
+		offset int
+		locals string
+		stack  string
+	}{
+		{jvm.SAME_FRAME, 3, "", ""},
+		{jvm.SAME_LOCALS_1_STACK_ITEM_FRAME, 6, "", "int"},
+		{jvm.SAME_LOCALS_1_STACK_ITEM_FRAME_EXTENDED, 107, "", "java/lang/String"},
+		{jvm.CHOP_FRAME, 108, "", ""},
+		{jvm.SAME_FRAME_EXTENDED, 409, "", ""},
+		{jvm.APPEND_FRAME, 411, "long uninitialized(7)", ""},
+		{jvm.FULL_FRAME, 414, "uninitializedThis null", "float"},
+	}
+	var frames = table.Entries()
+	if len(frames) != len(expect) {
+		ctx.Fatal("unexpected frames => ", len(frames))
+	}
+	var join = func(infos []*jvm.VerificationTypeInfo) string {
+		var names = make([]string, len(infos))
+		for idx, info := range infos {
+			names[idx] = info.String()
+		}
+		return strings.Join(names, " ")
+	}
+	for idx, e := range expect {
+		var frame = frames[idx]
+		if frame.Kind() != e.kind || frame.Offset() != e.offset || join(frame.Locals()) != e.locals || join(frame.Stack()) != e.stack {
+			ctx.Fatal(idx, " => unexpected frame ", frame.Kind(), " ", frame.Offset(), " [", join(frame.Locals()), "] [", join(frame.Stack()), "]")
+		}
+	}
+	if frames[3].ChoppedLocals() != 2 {
+		ctx.Fatal("unexpected chopped locals => ", frames[3].ChoppedLocals())
+	}
+}
+
+func TestParseReservedFrameType(ctx *testing.T) {
+	var bytecode = stackMapClass(func(cp *testConstantPool, b *classBytes) {
+		b.u2(1).u1(200)
+	})
+	var _, err = jvm.ParseJavaByteCode(bytecode)
+	var formatError *jvm.ClassFormatError
+	if !errors.As(err, &formatError) || formatError.Structure() != "method #0: attribute Code: attribute StackMapTable: frame #0" {
+		ctx.Fatal("unexpected error => ", err)
+	}
+}
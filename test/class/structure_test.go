@@ -0,0 +1,109 @@
+package class_test
+
+import (
+	"gava/jvm"
+	"testing"
+)
+
+// 由class常量索引组成的属性内容
+func classList(cp *testConstantPool, names ...string) []byte {
+	var b = new(classBytes)
+	b.u2(uint16(len(names)))
+	for _, name := range names {
+		b.u2(cp.class(name))
+	}
+	return b.Bytes()
+}
+
+func mustParse(ctx *testing.T, bytecode []byte) *jvm.JavaClass {
+	var javaClass, err = jvm.ParseJavaByteCode(bytecode)
+	if err != nil {
+		ctx.Fatal(err)
+	}
+	return javaClass
+}
+
+func TestParseInnerClassesAndNest(ctx *testing.T) {
+	// 宿主类 Outer，包含静态内部类 Outer$Inner 和匿名类 Outer$1
+	var cp = newTestConstantPool()
+	var inner = new(classBytes)
+	inner.u2(2)
+	inner.u2(cp.class("com/acme/Outer$Inner")).u2(cp.class("com/acme/Outer")).u2(cp.utf8("Inner")).u2(0x000A)
+	inner.u2(cp.class("com/acme/Outer$1")).u2(0).u2(0).u2(0)
+	var outer = mustParse(ctx, cp.build("com/acme/Outer", nil, nil,
+		cp.attribute("InnerClasses", inner.Bytes()),
+		cp.attribute("NestMembers", classList(cp, "com/acme/Outer$Inner", "com/acme/Outer$1")),
+	))
+	var entries = outer.InnerClasses()
+	if len(entries) != 2 {
+		ctx.Fatal("unexpected inner classes => ", entries)
+	}
+	if entries[0].InnerClassName() != "com/acme/Outer$Inner" || entries[0].OuterClassName() != "com/acme/Outer" ||
+		entries[0].InnerName() != "Inner" || !entries[0].AccessFlags().IsPrivate() || !entries[0].AccessFlags().IsStatic() {
+		ctx.Fatal("unexpected inner class => ", entries[0])
+	}
+	if entries[1].OuterClassName() != "" || entries[1].InnerName() != "" {
+		ctx.Fatal("unexpected anonymous class => ", entries[1])
+	}
+	if outer.NestHost() != "com/acme/Outer" || len(outer.NestMembers()) != 2 {
+		ctx.Fatal("unexpected nest => ", outer.NestHost(), outer.NestMembers())
+	}
+
+	// 匿名类 Outer$1，位于 Outer.run()V 中
+	cp = newTestConstantPool()
+	var enclosing = new(classBytes)
+	enclosing.u2(cp.class("com/acme/Outer")).u2(cp.nameAndType("run", "()V"))
+	var anonymous = mustParse(ctx, cp.build("com/acme/Outer$1", nil, nil,
+		cp.attribute("EnclosingMethod", enclosing.Bytes()),
+		cp.attribute("NestHost", new(classBytes).u2(cp.class("com/acme/Outer")).Bytes()),
+	))
+	var method = anonymous.EnclosingMethod()
+	if method == nil || method.ClassName() != "com/acme/Outer" {
+		ctx.Fatal("unexpected enclosing method => ", method)
+	}
+	if name, descriptor := method.MethodNameAndDescriptor(); name != "run" || descriptor != "()V" {
+		ctx.Fatal("unexpected enclosing method => ", name, descriptor)
+	}
+	if anonymous.NestHost() != "com/acme/Outer" || !anonymous.IsNestmateOf(outer) || !outer.IsNestmateOf(anonymous) {
+		ctx.Fatal("expect nestmates => ", anonymous.NestHost())
+	}
+
+	// 声明了宿主但没有被宿主列为成员
+	cp = newTestConstantPool()
+	var stranger = mustParse(ctx, cp.build("com/acme/Stranger", nil, nil,
+		cp.attribute("NestHost", new(classBytes).u2(cp.class("com/acme/Outer")).Bytes())))
+	if stranger.IsNestmateOf(outer) {
+		ctx.Fatal("unexpected nestmate => ", stranger.ClassName())
+	}
+}
+
+func TestParseSealedRecord(ctx *testing.T) {
+	// sealed interface Shape permits Circle, Square
+	var cp = newTestConstantPool()
+	var shape = mustParse(ctx, cp.build("com/acme/Shape", nil, nil,
+		cp.attribute("PermittedSubclasses", classList(cp, "com/acme/Circle", "com/acme/Square"))))
+	if !shape.IsSealed() || len(shape.PermittedSubclasses()) != 2 || shape.PermittedSubclasses()[1] != "com/acme/Square" {
+		ctx.Fatal("unexpected permitted subclasses => ", shape.PermittedSubclasses())
+	}
+
+	// record Point(int x, @Positive int y)
+	cp = newTestConstantPool()
+	var annotation = new(classBytes)
+	annotation.u2(1).u2(cp.utf8("LPositive;")).u2(0)
+	var record = new(classBytes)
+	record.u2(2)
+	record.u2(cp.utf8("x")).u2(cp.utf8("I")).u2(0)
+	record.u2(cp.utf8("y")).u2(cp.utf8("I")).u2(1)
+	record.Write(cp.attribute("RuntimeVisibleAnnotations", annotation.Bytes()))
+	var point = mustParse(ctx, cp.build("com/acme/Point", nil, nil, cp.attribute("Record", record.Bytes())))
+	var components = point.RecordComponents()
+	if len(components) != 2 || components[0].Name() != "x" || components[1].Descriptor() != "I" {
+		ctx.Fatal("unexpected record components => ", components)
+	}
+	if annotations := components[1].Annotations(); len(annotations) != 1 || annotations[0].TypeName() != "LPositive;" {
+		ctx.Fatal("unexpected component annotations => ", annotations)
+	}
+	if point.IsSealed() || point.RecordComponents() == nil || shape.RecordComponents() != nil {
+		ctx.Fatal("unexpected class kinds")
+	}
+}
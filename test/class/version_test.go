@@ -0,0 +1,79 @@
+package class_test
+
+import (
+	"errors"
+	"gava/jvm"
+	"strings"
+	"testing"
+)
+
+func TestParseClassVersions(ctx *testing.T) {
+	var cases = []struct {
+		minor   uint16
+		major   uint16
+		preview bool
+		accept  bool
+	}{
+		{0, 45, false, true},
+		{3, 45, false, true},
+		{0, 52, false, true},
+		{0, 55, false, true}, // Java 11
+		{0, 61, false, true}, // Java 17
+		{0, 65, false, true}, // Java 21
+		{0, 66, false, false},
+		{1, 61, false, false},
+		{0xFFFF, 65, false, false},
+		{0xFFFF, 65, true, true},
+		{0xFFFF, 61, true, false}, // 预览特性只能用于最高版本
+	}
+	for _, c := range cases {
+		var _, err = jvm.ParseJavaByteCodeWithOptions(classWithConstants(c.minor, c.major, 0, nil), jvm.ParseOptions{EnablePreview: c.preview})
+		if c.accept && err != nil {
+			ctx.Fatal(c.major, ".", c.minor, " => ", err)
+		}
+		var versionError *jvm.UnsupportedClassVersionError
+		if !c.accept && !errors.As(err, &versionError) {
+			ctx.Fatal(c.major, ".", c.minor, " => unexpected error ", err)
+		}
+	}
+}
+
+func TestParsePreviewOptions(ctx *testing.T) {
+	// 同一个进程中可以分别使用不同的选项解析
+	var bytecode = classWithConstants(0xFFFF, 65, 0, nil)
+	var _, err = jvm.ParseJavaByteCode(bytecode)
+	if err == nil || !strings.Contains(err.Error(), "try --enable-preview") {
+		ctx.Fatal("expect preview hint => ", err)
+	}
+	if _, err = jvm.ParseJavaByteCodeWithOptions(bytecode, jvm.ParseOptions{EnablePreview: true}); err != nil {
+		ctx.Fatal(err)
+	}
+	_, err = jvm.ParseJavaByteCodeWithOptions(classWithConstants(0xFFFF, 61, 0, nil), jvm.ParseOptions{EnablePreview: true})
+	if err == nil || strings.Contains(err.Error(), "try --enable-preview") {
+		ctx.Fatal("unexpected preview hint => ", err)
+	}
+}
+
+func TestParseJava11ConstantTags(ctx *testing.T) {
+	var cp = newTestConstantPool()
+	var moduleName, packageName = cp.utf8("java.base"), cp.utf8("java/lang")
+	var module = cp.add(func(b *classBytes) { b.u1(19).u2(moduleName) })
+	var pkg = cp.add(func(b *classBytes) { b.u1(20).u2(packageName) })
+	var bootstrap = cp.methodHandle(6, cp.methodref("java/lang/invoke/ConstantBootstraps", "nullConstant",
+		"(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/Class;)Ljava/lang/Object;"))
+	var dynamic = cp.dynamic(17, 0, "NULL", "Ljava/lang/Object;")
+	var javaClass, err = jvm.ParseJavaByteCode(cp.build("Constants", nil, nil, cp.bootstrapMethods([]uint16{bootstrap})))
+	if err != nil {
+		ctx.Fatal(err)
+	}
+	var constants = javaClass.ConstantPool()
+	if info, ok := constants.Information(module).(*jvm.ConstantModuleInfo); !ok || info.Name() != "java.base" {
+		ctx.Fatal("unexpected module constant => ", constants.Information(module))
+	}
+	if info, ok := constants.Information(pkg).(*jvm.ConstantPackageInfo); !ok || info.Name() != "java/lang" {
+		ctx.Fatal("unexpected package constant => ", constants.Information(pkg))
+	}
+	if info, ok := constants.Information(dynamic).(*jvm.ConstantDynamicInfo); !ok || info.BootstrapMethod() == nil {
+		ctx.Fatal("unexpected dynamic constant => ", constants.Information(dynamic))
+	}
+}
@@ -0,0 +1,196 @@
+package class_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"gava/jvm"
+	"strings"
+	"testing"
+)
+
+// 包含各种属性的class，用于测试写出后与原始字节一致
+//
+//	@Deprecated @Entity
+//	public final record com.acme.Point(long x, double y) implements Comparable<Point> {
+//	    public static final long ORIGIN = 0x123456789L;
+//	    public int compareTo(Point other) throws java.io.IOException { ... }
+//	}
+func recordClass() []byte {
+	var cp = newTestConstantPool()
+	// 包含\u0000和补充平面字符的字符串，MUTF8编码
+	var label = cp.add(func(b *classBytes) {
+		b.u1(1).u2(10).u1('p').u1(0xC0).u1(0x80).u1(0xED).u1(0xA0).u1(0xBD).u1(0xED).u1(0xB8).u1(0x80).u1('!')
+	})
+	var origin = cp.long(0x123456789)
+	var half = cp.add(func(b *classBytes) { b.u1(6).u4(0x3FE00000).u4(0) }) // 0.5
+	cp.count++
+	var io, inner = cp.class("java/io/IOException"), cp.class("com/acme/Point$Builder")
+
+	var entity = new(classBytes)
+	entity.u2(cp.utf8("Lcom/acme/Entity;")).u2(3)
+	entity.u2(cp.utf8("label")).u1('s').u2(label)
+	entity.u2(cp.utf8("scale")).u1('D').u2(half)
+	entity.u2(cp.utf8("tags")).u1('[').u2(1).u1('e').u2(cp.utf8("Lcom/acme/Tag;")).u2(cp.utf8("PRIMARY"))
+	var typeAnnotation = new(classBytes)
+	typeAnnotation.u2(2)
+	typeAnnotation.u1(0x10).u2(0).u1(0).u2(cp.utf8("LNonNull;")).u2(0)
+	typeAnnotation.u1(0x40).u2(1).u2(0).u2(4).u2(1).u1(1).u1(3).u1(0).u2(cp.utf8("LNonNull;")).u2(0)
+
+	var lines = new(classBytes)
+	lines.u2(2).u2(0).u2(7).u2(3).u2(8)
+	var locals = new(classBytes)
+	locals.u2(1).u2(0).u2(4).u2(cp.utf8("other")).u2(cp.utf8("Lcom/acme/Point;")).u2(1)
+	var parameters = new(classBytes)
+	parameters.u1(1).u2(cp.utf8("other")).u2(0x0010)
+	var components = new(classBytes)
+	components.u2(2)
+	components.u2(cp.utf8("x")).u2(cp.utf8("J")).u2(0)
+	components.u2(cp.utf8("y")).u2(cp.utf8("D")).u2(1).Write(cp.attribute("Signature", new(classBytes).u2(cp.utf8("D")).Bytes()))
+	var innerClasses = new(classBytes)
+	innerClasses.u2(1).u2(inner).u2(cp.class("com/acme/Point")).u2(cp.utf8("Builder")).u2(0x0009)
+
+	var field = cp.member(0x0019, "ORIGIN", "J", cp.attribute("ConstantValue", new(classBytes).u2(origin).Bytes()))
+	var method = cp.member(0x0001, "compareTo", "(Lcom/acme/Point;)I",
+		cp.code(2, 2, []byte{0x03, 0xAC, 0x00, 0x00},
+			cp.attribute("LineNumberTable", lines.Bytes()),
+			cp.attribute("LocalVariableTable", locals.Bytes()),
+			cp.attribute("RuntimeVisibleTypeAnnotations", typeAnnotation.Bytes()),
+		),
+		cp.attribute("Exceptions", new(classBytes).u2(1).u2(io).Bytes()),
+		cp.attribute("MethodParameters", parameters.Bytes()),
+		cp.attribute("Synthetic", nil),
+	)
+	return cp.build("com/acme/Point", [][]byte{field}, [][]byte{method},
+		cp.attribute("SourceFile", new(classBytes).u2(cp.utf8("Point.java")).Bytes()),
+		cp.attribute("Deprecated", nil),
+		cp.attribute("RuntimeVisibleAnnotations", annotationsBody(entity.Bytes())),
+		cp.attribute("Record", components.Bytes()),
+		cp.attribute("InnerClasses", innerClasses.Bytes()),
+		cp.attribute("NestMembers", new(classBytes).u2(1).u2(inner).Bytes()),
+		cp.attribute("SourceDebugExtension", []byte("SMAP\nPoint.kt\n")),
+		cp.attribute("com.acme.Custom", []byte{0xFF, 0x00, 0xCA, 0xFE}), // 未识别的属性
+	)
+}
+
+func TestWriteRoundTrip(ctx *testing.T) {
+	var module = newTestConstantPool()
+	var moduleBody = new(classBytes)
+	moduleBody.u2(module.module("com.acme.app")).u2(0).u2(0)
+	moduleBody.u2(1).u2(module.module("java.base")).u2(0x8000).u2(0)
+	moduleBody.u2(1).u2(module.pkg("com/acme/api")).u2(0).u2(1).u2(module.module("com.acme.impl"))
+	moduleBody.u2(0).u2(0).u2(0)
+	var frames = stackMapClass(func(cp *testConstantPool, b *classBytes) {
+		b.u2(4)
+		b.u1(64 + 2).u1(jvm.ITEM_Integer)
+		b.u1(249).u2(0)
+		b.u1(252).u2(1).u1(jvm.ITEM_Uninitialized).u2(7)
+		b.u1(255).u2(2).u2(1).u1(jvm.ITEM_Object).u2(cp.class("java/lang/String")).u2(1).u1(jvm.ITEM_Null)
+	})
+
+	var fixtures = map[string][]byte{
+		"shape":   shapeClass(),
+		"lambda":  lambdaClass(true),
+		"record":  recordClass(),
+		"frames":  frames,
+		"module":  module.buildModule(module.attribute("Module", moduleBody.Bytes())),
+		"minimal": minimalClass(52),
+	}
+	for name, bytecode := range fixtures {
+		var javaClass = mustParse(ctx, bytecode)
+		var written, err = jvm.WriteJavaByteCode(javaClass)
+		if err != nil {
+			ctx.Fatal(name, " => ", err)
+		}
+		// 常量池的索引保持不变，因此写出的字节与原始字节完全一致
+		if !bytes.Equal(written, bytecode) {
+			ctx.Fatalf("%s => written bytes differ\nexpect % x\nactual % x", name, bytecode, written)
+		}
+	}
+
+	var javaClass = mustParse(ctx, recordClass())
+	var cp = javaClass.ConstantPool()
+	var utf8, ok = cp.Information(1).(*jvm.ConstantUtf8Info)
+	if !ok || utf8.String() != "p\x00\U0001F600!" {
+		ctx.Fatal("unexpected mutf8 string => ", utf8)
+	}
+	for _, attribute := range javaClass.Attributes() {
+		if unparsed, ok := (*attribute).(*jvm.UnparsedAttribute); ok && unparsed.Name() != "com.acme.Custom" {
+			ctx.Fatal("unexpected unparsed attribute => ", unparsed.Name())
+		}
+	}
+}
+
+func TestConstantPoolBuilder(ctx *testing.T) {
+	var builder = jvm.NewConstantPoolBuilder()
+	var name = builder.Utf8("com/acme/Hello")
+	var class = builder.Class("com/acme/Hello")
+	if name != 1 || class != 2 || builder.Utf8("com/acme/Hello") != name || builder.Class("com/acme/Hello") != class {
+		ctx.Fatal("unexpected indexes => ", name, class)
+	}
+	// long占用两个索引，之后的常量从 n+2 开始
+	var long = builder.Long(42)
+	var next = builder.Utf8("next")
+	if long != 3 || next != 5 || builder.Long(42) != long || builder.Double(42) == long {
+		ctx.Fatal("unexpected long indexes => ", long, next)
+	}
+	var object = builder.Class("java/lang/Object")
+	var methodref = builder.Methodref("java/lang/Object", "<init>", "()V")
+	if builder.Methodref("java/lang/Object", "<init>", "()V") != methodref || builder.Fieldref("java/lang/Object", "<init>", "()V") == methodref {
+		ctx.Fatal("unexpected memberref index => ", methodref)
+	}
+	builder.MethodHandle(jvm.REF_invokeSpecial, methodref)
+	builder.String("\x00é中\U0001F600")
+
+	var classFile = new(classBytes)
+	classFile.u4(0xCAFEBABE).u2(0).u2(61).u2(builder.Count())
+	classFile.Write(builder.Bytes())
+	classFile.u2(0x0021).u2(class).u2(object).u2(0).u2(0).u2(0).u2(0)
+	var javaClass = mustParse(ctx, classFile.Bytes())
+	var cp = javaClass.ConstantPool()
+	if javaClass.ClassName() != "com/acme/Hello" || cp.Information(4) != nil {
+		ctx.Fatal("unexpected constant pool => ", javaClass.ClassName(), cp.Information(4))
+	}
+	var str = cp.Information(uint16(cp.Size() - 1)).(*jvm.ConstantStringInfo)
+	if str.String() != "\x00é中\U0001F600" {
+		ctx.Fatalf("unexpected string => %q", str.String())
+	}
+	var written, err = jvm.WriteJavaByteCode(javaClass)
+	if err != nil || !bytes.Equal(written, classFile.Bytes()) {
+		ctx.Fatal("unexpected written bytes => ", err)
+	}
+}
+
+func TestConstantPoolBuilderMUtf8(ctx *testing.T) {
+	var builder = jvm.NewConstantPoolBuilder()
+	builder.Utf8("a\x00\U0001F600")
+	// \u0000 => C0 80，U+1F600 => 代理项 D83D DE00，各占三个字节
+	var expect = []byte{1, 0, 9, 'a', 0xC0, 0x80, 0xED, 0xA0, 0xBD, 0xED, 0xB8, 0x80}
+	if !bytes.Equal(builder.Bytes(), expect) {
+		ctx.Fatalf("unexpected mutf8 => % x", builder.Bytes())
+	}
+}
+
+func TestConstantPoolBuilderOverflow(ctx *testing.T) {
+	var builder = jvm.NewConstantPoolBuilder()
+	for i := 1; i < 65534; i++ {
+		builder.Utf8(fmt.Sprint(i))
+	}
+	if builder.Err() != nil || builder.Count() != 65534 {
+		ctx.Fatal("unexpected count => ", builder.Count(), builder.Err())
+	}
+	// 只剩下一个索引，long需要两个
+	if builder.Long(1) != 0 || builder.Err() == nil {
+		ctx.Fatal("expect overflow error")
+	}
+	var writeError *jvm.ClassWriteError
+	if !errors.As(builder.Err(), &writeError) || !strings.Contains(writeError.Error(), "constant pool overflow") ||
+		writeError.Structure() != "constant pool entry #65534" {
+		ctx.Fatal("unexpected error => ", builder.Err())
+	}
+
+	builder = jvm.NewConstantPoolBuilder()
+	if builder.Utf8(strings.Repeat("中", 21846)) != 0 || !strings.Contains(builder.Err().Error(), "utf8 constant is too long: 65538 bytes") {
+		ctx.Fatal("expect too long error => ", builder.Err())
+	}
+}
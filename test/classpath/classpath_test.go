@@ -1,11 +1,90 @@
 package classpath
 
 import (
+	"archive/zip"
+	"bytes"
+	"errors"
 	"gava/jvm"
+	"path/filepath"
 	"testing"
+	"testing/fstest"
 )
 
 func TestCompositeClassEntry(ctx *testing.T) {
-	var _ = jvm.NewClassEntry("/Users/huobingnan/env/zulu-11.jdk/Contents/Home/lib/jrt-fs.jar:/usr/local:/usr/bin")
+	var app = jvm.NewFSClassEntry(fstest.MapFS{
+		"com/acme/Main.class":   {Data: []byte("main")},
+		"com/acme/Shared.class": {Data: []byte("app")},
+	}, "app")
+	var lib = jvm.NewFSClassEntry(fstest.MapFS{
+		"com/acme/Shared.class": {Data: []byte("lib")},
+		"com/acme/Lib.class":    {Data: []byte("lib")},
+	}, "lib")
+	var entry = jvm.NewCompositeClassEntry(app, lib)
+	var cases = []struct {
+		name   string
+		expect string
+		from   jvm.ClassEntry
+	}{
+		{"com/acme/Main", "main", app},
+		{"com.acme.Shared", "app", app},
+		{"com/acme/Lib", "lib", lib},
+	}
+	for _, c := range cases {
+		var data, from, err = entry.ReadClass(c.name)
+		if err != nil {
+			ctx.Fatal(c.name, err)
+		}
+		if string(data) != c.expect || from != c.from {
+			ctx.Fatal(c.name, " => unexpected result ", string(data), " from ", from)
+		}
+	}
+	if _, _, err := entry.ReadClass("com/acme/Missing"); !jvm.IsClassNotFound(err) {
+		ctx.Fatal("expect class not found, got => ", err)
+	}
+}
+
+func TestFSClassEntryFromZipReader(ctx *testing.T) {
+	var buffer bytes.Buffer
+	var writer = zip.NewWriter(&buffer)
+	var w, _ = writer.Create("com/acme/Zipped.class")
+	w.Write([]byte("zipped"))
+	writer.Close()
+	var reader, err = zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		ctx.Fatal(err)
+	}
+
+	var classpath = mustClasspath(ctx, makeJre(ctx), ctx.TempDir())
+	classpath.AddUserClassEntry(jvm.NewFSClassEntry(reader, "memory.jar"))
+	var data, from, readErr = classpath.ReadClass("com/acme/Zipped")
+	if readErr != nil {
+		ctx.Fatal(readErr)
+	}
+	if string(data) != "zipped" || from.String() != "memory.jar" {
+		ctx.Fatal("unexpected result => ", string(data), " from ", from)
+	}
+}
 
+func TestCompressedClassEntryClose(ctx *testing.T) {
+	var jar = filepath.Join(ctx.TempDir(), "lib.jar")
+	writeJar(ctx, jar, jarFile{name: "com/acme/Lib.class", data: []byte("lib"), method: zip.Deflate})
+	var opened = mustClassEntry(ctx, jar).(*jvm.CompressedClassEntry)
+	if _, _, err := opened.ReadClass("com/acme/Lib"); err != nil {
+		ctx.Fatal(err)
+	}
+	// 打开过和没有打开过的压缩包关闭后都不能再读取
+	var unopened = mustClassEntry(ctx, jar).(*jvm.CompressedClassEntry)
+	for _, entry := range []*jvm.CompressedClassEntry{opened, unopened} {
+		if err := entry.Close(); err != nil {
+			ctx.Fatal(err)
+		}
+		var _, _, err = entry.ReadClass("com/acme/Lib")
+		var classpathErr *jvm.ClasspathError
+		if !errors.As(err, &classpathErr) || classpathErr.Kind() != jvm.CLASSPATH_CLOSED {
+			ctx.Fatal("expect entry closed, got => ", err)
+		}
+		if _, err = entry.ListClasses(); !errors.As(err, &classpathErr) || classpathErr.Kind() != jvm.CLASSPATH_CLOSED {
+			ctx.Fatal("expect entry closed, got => ", err)
+		}
+	}
 }
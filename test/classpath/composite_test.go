@@ -0,0 +1,75 @@
+package classpath
+
+import (
+	"archive/zip"
+	"bytes"
+	"gava/jvm"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 在dir下写入一个class文件
+func writeClass(ctx *testing.T, dir string, name string, data []byte) {
+	var path = filepath.Join(dir, filepath.FromSlash(name)+".class")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		ctx.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		ctx.Fatal(err)
+	}
+}
+
+func TestCompositeClassEntryFirstMatchWins(ctx *testing.T) {
+	var root = ctx.TempDir()
+	var first, second = filepath.Join(root, "first"), filepath.Join(root, "second")
+	var jar = filepath.Join(root, "lib.jar")
+	writeClass(ctx, first, "com/acme/Shared", []byte("first"))
+	writeClass(ctx, second, "com/acme/Shared", []byte("second"))
+	writeClass(ctx, second, "com/acme/Only", []byte("only"))
+	writeJar(ctx, jar, jarFile{name: "com/acme/Lib.class", data: []byte("lib"), method: zip.Deflate})
+
+	var sep = string(os.PathListSeparator)
+	// 空片段需要被跳过
+	var entry = mustClassEntry(ctx, strings.Join([]string{first, "", second, jar}, sep))
+	var cases = []struct {
+		name   string
+		expect string
+		from   string
+	}{
+		{"com/acme/Shared", "first", first},
+		{"com/acme/Only", "only", second},
+		{"com.acme.Lib", "lib", jar},
+	}
+	for _, c := range cases {
+		var data, from, err = entry.ReadClass(c.name)
+		if err != nil {
+			ctx.Fatal(c.name, err)
+		}
+		if !bytes.Equal(data, []byte(c.expect)) {
+			ctx.Fatal(c.name, " => unexpected bytecode ", string(data))
+		}
+		if from.String() != c.from {
+			ctx.Fatal(c.name, " => unexpected entry ", from)
+		}
+	}
+	var _, _, err = entry.ReadClass("com/acme/Missing")
+	if !jvm.IsClassNotFound(err) {
+		ctx.Fatal("expect class not found, got => ", err)
+	}
+}
+
+func TestCompositeClassEntrySingleElement(ctx *testing.T) {
+	var dir = ctx.TempDir()
+	writeClass(ctx, dir, "Main", []byte("main"))
+	var entry = mustClassEntry(ctx, dir+string(os.PathListSeparator))
+	var data, from, err = entry.ReadClass("Main")
+	if err != nil {
+		ctx.Fatal(err)
+	}
+	if string(data) != "main" || from.String() != dir {
+		ctx.Fatal("unexpected result => ", string(data), from)
+	}
+}
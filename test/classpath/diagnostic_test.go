@@ -0,0 +1,72 @@
+package classpath
+
+import (
+	"bytes"
+	"gava/jvm"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClasspathWhich(ctx *testing.T) {
+	var jre = makeJre(ctx)
+	var user = ctx.TempDir()
+	writeClass(ctx, user, "com/acme/Main", []byte("user"))
+	var classpath = mustClasspath(ctx, jre, user)
+	var cases = []struct {
+		name string
+		tier string
+		from string
+	}{
+		{"java/lang/Object", jvm.TIER_BOOTSTRAP, filepath.Join(jre, "lib", "rt.jar")},
+		{"com.acme.Ext", jvm.TIER_EXTENSION, filepath.Join(jre, "lib", "ext", "ext.jar")},
+		{"com/acme/Main", jvm.TIER_USER, user},
+	}
+	for _, c := range cases {
+		var tier, from, err = classpath.Which(c.name)
+		if err != nil {
+			ctx.Fatal(c.name, err)
+		}
+		if tier != c.tier || from.String() != c.from {
+			ctx.Fatal(c.name, " => unexpected result ", tier, " ", from)
+		}
+	}
+	if _, _, err := classpath.Which("com/acme/Missing"); !jvm.IsClassNotFound(err) {
+		ctx.Fatal("expect class not found, got => ", err)
+	}
+
+	var out bytes.Buffer
+	classpath.Describe(&out)
+	for _, expect := range []string{"bootstrap classpath (2 entries):", "extension classpath (1 entries):", "user classpath (1 entries):", user} {
+		if !strings.Contains(out.String(), expect) {
+			ctx.Fatal("missing ", expect, " in => ", out.String())
+		}
+	}
+}
+
+func TestFindDuplicateClasses(ctx *testing.T) {
+	var jre = makeJre(ctx)
+	var user = ctx.TempDir()
+	writeClass(ctx, user, "java/lang/Object", []byte("user"))
+	writeClass(ctx, user, "com/acme/Main", []byte("user"))
+	var classpath = mustClasspath(ctx, jre, user)
+
+	var duplicates, err = jvm.FindDuplicateClasses(classpath)
+	if err != nil {
+		ctx.Fatal(err)
+	}
+	if len(duplicates) != 1 || duplicates[0].ClassName() != "java/lang/Object" {
+		ctx.Fatal("unexpected duplicates => ", duplicates)
+	}
+	// rt.jar 最先被查找，a.jar 和用户目录中的 Object 被遮蔽
+	var expect = []string{filepath.Join(jre, "lib", "rt.jar"), filepath.Join(jre, "lib", "a.jar"), user}
+	var entrys = duplicates[0].Entrys()
+	if len(entrys) != len(expect) {
+		ctx.Fatal("unexpected entrys => ", entrys)
+	}
+	for idx, entry := range entrys {
+		if entry.String() != expect[idx] {
+			ctx.Fatal(idx, " => unexpected entry ", entry)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package classpath
+
+import (
+	"bytes"
+	"gava/jvm"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDirClassEntryReadClass(ctx *testing.T) {
+	var dir = ctx.TempDir()
+	var bytecode = []byte{0xCA, 0xFE, 0xBA, 0xBE}
+	if err := os.MkdirAll(filepath.Join(dir, "com", "acme"), 0755); err != nil {
+		ctx.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "com", "acme", "Main.class"), bytecode, 0644); err != nil {
+		ctx.Fatal(err)
+	}
+	var entry = mustClassEntry(ctx, dir)
+	for _, name := range []string{"com/acme/Main", "com.acme.Main", "com/acme/Main.class"} {
+		var data, from, err = entry.ReadClass(name)
+		if err != nil {
+			ctx.Fatal(name, err)
+		}
+		if !bytes.Equal(data, bytecode) {
+			ctx.Fatal(name, " => unexpected bytecode ", data)
+		}
+		if from != entry {
+			ctx.Fatal(name, " => unexpected entry ", from)
+		}
+	}
+	var _, _, err = entry.ReadClass("com/acme/Missing")
+	if !jvm.IsClassNotFound(err) {
+		ctx.Fatal("expect class not found, got => ", err)
+	}
+}
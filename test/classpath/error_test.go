@@ -0,0 +1,100 @@
+package classpath
+
+import (
+	"archive/zip"
+	"errors"
+	"gava/jvm"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClassEntryErrors(ctx *testing.T) {
+	var root = ctx.TempDir()
+	var file = filepath.Join(root, "file.txt")
+	var broken = filepath.Join(root, "broken.jar")
+	if err := ioutil.WriteFile(file, []byte("txt"), 0644); err != nil {
+		ctx.Fatal(err)
+	}
+	if err := ioutil.WriteFile(broken, []byte("not a zip"), 0644); err != nil {
+		ctx.Fatal(err)
+	}
+	var missing = filepath.Join(root, "missing")
+	var cases = []struct {
+		classpath string
+		segment   string
+		kind      jvm.ClasspathErrorKind
+	}{
+		{missing, missing, jvm.CLASSPATH_NOT_FOUND},
+		{file, file, jvm.CLASSPATH_NOT_DIRECTORY},
+		{broken, broken, jvm.CLASSPATH_UNREADABLE_ARCHIVE},
+		{strings.Join([]string{root, missing + ".jar"}, string(os.PathListSeparator)), missing + ".jar", jvm.CLASSPATH_NOT_FOUND},
+	}
+	for _, c := range cases {
+		var entry, err = jvm.NewClassEntry(c.classpath)
+		if entry != nil {
+			ctx.Fatal(c.classpath, " => expect nil entry")
+		}
+		var classpathErr *jvm.ClasspathError
+		if !errors.As(err, &classpathErr) {
+			ctx.Fatal(c.classpath, " => expect ClasspathError, got ", err)
+		}
+		if classpathErr.Segment() != c.segment || classpathErr.Kind() != c.kind {
+			ctx.Fatal(c.classpath, " => unexpected error ", err)
+		}
+	}
+}
+
+func TestLenientClassEntry(ctx *testing.T) {
+	var root = ctx.TempDir()
+	writeClass(ctx, root, "com/acme/Main", []byte("main"))
+	var classpath = strings.Join([]string{filepath.Join(root, "missing.jar"), root, filepath.Join(root, "missing")}, string(os.PathListSeparator))
+	if _, err := jvm.NewClassEntry(classpath); err == nil {
+		ctx.Fatal("strict mode should fail on missing entries")
+	}
+	var entry, err = jvm.NewLenientClassEntry(classpath)
+	if err != nil {
+		ctx.Fatal(err)
+	}
+	var data, _, readErr = entry.ReadClass("com/acme/Main")
+	if readErr != nil || string(data) != "main" {
+		ctx.Fatal("unexpected result => ", string(data), readErr)
+	}
+}
+
+func TestLenientJreClasspath(ctx *testing.T) {
+	var jre = makeJre(ctx)
+	// 启动类路径和扩展类路径中各有一个损坏的jar
+	for _, broken := range []string{filepath.Join(jre, "lib", "broken.jar"), filepath.Join(jre, "lib", "ext", "broken.jar")} {
+		if err := ioutil.WriteFile(broken, []byte("not a zip"), 0644); err != nil {
+			ctx.Fatal(err)
+		}
+	}
+	var jar = filepath.Join(ctx.TempDir(), "app.jar")
+	writeJar(ctx, jar,
+		jarFile{name: "META-INF/MANIFEST.MF", data: []byte("Main-Class: com.acme.Main\r\n\r\n"), method: zip.Deflate},
+		jarFile{name: "com/acme/Main.class", data: []byte("main"), method: zip.Deflate},
+	)
+	if _, _, err := jvm.NewJarClasspath(jre, jar); err == nil {
+		ctx.Fatal("strict mode should fail on broken jre entries")
+	}
+	var classpath, mainClass, err = jvm.NewJarClasspathWithOptions(jre, jar, jvm.ClasspathOptions{Lenient: true})
+	if err != nil || mainClass != "com.acme.Main" {
+		ctx.Fatal("unexpected result => ", mainClass, err)
+	}
+	for _, name := range []string{"java/lang/Object", "com/acme/Main"} {
+		if _, _, err = classpath.ReadClass(name); err != nil {
+			ctx.Fatal(name, " => ", err)
+		}
+	}
+}
+
+func TestClasspathMissingJre(ctx *testing.T) {
+	var _, err = jvm.NewClasspath(filepath.Join(ctx.TempDir(), "missing-jre"), ".")
+	var classpathErr *jvm.ClasspathError
+	if !errors.As(err, &classpathErr) || classpathErr.Kind() != jvm.CLASSPATH_NOT_FOUND {
+		ctx.Fatal("expect not found error, got => ", err)
+	}
+}
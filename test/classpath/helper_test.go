@@ -0,0 +1,66 @@
+package classpath
+
+import (
+	"archive/zip"
+	"bytes"
+	"gava/jvm"
+	"io/ioutil"
+	"testing"
+)
+
+// 压缩包中的一个文件
+type jarFile struct {
+	name   string
+	data   []byte
+	method uint16 // zip.Store 或 zip.Deflate
+}
+
+// 在内存中生成一个只包含文件（没有目录项）的压缩包
+func jarBytes(ctx *testing.T, files ...jarFile) []byte {
+	var out bytes.Buffer
+	var writer = zip.NewWriter(&out)
+	for _, file := range files {
+		var w, err = writer.CreateHeader(&zip.FileHeader{Name: file.name, Method: file.method})
+		if err != nil {
+			ctx.Fatal(err)
+		}
+		if _, err = w.Write(file.data); err != nil {
+			ctx.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		ctx.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+// 在path处生成一个只包含文件（没有目录项）的压缩包
+func writeJar(ctx *testing.T, path string, files ...jarFile) {
+	if err := ioutil.WriteFile(path, jarBytes(ctx, files...), 0644); err != nil {
+		ctx.Fatal(err)
+	}
+}
+
+func mustClassEntry(ctx *testing.T, classpath string) jvm.ClassEntry {
+	var entry, err = jvm.NewClassEntry(classpath)
+	if err != nil {
+		ctx.Fatal(err)
+	}
+	return entry
+}
+
+func mustClasspath(ctx *testing.T, jreOption string, cpOption string) *jvm.Classpath {
+	var classpath, err = jvm.NewClasspath(jreOption, cpOption)
+	if err != nil {
+		ctx.Fatal(err)
+	}
+	return classpath
+}
+
+func mustJarClasspath(ctx *testing.T, jreOption string, jarPath string) (*jvm.Classpath, string) {
+	var classpath, mainClass, err = jvm.NewJarClasspath(jreOption, jarPath)
+	if err != nil {
+		ctx.Fatal(err)
+	}
+	return classpath, mainClass
+}
@@ -0,0 +1,90 @@
+package classpath
+
+import (
+	"archive/zip"
+	"gava/jvm"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClasspathIndex(ctx *testing.T) {
+	var root = ctx.TempDir()
+	var first, second = filepath.Join(root, "first"), filepath.Join(root, "second")
+	var jar = filepath.Join(root, "lib.jar")
+	writeClass(ctx, first, "com/acme/Shared", []byte("first"))
+	writeClass(ctx, second, "com/acme/Shared", []byte("second"))
+	writeClass(ctx, second, "Default", []byte("default"))
+	writeJar(ctx, jar,
+		jarFile{name: "com/acme/Shared.class", data: []byte("jar"), method: zip.Deflate},
+		jarFile{name: "com/acme/lib/Lib.class", data: []byte("lib"), method: zip.Deflate},
+	)
+	var classpath = mustClasspath(ctx, makeJre(ctx), strings.Join([]string{jar, first, second}, string(os.PathListSeparator)))
+	var ordered = jvm.NewCompositeClassEntry(classpath.BootClasspath(), classpath.ExtClasspath(), classpath.UserClasspath())
+	var index = jvm.NewClasspathIndex(classpath)
+
+	// 索引的结果需要与按顺序查找的结果一致
+	for _, name := range []string{"java/lang/Object", "com/acme/Shared", "com.acme.lib.Lib", "Default", "com/acme/Ext"} {
+		var expect, expectFrom, expectErr = ordered.ReadClass(name)
+		var data, from, err = index.ReadClass(name)
+		if string(data) != string(expect) || from != expectFrom || (err == nil) != (expectErr == nil) {
+			ctx.Fatal(name, " => index result differs from ordered lookup ", string(data), from, err)
+		}
+	}
+	for idx := 0; idx < 3; idx++ {
+		if _, _, err := index.ReadClass("com/acme/Missing"); !jvm.IsClassNotFound(err) {
+			ctx.Fatal("expect class not found, got => ", err)
+		}
+	}
+	var stats = index.Stats()
+	if stats.Hits != 5 || stats.Misses != 1 || stats.NegativeHits != 2 || stats.Rebuilds != 1 || stats.Rescans != 0 {
+		ctx.Fatalf("unexpected stats => %+v", stats)
+	}
+
+	// 目录中新增class后，只重新扫描该目录，负缓存需要失效
+	writeClass(ctx, second, "com/acme/Missing", []byte("added"))
+	var data, _, err = index.ReadClass("com/acme/Missing")
+	if err != nil || string(data) != "added" {
+		ctx.Fatal("expect index refresh, got => ", string(data), err)
+	}
+	// 排在前面的目录中新增的包同样需要生效
+	writeClass(ctx, first, "org/acme/Added", []byte("added"))
+	if data, _, err = index.ReadClass("org/acme/Added"); err != nil || string(data) != "added" {
+		ctx.Fatal("expect index refresh, got => ", string(data), err)
+	}
+	if stats = index.Stats(); stats.Rebuilds != 1 || stats.Rescans != 2 {
+		ctx.Fatalf("unexpected stats => %+v", stats)
+	}
+}
+
+func TestClasspathIndexArchiveListing(ctx *testing.T) {
+	var root = ctx.TempDir()
+	var jar = filepath.Join(root, "lib.jar")
+	writeJar(ctx, jar, jarFile{name: "com/acme/Lib.class", data: []byte("lib"), method: zip.Deflate})
+	var classpath = mustClasspath(ctx, makeJre(ctx), jar)
+
+	// Classpath的查找通过索引完成
+	if data, _, err := classpath.ReadClass("com/acme/Lib"); err != nil || string(data) != "lib" {
+		ctx.Fatal("unexpected class => ", string(data), err)
+	}
+	var index = classpath.Index()
+	var listings = index.Stats().Listings
+
+	// 压缩包没有变化时，重新建立索引不需要再次列出
+	index.Invalidate()
+	if _, _, err := classpath.ReadClass("com/acme/Lib"); err != nil {
+		ctx.Fatal(err)
+	}
+	if stats := index.Stats(); stats.Rebuilds != 2 || stats.Listings != listings {
+		ctx.Fatalf("unexpected stats => %+v", stats)
+	}
+
+	// 追加用户类路径后索引需要失效
+	var dir = filepath.Join(root, "classes")
+	writeClass(ctx, dir, "com/acme/Extra", []byte("extra"))
+	classpath.AddUserClassEntry(mustClassEntry(ctx, dir))
+	if data, _, err := classpath.ReadClass("com/acme/Extra"); err != nil || string(data) != "extra" {
+		ctx.Fatal("unexpected class => ", string(data), err)
+	}
+}
@@ -0,0 +1,49 @@
+package classpath
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJarClasspathFollowsManifest(ctx *testing.T) {
+	var jre = makeJre(ctx)
+	var root = ctx.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "lib"), 0755); err != nil {
+		ctx.Fatal(err)
+	}
+	writeClass(ctx, filepath.Join(root, "classes"), "com/acme/Resource", []byte("dir"))
+	writeJar(ctx, filepath.Join(root, "app.jar"),
+		jarFile{name: "META-INF/MANIFEST.MF", data: []byte("Manifest-Version: 1.0\r\nMain-Class: com.acme.Main\r\nClass-Path: lib/dep.jar classes/ missing.jar\r\n\r\n"), method: zip.Deflate},
+		jarFile{name: "com/acme/Main.class", data: []byte("main"), method: zip.Deflate},
+	)
+	// dep.jar 与 app.jar 相互引用，构成循环
+	writeJar(ctx, filepath.Join(root, "lib", "dep.jar"),
+		jarFile{name: "META-INF/MANIFEST.MF", data: []byte("Class-Path: ../app.jar dep2.jar\n"), method: zip.Store},
+		jarFile{name: "com/acme/Dep.class", data: []byte("dep"), method: zip.Deflate},
+	)
+	writeJar(ctx, filepath.Join(root, "lib", "dep2.jar"),
+		jarFile{name: "com/acme/Dep2.class", data: []byte("dep2"), method: zip.Deflate},
+	)
+
+	var classpath, mainClass = mustJarClasspath(ctx, jre, filepath.Join(root, "app.jar"))
+	if mainClass != "com.acme.Main" {
+		ctx.Fatal("unexpected main class => ", mainClass)
+	}
+	var cases = map[string]string{
+		"com/acme/Main":     "main",
+		"com/acme/Dep":      "dep",
+		"com/acme/Dep2":     "dep2",
+		"com/acme/Resource": "dir",
+	}
+	for name, expect := range cases {
+		var data, _, err = classpath.ReadClass(name)
+		if err != nil {
+			ctx.Fatal(name, err)
+		}
+		if string(data) != expect {
+			ctx.Fatal(name, " => unexpected bytecode ", string(data))
+		}
+	}
+}
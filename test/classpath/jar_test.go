@@ -0,0 +1,40 @@
+package classpath
+
+import (
+	"archive/zip"
+	"bytes"
+	"gava/jvm"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompressedClassEntryReadClass(ctx *testing.T) {
+	var jar = filepath.Join(ctx.TempDir(), "app.jar")
+	var stored = []byte{0xCA, 0xFE, 0xBA, 0xBE, 0x01}
+	var deflated = bytes.Repeat([]byte{0xCA, 0xFE, 0xBA, 0xBE}, 64)
+	writeJar(ctx, jar,
+		jarFile{name: "com/acme/Stored.class", data: stored, method: zip.Store},
+		jarFile{name: "com/acme/Deflated.class", data: deflated, method: zip.Deflate},
+	)
+	var entry = mustClassEntry(ctx, jar)
+	var cases = map[string][]byte{
+		"com/acme/Stored":   stored,
+		"com.acme.Deflated": deflated,
+	}
+	for name, expect := range cases {
+		var data, from, err = entry.ReadClass(name)
+		if err != nil {
+			ctx.Fatal(name, err)
+		}
+		if !bytes.Equal(data, expect) {
+			ctx.Fatal(name, " => unexpected bytecode")
+		}
+		if from != entry {
+			ctx.Fatal(name, " => unexpected entry ", from)
+		}
+	}
+	var _, _, err = entry.ReadClass("com/acme/Missing")
+	if !jvm.IsClassNotFound(err) {
+		ctx.Fatal("expect class not found, got => ", err)
+	}
+}
@@ -0,0 +1,317 @@
+package classpath
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"gava/jvm"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// jimage中的一个资源
+type jimageResource struct {
+	module, parent, base, extension string
+	data                            []byte
+	compress                        func(data []byte, addString func(string) uint64) []byte // 非nil时保存压缩后的内容
+}
+
+func (this jimageResource) name() string {
+	return "/" + this.module + "/" + this.parent + "/" + this.base + "." + this.extension
+}
+
+func jimageHash(name string, seed int32) int32 {
+	for _, b := range []byte(name) {
+		seed = seed*0x01000193 ^ int32(b)
+	}
+	return seed & 0x7FFFFFFF
+}
+
+// 生成一个小端存储的jimage文件，哈希表的构造方式与jlink的PerfectHashBuilder一致
+func writeJImage(ctx *testing.T, path string, resources ...jimageResource) {
+	var stringsTable = []byte{0}
+	var stringOffsets = map[string]uint64{"": 0}
+	var addString = func(s string) uint64 {
+		if offset, ok := stringOffsets[s]; ok {
+			return offset
+		}
+		var offset = uint64(len(stringsTable))
+		stringOffsets[s] = offset
+		stringsTable = append(append(stringsTable, s...), 0)
+		return offset
+	}
+	var locations, content bytes.Buffer
+	var locationOffsets = make([]uint32, len(resources))
+	for idx, resource := range resources {
+		locationOffsets[idx] = uint32(locations.Len())
+		var stored, compressed = resource.data, uint64(0)
+		if resource.compress != nil {
+			stored = resource.compress(resource.data, addString)
+			compressed = uint64(len(stored))
+		}
+		var attributes = []uint64{
+			0,
+			addString(resource.module),
+			addString(resource.parent),
+			addString(resource.base),
+			addString(resource.extension),
+			uint64(content.Len()),
+			compressed,
+			uint64(len(resource.data)),
+		}
+		for kind := 1; kind < len(attributes); kind++ {
+			var value = attributes[kind]
+			if value == 0 {
+				continue
+			}
+			var length = 1
+			for value>>(8*uint(length)) != 0 {
+				length++
+			}
+			locations.WriteByte(byte(kind<<3 | (length - 1)))
+			for shift := length - 1; shift >= 0; shift-- {
+				locations.WriteByte(byte(value >> (8 * uint(shift))))
+			}
+		}
+		locations.WriteByte(0)
+		content.Write(stored)
+	}
+
+	// 构造完美哈希表
+	var length = int32(len(resources))
+	var redirect = make([]int32, length)
+	var offsets = make([]uint32, length)
+	var used = make([]bool, length)
+	var buckets = make(map[int32][]int)
+	for idx, resource := range resources {
+		var bucket = jimageHash(resource.name(), 0x01000193) % length
+		buckets[bucket] = append(buckets[bucket], idx)
+	}
+	var keys = make([]int32, 0, len(buckets))
+	for key := range buckets {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return len(buckets[keys[i]]) > len(buckets[keys[j]]) })
+	for _, key := range keys {
+		var members = buckets[key]
+		if len(members) == 1 {
+			continue
+		}
+		for seed := int32(1); ; seed++ {
+			var slots = make(map[int32]bool)
+			for _, idx := range members {
+				var slot = jimageHash(resources[idx].name(), seed) % length
+				if used[slot] || slots[slot] {
+					break
+				}
+				slots[slot] = true
+			}
+			if len(slots) != len(members) {
+				continue
+			}
+			for _, idx := range members {
+				var slot = jimageHash(resources[idx].name(), seed) % length
+				used[slot] = true
+				offsets[slot] = locationOffsets[idx]
+			}
+			redirect[key] = seed
+			break
+		}
+	}
+	for _, key := range keys {
+		var members = buckets[key]
+		if len(members) != 1 {
+			continue
+		}
+		for slot := int32(0); slot < length; slot++ {
+			if !used[slot] {
+				used[slot] = true
+				offsets[slot] = locationOffsets[members[0]]
+				redirect[key] = -slot - 1
+				break
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	for _, value := range []uint32{0xCAFEDADA, 1 << 16, 0, uint32(length), uint32(length), uint32(locations.Len()), uint32(len(stringsTable))} {
+		binary.Write(&out, binary.LittleEndian, value)
+	}
+	binary.Write(&out, binary.LittleEndian, redirect)
+	binary.Write(&out, binary.LittleEndian, offsets)
+	out.Write(locations.Bytes())
+	out.Write(stringsTable)
+	out.Write(content.Bytes())
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		ctx.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, out.Bytes(), 0644); err != nil {
+		ctx.Fatal(err)
+	}
+}
+
+func TestJImageClasspath(ctx *testing.T) {
+	var jdk = filepath.Join(ctx.TempDir(), "jdk-17")
+	var resources = []jimageResource{
+		{"java.base", "java/lang", "Object", "class", []byte("object"), nil},
+		{"java.base", "java/lang", "String", "class", []byte("string"), nil},
+		{"java.base", "java/util", "List", "class", []byte("list"), nil},
+		{"java.sql", "java/sql", "Connection", "class", []byte("connection"), nil},
+		{"java.logging", "java/util/logging", "Logger", "class", []byte("logger"), nil},
+	}
+	// 足够多的资源以产生哈希冲突
+	for idx := 0; idx < 64; idx++ {
+		resources = append(resources, jimageResource{"java.desktop", "java/awt", "Gen" + strings.Repeat("x", idx), "class", []byte{byte(idx)}, nil})
+	}
+	writeJImage(ctx, filepath.Join(jdk, "lib", "modules"), resources...)
+
+	var classpath = mustClasspath(ctx, jdk, ctx.TempDir())
+	for _, resource := range resources {
+		var name = resource.parent + "/" + resource.base
+		var data, from, err = classpath.ReadClass(name)
+		if err != nil {
+			ctx.Fatal(name, err)
+		}
+		if !bytes.Equal(data, resource.data) {
+			ctx.Fatal(name, " => unexpected bytecode ", data)
+		}
+		if from.String() != filepath.Join(jdk, "lib", "modules") {
+			ctx.Fatal(name, " => unexpected entry ", from)
+		}
+	}
+	for _, name := range []string{"java/lang/Missing", "com/acme/Main"} {
+		if _, _, err := classpath.ReadClass(name); !jvm.IsClassNotFound(err) {
+			ctx.Fatal(name, " => expect class not found, got ", err)
+		}
+	}
+
+	// 关闭后的读取返回明确的错误
+	var image = classpath.BootClasspath().(*jvm.JImageClassEntry)
+	if err := image.Close(); err != nil {
+		ctx.Fatal(err)
+	}
+	var _, _, err = image.ReadClass("java/lang/Object")
+	var classpathErr *jvm.ClasspathError
+	if !errors.As(err, &classpathErr) || classpathErr.Kind() != jvm.CLASSPATH_CLOSED {
+		ctx.Fatal("expect entry closed, got => ", err)
+	}
+}
+
+// 在压缩后的内容前加上小端存储的压缩资源头
+func compressedResource(decompressor string, content []byte, uncompressed int, addString func(string) uint64) []byte {
+	var out bytes.Buffer
+	binary.Write(&out, binary.LittleEndian, uint32(0xCAFEFAFA))
+	binary.Write(&out, binary.LittleEndian, uint64(len(content)))
+	binary.Write(&out, binary.LittleEndian, uint64(uncompressed))
+	binary.Write(&out, binary.LittleEndian, uint32(addString(decompressor)))
+	binary.Write(&out, binary.LittleEndian, uint32(0))
+	out.WriteByte(1)
+	out.Write(content)
+	return out.Bytes()
+}
+
+func zipResource(data []byte, addString func(string) uint64) []byte {
+	var content bytes.Buffer
+	var writer = zlib.NewWriter(&content)
+	writer.Write(data)
+	writer.Close()
+	return compressedResource("zip", content.Bytes(), len(data), addString)
+}
+
+// CompressIndexes 编码的整数
+func compressedInt(value uint64) []byte {
+	switch {
+	case value < 0x20:
+		return []byte{byte(0xA0 | value)}
+	case value < 0x2000:
+		return []byte{byte(0xC0 | value>>8), byte(value)}
+	default:
+		return []byte{byte(value >> 24), byte(value >> 16), byte(value >> 8), byte(value)}
+	}
+}
+
+// compact-cp处理前后的class：Utf8常量和描述符中的类名保存在strings表中
+func sharedStringsClass(addString func(string) uint64) (transformed []byte, expect []byte) {
+	var out, want bytes.Buffer
+	var utf8 = func(value string) {
+		want.WriteByte(1)
+		binary.Write(&want, binary.BigEndian, uint16(len(value)))
+		want.WriteString(value)
+	}
+	out.Write([]byte{0xCA, 0xFE, 0xBA, 0xBE, 0, 0, 0, 52, 0, 7})
+	want.Write([]byte{0xCA, 0xFE, 0xBA, 0xBE, 0, 0, 0, 52, 0, 7})
+	// #1 Utf8 Hello
+	out.WriteByte(23)
+	if addString != nil {
+		out.Write(compressedInt(addString("Hello")))
+	}
+	utf8("Hello")
+	// #2 Class #1
+	out.Write([]byte{7, 0, 1})
+	want.Write([]byte{7, 0, 1})
+	// #3 描述符，第二个类没有包名
+	out.WriteByte(25)
+	if addString != nil {
+		out.Write(compressedInt(addString("(L;IL;)V")))
+		var indexes = append(compressedInt(addString("java/lang")), compressedInt(addString("String"))...)
+		indexes = append(append(indexes, compressedInt(0)...), compressedInt(addString("Main"))...)
+		out.Write(append(compressedInt(uint64(len(indexes))), indexes...))
+	}
+	utf8("(Ljava/lang/String;ILMain;)V")
+	// #4 #5 Long
+	out.Write([]byte{5, 0, 0, 0, 0, 0, 0, 0, 42})
+	want.Write([]byte{5, 0, 0, 0, 0, 0, 0, 0, 42})
+	// #6 未共享的Utf8
+	out.Write([]byte{1, 0, 3, 'a', 'b', 'c'})
+	utf8("abc")
+	// 常量池之后的内容原样保留
+	out.Write([]byte{0, 0x21, 0, 2, 0, 2})
+	want.Write([]byte{0, 0x21, 0, 2, 0, 2})
+	return out.Bytes(), want.Bytes()
+}
+
+func TestJImageCompressedResources(ctx *testing.T) {
+	var jdk = filepath.Join(ctx.TempDir(), "jdk-17")
+	var _, shared = sharedStringsClass(nil)
+	var compactCp = func(data []byte, addString func(string) uint64) []byte {
+		var transformed, _ = sharedStringsClass(addString)
+		return compressedResource("compact-cp", transformed, len(data), addString)
+	}
+	var resources = []jimageResource{
+		{"java.base", "java/lang", "Object", "class", []byte("object"), nil},
+		{"java.base", "java/lang", "String", "class", bytes.Repeat([]byte("string"), 100), zipResource},
+		{"java.base", "java/util", "List", "class", shared, compactCp},
+		// 先使用compact-cp，再使用zip
+		{"java.sql", "java/sql", "Connection", "class", shared, func(data []byte, addString func(string) uint64) []byte {
+			return zipResource(compactCp(data, addString), addString)
+		}},
+		{"java.logging", "java/util/logging", "Logger", "class", []byte("logger"), func(data []byte, addString func(string) uint64) []byte {
+			return compressedResource("lzma", data, len(data), addString)
+		}},
+	}
+	writeJImage(ctx, filepath.Join(jdk, "lib", "modules"), resources...)
+
+	var classpath = mustClasspath(ctx, jdk, ctx.TempDir())
+	for _, resource := range resources[:4] {
+		var name = resource.parent + "/" + resource.base
+		var data, _, err = classpath.ReadClass(name)
+		if err != nil {
+			ctx.Fatal(name, " => ", err)
+		}
+		if !bytes.Equal(data, resource.data) {
+			ctx.Fatalf("%s => unexpected bytecode % x", name, data)
+		}
+	}
+	// 不支持的解压器只影响对应的资源
+	var _, _, err = classpath.ReadClass("java/util/logging/Logger")
+	var decompressError *jvm.JImageDecompressError
+	if !errors.As(err, &decompressError) || decompressError.Decompressor() != "lzma" ||
+		decompressError.Resource() != "/java.logging/java/util/logging/Logger.class" {
+		ctx.Fatal("expect decompress error => ", err)
+	}
+}
@@ -0,0 +1,233 @@
+package classpath
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"gava/jvm"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// module-info.class 中的一条requires，flags为 0x0020（transitive）或 0x0040（static）
+type moduleRequire struct {
+	name  string
+	flags uint16
+}
+
+// 生成只包含Module属性（以及可选的ModuleMainClass属性）的 module-info.class
+func moduleInfo(name string, mainClass string, requires ...moduleRequire) []byte {
+	var pool bytes.Buffer
+	var count uint16 = 1
+	var u1 = func(b *bytes.Buffer, v uint8) { b.WriteByte(v) }
+	var u2 = func(b *bytes.Buffer, v uint16) { binary.Write(b, binary.BigEndian, v) }
+	var utf8 = func(s string) uint16 {
+		u1(&pool, 1)
+		u2(&pool, uint16(len(s)))
+		pool.WriteString(s)
+		count++
+		return count - 1
+	}
+	var constant = func(tag uint8, s string) uint16 {
+		var nameIndex = utf8(s)
+		u1(&pool, tag)
+		u2(&pool, nameIndex)
+		count++
+		return count - 1
+	}
+	var thisClass = constant(7, "module-info")
+	var module = new(bytes.Buffer)
+	u2(module, constant(19, name))
+	u2(module, 0)
+	u2(module, 0)
+	u2(module, uint16(len(requires)))
+	for _, require := range requires {
+		u2(module, constant(19, require.name))
+		u2(module, require.flags)
+		u2(module, 0)
+	}
+	u2(module, 0) // exports
+	u2(module, 0) // opens
+	u2(module, 0) // uses
+	u2(module, 0) // provides
+	var attributes = [][]byte{}
+	var attribute = func(name string, body []byte) {
+		var b = new(bytes.Buffer)
+		u2(b, utf8(name))
+		binary.Write(b, binary.BigEndian, uint32(len(body)))
+		b.Write(body)
+		attributes = append(attributes, b.Bytes())
+	}
+	attribute("Module", module.Bytes())
+	if mainClass != "" {
+		var b = new(bytes.Buffer)
+		u2(b, constant(7, mainClass))
+		attribute("ModuleMainClass", b.Bytes())
+	}
+
+	var class = new(bytes.Buffer)
+	binary.Write(class, binary.BigEndian, uint32(0xCAFEBABE))
+	u2(class, 0)
+	u2(class, 61)
+	u2(class, count)
+	class.Write(pool.Bytes())
+	for _, v := range []uint16{0x8000, thisClass, 0, 0, 0, 0, uint16(len(attributes))} {
+		u2(class, v)
+	}
+	for _, attribute := range attributes {
+		class.Write(attribute)
+	}
+	return class.Bytes()
+}
+
+func moduleNames(modules []*jvm.ModuleReference) []string {
+	var names = make([]string, len(modules))
+	for idx, module := range modules {
+		names[idx] = module.Name()
+	}
+	return names
+}
+
+func TestResolveModules(ctx *testing.T) {
+	var jre = makeJre(ctx)
+	var mods = filepath.Join(ctx.TempDir(), "mods")
+	// com.acme.app => com.acme.lib（展开的模块） => util（自动模块）
+	writeClass(ctx, filepath.Join(mods, "lib"), "module-info", moduleInfo("com.acme.lib", "",
+		moduleRequire{name: "util", flags: 0x0020}))
+	writeClass(ctx, filepath.Join(mods, "lib"), "com/acme/lib/Lib", []byte("lib"))
+	writeJar(ctx, filepath.Join(mods, "app.jar"),
+		jarFile{name: "module-info.class", data: moduleInfo("com.acme.app", "com/acme/app/Main",
+			moduleRequire{name: "com.acme.lib"},
+			moduleRequire{name: "com.acme.tools", flags: 0x0040}, // requires static 不参与解析
+			moduleRequire{name: "java.sql"},
+		), method: zip.Deflate},
+		jarFile{name: "com/acme/app/Main.class", data: []byte("main"), method: zip.Deflate},
+	)
+	writeJar(ctx, filepath.Join(mods, "util-1.2.jar"),
+		jarFile{name: "com/acme/util/Util.class", data: []byte("util"), method: zip.Deflate},
+	)
+	// 自动模块名称由manifest指定
+	writeJar(ctx, filepath.Join(mods, "commons-io.jar"),
+		jarFile{name: "META-INF/MANIFEST.MF", data: []byte("Automatic-Module-Name: org.apache.commons.io\r\n\r\n"), method: zip.Deflate},
+		jarFile{name: "org/apache/commons/io/IOUtils.class", data: []byte("io"), method: zip.Deflate},
+	)
+	// 展开的模块之外的目录会被忽略
+	if err := os.MkdirAll(filepath.Join(mods, "notes"), 0755); err != nil {
+		ctx.Fatal(err)
+	}
+
+	var found, err = jvm.FindModules(mods)
+	if err != nil {
+		ctx.Fatal(err)
+	}
+	if names := moduleNames(found); !reflect.DeepEqual(names, []string{"com.acme.app", "org.apache.commons.io", "com.acme.lib", "util"}) {
+		ctx.Fatal("unexpected modules => ", names)
+	}
+
+	var classpath = mustClasspath(ctx, jre, "")
+	var graph *jvm.ModuleGraph
+	graph, err = jvm.ResolveModules(classpath.BootClasspath(), mods, "com.acme.app")
+	if err != nil {
+		ctx.Fatal(err)
+	}
+	// 解析util时模块路径上的所有自动模块都会被解析
+	if names := moduleNames(graph.Modules()); !reflect.DeepEqual(names, []string{"com.acme.app", "com.acme.lib", "util", "org.apache.commons.io"}) {
+		ctx.Fatal("unexpected resolved modules => ", names)
+	}
+	// requires transitive util 使得 com.acme.app 也可以读取 util，以及util隐式 requires transitive 的其他自动模块
+	if reads := graph.Reads("com.acme.app"); !reflect.DeepEqual(reads, []string{"com.acme.lib", "java.base", "java.sql", "org.apache.commons.io", "util"}) {
+		ctx.Fatal("unexpected reads => ", reads)
+	}
+	if reads := graph.Reads("util"); !reflect.DeepEqual(reads, []string{"com.acme.app", "com.acme.lib", "java.base", "org.apache.commons.io"}) {
+		ctx.Fatal("unexpected automatic module reads => ", reads)
+	}
+	var util = graph.Module("util")
+	if util == nil || !util.IsAutomatic() || !reflect.DeepEqual(util.Packages(), []string{"com/acme/util"}) {
+		ctx.Fatal("unexpected automatic module => ", util)
+	}
+
+	var mainClass string
+	classpath, mainClass, err = jvm.NewModuleClasspath(jre, mods, "com.acme.app")
+	if err != nil {
+		ctx.Fatal(err)
+	}
+	if mainClass != "com.acme.app.Main" {
+		ctx.Fatal("unexpected main class => ", mainClass)
+	}
+	for name, expect := range map[string]string{"com/acme/app/Main": "main", "com/acme/lib/Lib": "lib", "com/acme/util/Util": "util"} {
+		var data, _, err = classpath.ReadClass(name)
+		if err != nil || string(data) != expect {
+			ctx.Fatal(name, " => unexpected bytecode ", string(data), err)
+		}
+	}
+	if _, mainClass, err = jvm.NewModuleClasspath(jre, mods, "com.acme.app/com.acme.app.Other"); err != nil || mainClass != "com.acme.app.Other" {
+		ctx.Fatal("unexpected main class => ", mainClass, err)
+	}
+	if _, _, err = jvm.NewModuleClasspath(jre, mods, "com.acme.lib"); err == nil || !strings.Contains(err.Error(), "ModuleMainClass") {
+		ctx.Fatal("expect missing main class => ", err)
+	}
+}
+
+func TestModuleClasspathMultiRelease(ctx *testing.T) {
+	var jre = makeJre(ctx)
+	var mods = ctx.TempDir()
+	writeJar(ctx, filepath.Join(mods, "app.jar"),
+		jarFile{name: "META-INF/MANIFEST.MF", data: []byte("Multi-Release: true\n"), method: zip.Deflate},
+		jarFile{name: "module-info.class", data: moduleInfo("com.acme.app", "com/acme/app/Main"), method: zip.Deflate},
+		jarFile{name: "com/acme/app/Main.class", data: []byte("base"), method: zip.Deflate},
+		jarFile{name: "META-INF/versions/11/com/acme/app/Main.class", data: []byte("11"), method: zip.Deflate},
+	)
+	// 模块路径上的多版本jar使用选项中的版本，未指定时使用gava支持的最高版本
+	for release, expect := range map[int]string{8: "base", 11: "11", 0: "11"} {
+		var classpath, _, err = jvm.NewModuleClasspathWithOptions(jre, mods, "com.acme.app", jvm.ClasspathOptions{Release: release})
+		if err != nil {
+			ctx.Fatal(err)
+		}
+		var data []byte
+		if data, _, err = classpath.ReadClass("com/acme/app/Main"); err != nil || string(data) != expect {
+			ctx.Fatal(release, " => unexpected variant ", string(data), err)
+		}
+	}
+}
+
+func TestResolveModulesReportsProblems(ctx *testing.T) {
+	var jre = makeJre(ctx)
+	var mods = ctx.TempDir()
+	// a => b => a 构成循环，a 和 c 都包含 com.acme.shared，a 依赖的 missing 不存在
+	writeJar(ctx, filepath.Join(mods, "a.jar"),
+		jarFile{name: "module-info.class", data: moduleInfo("a", "", moduleRequire{name: "b"}, moduleRequire{name: "c"}, moduleRequire{name: "missing"}), method: zip.Deflate},
+		jarFile{name: "com/acme/shared/A.class", data: []byte("a"), method: zip.Deflate},
+	)
+	writeJar(ctx, filepath.Join(mods, "b.jar"),
+		jarFile{name: "module-info.class", data: moduleInfo("b", "", moduleRequire{name: "a"}), method: zip.Deflate},
+	)
+	writeJar(ctx, filepath.Join(mods, "c.jar"),
+		jarFile{name: "module-info.class", data: moduleInfo("c", ""), method: zip.Deflate},
+		jarFile{name: "com/acme/shared/C.class", data: []byte("c"), method: zip.Deflate},
+	)
+
+	var classpath = mustClasspath(ctx, jre, "")
+	var _, err = jvm.ResolveModules(classpath.BootClasspath(), mods, "a", "absent")
+	var resolutionError *jvm.ModuleResolutionError
+	if !errors.As(err, &resolutionError) {
+		ctx.Fatal("expect ModuleResolutionError => ", err)
+	}
+	if !reflect.DeepEqual(resolutionError.MissingModules(), map[string][]string{"absent": nil, "missing": {"a"}}) {
+		ctx.Fatal("unexpected missing modules => ", resolutionError.MissingModules())
+	}
+	if !reflect.DeepEqual(resolutionError.Cycles(), [][]string{{"a", "b", "a"}}) {
+		ctx.Fatal("unexpected cycles => ", resolutionError.Cycles())
+	}
+	if !reflect.DeepEqual(resolutionError.SplitPackages(), map[string][]string{"com/acme/shared": {"a", "c"}}) {
+		ctx.Fatal("unexpected split packages => ", resolutionError.SplitPackages())
+	}
+	var expect = "module absent not found; module missing not found, required by a; cycle detected: a -> b -> a; " +
+		"package com.acme.shared is split between modules a, c"
+	if err.Error() != expect {
+		ctx.Fatal("unexpected message => ", err)
+	}
+}
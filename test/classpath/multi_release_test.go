@@ -0,0 +1,70 @@
+package classpath
+
+import (
+	"archive/zip"
+	"gava/jvm"
+	"path/filepath"
+	"testing"
+)
+
+func TestMultiReleaseJar(ctx *testing.T) {
+	var root = ctx.TempDir()
+	var files = []jarFile{
+		{name: "com/acme/Json.class", data: []byte("base"), method: zip.Deflate},
+		{name: "META-INF/versions/9/com/acme/Json.class", data: []byte("9"), method: zip.Deflate},
+		{name: "META-INF/versions/11/com/acme/Json.class", data: []byte("11"), method: zip.Deflate},
+		{name: "META-INF/versions/11/com/acme/Only11.class", data: []byte("11"), method: zip.Deflate},
+	}
+	var multi = filepath.Join(root, "multi.jar")
+	writeJar(ctx, multi, append([]jarFile{{name: "META-INF/MANIFEST.MF", data: []byte("Multi-Release: true\n"), method: zip.Deflate}}, files...)...)
+	var plain = filepath.Join(root, "plain.jar")
+	writeJar(ctx, plain, files...)
+
+	var cases = []struct {
+		jar     string
+		release int
+		expect  string
+	}{
+		{multi, 8, "base"},
+		{multi, 10, "9"},
+		{multi, 17, "11"},
+		{plain, 17, "base"},
+	}
+	for _, c := range cases {
+		var entry, err = jvm.NewClassEntryWithOptions(c.jar, jvm.ClasspathOptions{Release: c.release})
+		if err != nil {
+			ctx.Fatal(err)
+		}
+		var data []byte
+		data, _, err = entry.ReadClass("com/acme/Json")
+		if err != nil {
+			ctx.Fatal(err)
+		}
+		if string(data) != c.expect {
+			ctx.Fatal(c.jar, " release ", c.release, " => unexpected variant ", string(data))
+		}
+		var _, _, only11Err = entry.ReadClass("com/acme/Only11")
+		if (c.jar == multi && c.release >= 11) != (only11Err == nil) {
+			ctx.Fatal(c.jar, " release ", c.release, " => unexpected Only11 lookup ", only11Err)
+		}
+	}
+}
+
+func TestMultiReleaseVersionPerClasspath(ctx *testing.T) {
+	var jar = filepath.Join(ctx.TempDir(), "multi.jar")
+	writeJar(ctx, jar,
+		jarFile{name: "META-INF/MANIFEST.MF", data: []byte("Multi-Release: true\n"), method: zip.Deflate},
+		jarFile{name: "com/acme/Json.class", data: []byte("base"), method: zip.Deflate},
+		jarFile{name: "META-INF/versions/11/com/acme/Json.class", data: []byte("11"), method: zip.Deflate},
+	)
+	// 同一个进程中的两个classpath使用不同的版本，未指定时使用gava支持的最高版本
+	var java8, _ = jvm.NewClassEntryWithOptions(jar, jvm.ClasspathOptions{Release: 8})
+	var java17, _ = jvm.NewClassEntryWithOptions("."+string(filepath.ListSeparator)+jar, jvm.ClasspathOptions{Release: 17})
+	var latest = mustClassEntry(ctx, jar)
+	for entry, expect := range map[jvm.ClassEntry]string{java8: "base", java17: "11", latest: "11"} {
+		var data, _, err = entry.ReadClass("com/acme/Json")
+		if err != nil || string(data) != expect {
+			ctx.Fatal(entry, " => unexpected variant ", string(data), err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package classpath
+
+import (
+	"archive/zip"
+	"gava/jvm"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 生成一个Spring Boot风格的fat jar
+func writeFatJar(ctx *testing.T, path string) {
+	var stored = jarBytes(ctx, jarFile{name: "com/acme/dep/Stored.class", data: []byte("stored"), method: zip.Deflate})
+	var deflated = jarBytes(ctx, jarFile{name: "com/acme/dep/Deflated.class", data: []byte("deflated"), method: zip.Store})
+	writeJar(ctx, path,
+		jarFile{name: "META-INF/MANIFEST.MF", data: []byte("Main-Class: org.springframework.boot.loader.JarLauncher\r\nStart-Class: com.acme.App\r\nSpring-Boot-Classes: BOOT-INF/classes/\r\nSpring-Boot-Lib: BOOT-INF/lib/\r\n\r\n"), method: zip.Deflate},
+		jarFile{name: "org/springframework/boot/loader/JarLauncher.class", data: []byte("launcher"), method: zip.Deflate},
+		jarFile{name: "BOOT-INF/classes/com/acme/App.class", data: []byte("app"), method: zip.Deflate},
+		jarFile{name: "BOOT-INF/lib/stored.jar", data: stored, method: zip.Store},
+		jarFile{name: "BOOT-INF/lib/deflated.jar", data: deflated, method: zip.Deflate},
+	)
+}
+
+func TestNestedClassEntry(ctx *testing.T) {
+	var fat = filepath.Join(ctx.TempDir(), "app.jar")
+	writeFatJar(ctx, fat)
+	var cases = []struct {
+		classpath string
+		name      string
+		expect    string
+	}{
+		{fat + "!/BOOT-INF/lib/stored.jar", "com/acme/dep/Stored", "stored"},
+		{fat + "!/BOOT-INF/lib/deflated.jar", "com/acme/dep/Deflated", "deflated"},
+		{fat + "!/BOOT-INF/classes", "com.acme.App", "app"},
+	}
+	for _, c := range cases {
+		var entry = mustClassEntry(ctx, c.classpath)
+		var data, from, err = entry.ReadClass(c.name)
+		if err != nil {
+			ctx.Fatal(c.classpath, err)
+		}
+		if string(data) != c.expect || from.String() != c.classpath {
+			ctx.Fatal(c.classpath, " => unexpected result ", string(data), " from ", from)
+		}
+		if _, _, err = entry.ReadClass("com/acme/Missing"); !jvm.IsClassNotFound(err) {
+			ctx.Fatal(c.classpath, " => expect class not found, got ", err)
+		}
+	}
+}
+
+func TestSpringBootJarClasspath(ctx *testing.T) {
+	var fat = filepath.Join(ctx.TempDir(), "app.jar")
+	writeFatJar(ctx, fat)
+	var classpath, mainClass = mustJarClasspath(ctx, makeJre(ctx), fat)
+	if mainClass != "com.acme.App" {
+		ctx.Fatal("unexpected main class => ", mainClass)
+	}
+	var cases = map[string]string{
+		"com/acme/App":                                "app",
+		"com/acme/dep/Stored":                         "stored",
+		"com/acme/dep/Deflated":                       "deflated",
+		"org/springframework/boot/loader/JarLauncher": "launcher",
+	}
+	for name, expect := range cases {
+		var data, _, err = classpath.ReadClass(name)
+		if err != nil {
+			ctx.Fatal(name, err)
+		}
+		if string(data) != expect {
+			ctx.Fatal(name, " => unexpected bytecode ", string(data))
+		}
+	}
+}
+
+func TestSpringBootJarListClasses(ctx *testing.T) {
+	var fat = filepath.Join(ctx.TempDir(), "app.jar")
+	writeFatJar(ctx, fat)
+	var classpath, _ = mustJarClasspath(ctx, makeJre(ctx), fat)
+	var entrys = classpath.UserClasspath().(*jvm.CompositeClassEntry).Entrys()
+	var all = make([]string, 0)
+	for _, entry := range entrys {
+		var classes, err = entry.(jvm.ClassLister).ListClasses()
+		if err != nil {
+			ctx.Fatal(entry, err)
+		}
+		all = append(all, classes...)
+	}
+	// jar本身只提供loader类，BOOT-INF/ 下的class由各自的entry提供
+	var loader, _ = entrys[len(entrys)-1].(jvm.ClassLister).ListClasses()
+	if len(loader) != 1 || loader[0] != "org/springframework/boot/loader/JarLauncher" || entrys[len(entrys)-1].String() != fat {
+		ctx.Fatal("unexpected loader classes => ", loader)
+	}
+	for _, name := range all {
+		if strings.HasPrefix(name, "BOOT-INF/") {
+			ctx.Fatal("unexpected class => ", name)
+		}
+	}
+	if _, _, err := classpath.ReadClass("BOOT-INF/classes/com/acme/App"); !jvm.IsClassNotFound(err) {
+		ctx.Fatal("expect class not found => ", err)
+	}
+	if duplicates, err := jvm.FindDuplicateClasses(classpath.UserClasspath()); err != nil || len(duplicates) != 0 {
+		ctx.Fatal("unexpected duplicates => ", duplicates, err)
+	}
+}
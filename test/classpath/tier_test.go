@@ -0,0 +1,93 @@
+package classpath
+
+import (
+	"archive/zip"
+	"errors"
+	"gava/jvm"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 构造一个最小的jre目录结构
+func makeJre(ctx *testing.T) string {
+	var jre = filepath.Join(ctx.TempDir(), "jre")
+	if err := os.MkdirAll(filepath.Join(jre, "lib", "ext"), 0755); err != nil {
+		ctx.Fatal(err)
+	}
+	// a.jar 排在 rt.jar 之前，但 rt.jar 需要最先被查找
+	writeJar(ctx, filepath.Join(jre, "lib", "a.jar"),
+		jarFile{name: "java/lang/Object.class", data: []byte("a"), method: zip.Deflate},
+		jarFile{name: "java/lang/A.class", data: []byte("a"), method: zip.Deflate},
+	)
+	writeJar(ctx, filepath.Join(jre, "lib", "rt.jar"),
+		jarFile{name: "java/lang/Object.class", data: []byte("rt"), method: zip.Deflate},
+	)
+	writeJar(ctx, filepath.Join(jre, "lib", "ext", "ext.jar"),
+		jarFile{name: "com/acme/Ext.class", data: []byte("ext"), method: zip.Deflate},
+	)
+	return jre
+}
+
+func TestClasspathTierOrder(ctx *testing.T) {
+	var jre = makeJre(ctx)
+	var user = ctx.TempDir()
+	writeClass(ctx, user, "java/lang/Object", []byte("user"))
+	writeClass(ctx, user, "com/acme/Ext", []byte("user"))
+	writeClass(ctx, user, "com/acme/Main", []byte("user"))
+
+	var classpath = mustClasspath(ctx, jre, user)
+	var cases = []struct {
+		name   string
+		expect string
+		from   string
+	}{
+		{"java/lang/Object", "rt", filepath.Join(jre, "lib", "rt.jar")},
+		{"java/lang/A", "a", filepath.Join(jre, "lib", "a.jar")},
+		{"com/acme/Ext", "ext", filepath.Join(jre, "lib", "ext", "ext.jar")},
+		{"com/acme/Main", "user", user},
+	}
+	for _, c := range cases {
+		var data, from, err = classpath.ReadClass(c.name)
+		if err != nil {
+			ctx.Fatal(c.name, err)
+		}
+		if string(data) != c.expect || from.String() != c.from {
+			ctx.Fatal(c.name, " => unexpected result ", string(data), " from ", from)
+		}
+	}
+}
+
+func TestClasspathJdkHomeLayout(ctx *testing.T) {
+	// 传入jdk目录时，使用其下的jre目录
+	var jre = makeJre(ctx)
+	var classpath = mustClasspath(ctx, filepath.Dir(jre), ctx.TempDir())
+	var data, _, err = classpath.ReadClass("java/lang/Object")
+	if err != nil || string(data) != "rt" {
+		ctx.Fatal("unexpected result => ", string(data), err)
+	}
+}
+
+func TestClasspathParseClassPreview(ctx *testing.T) {
+	var jre = makeJre(ctx)
+	var user = ctx.TempDir()
+	var bytecode, err = jvm.NewClassBuilder(jvm.ACC_PUBLIC|jvm.ACC_SUPER, "com/acme/Preview", "java/lang/Object").Version(65, 0xFFFF).Bytes()
+	if err != nil {
+		ctx.Fatal(err)
+	}
+	writeClass(ctx, user, "com/acme/Preview", bytecode)
+	// 预览特性由各个classpath的选项决定
+	var strict = mustClasspath(ctx, jre, user)
+	var versionError *jvm.UnsupportedClassVersionError
+	if _, _, err = strict.ParseClass("com/acme/Preview"); !errors.As(err, &versionError) {
+		ctx.Fatal("expect UnsupportedClassVersionError => ", err)
+	}
+	var preview *jvm.Classpath
+	if preview, err = jvm.NewClasspathWithOptions(jre, user, jvm.ClasspathOptions{EnablePreview: true}); err != nil {
+		ctx.Fatal(err)
+	}
+	var javaClass, from, parseErr = preview.ParseClass("com/acme/Preview")
+	if parseErr != nil || javaClass.ClassName() != "com/acme/Preview" || from.String() != user {
+		ctx.Fatal("unexpected result => ", javaClass, from, parseErr)
+	}
+}
@@ -0,0 +1,60 @@
+package classpath
+
+import (
+	"archive/zip"
+	"gava/jvm"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWildcardClassEntry(ctx *testing.T) {
+	var root = ctx.TempDir()
+	var lib = filepath.Join(root, "lib")
+	if err := os.MkdirAll(filepath.Join(lib, "nested"), 0755); err != nil {
+		ctx.Fatal(err)
+	}
+	writeJar(ctx, filepath.Join(lib, "a.jar"), jarFile{name: "com/acme/A.class", data: []byte("a"), method: zip.Deflate})
+	writeJar(ctx, filepath.Join(lib, "B.JAR"), jarFile{name: "com/acme/B.class", data: []byte("b"), method: zip.Store})
+	// 子目录中的jar不会被展开
+	writeJar(ctx, filepath.Join(lib, "nested", "c.jar"), jarFile{name: "com/acme/C.class", data: []byte("c"), method: zip.Store})
+	if err := ioutil.WriteFile(filepath.Join(lib, "notes.txt"), []byte("txt"), 0644); err != nil {
+		ctx.Fatal(err)
+	}
+
+	var entry = mustClassEntry(ctx, "  "+filepath.Join(lib, "*")+" ")
+	for _, name := range []string{"com/acme/A", "com/acme/B"} {
+		if _, _, err := entry.ReadClass(name); err != nil {
+			ctx.Fatal(name, err)
+		}
+	}
+	if _, _, err := entry.ReadClass("com/acme/C"); !jvm.IsClassNotFound(err) {
+		ctx.Fatal("expect class not found, got => ", err)
+	}
+}
+
+func TestClasspathFromEnvironment(ctx *testing.T) {
+	var jre = makeJre(ctx)
+	var user = ctx.TempDir()
+	writeClass(ctx, user, "com/acme/Main", []byte("env"))
+
+	var old, ok = os.LookupEnv("CLASSPATH")
+	os.Setenv("CLASSPATH", user)
+	defer func() {
+		if ok {
+			os.Setenv("CLASSPATH", old)
+		} else {
+			os.Unsetenv("CLASSPATH")
+		}
+	}()
+
+	var classpath = mustClasspath(ctx, jre, "")
+	var data, from, err = classpath.ReadClass("com.acme.Main")
+	if err != nil {
+		ctx.Fatal(err)
+	}
+	if string(data) != "env" || from.String() != user {
+		ctx.Fatal("unexpected result => ", string(data), " from ", from)
+	}
+}
@@ -0,0 +1,41 @@
+package manifest_test
+
+import (
+	"gava/jvm"
+	"reflect"
+	"testing"
+)
+
+func TestParseManifest(ctx *testing.T) {
+	var data = "Manifest-Version: 1.0\r\n" +
+		"Main-Class: com.acme.very.long.pack\r\n" +
+		" age.Main\r\n" +
+		"Class-Path: lib/a.jar lib/b.jar\r\n" +
+		"  lib/c.jar\r\n" +
+		"\r\n" +
+		"Name: com/acme/\r\n" +
+		"Main-Class: com.acme.Other\r\n"
+	var manifest, err = jvm.ParseManifest([]byte(data))
+	if err != nil {
+		ctx.Fatal(err)
+	}
+	if manifest.MainClass() != "com.acme.very.long.package.Main" {
+		ctx.Fatal("unexpected Main-Class => ", manifest.MainClass())
+	}
+	if manifest.Get("manifest-version") != "1.0" {
+		ctx.Fatal("attribute names should be case insensitive")
+	}
+	var expect = []string{"lib/a.jar", "lib/b.jar", "lib/c.jar"}
+	if !reflect.DeepEqual(manifest.ClassPath(), expect) {
+		ctx.Fatal("unexpected Class-Path => ", manifest.ClassPath())
+	}
+}
+
+func TestParseInvalidManifest(ctx *testing.T) {
+	if _, err := jvm.ParseManifest([]byte(" continuation\n")); err == nil {
+		ctx.Fatal("expect error for leading continuation line")
+	}
+	if _, err := jvm.ParseManifest([]byte("Main-Class com.acme.Main\n")); err == nil {
+		ctx.Fatal("expect error for header without separator")
+	}
+}